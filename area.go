@@ -0,0 +1,115 @@
+package gocantile
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+)
+
+// TileArea returns the area of the tile in square meters on the WGS84
+// ellipsoid. If p is nil, a projector is created from the TMS CRS.
+func (t *TileMatrixSet) TileArea(tile grid.Tile, p grid.Projector) (float64, error) {
+	adapter, p, err := t.geodesicMatrix(tile, p)
+	if err != nil {
+		return 0, err
+	}
+	return adapter.GeodesicArea(tile.TileIndex, p)
+}
+
+// TilePerimeter returns the length of the tile outline in meters on the WGS84
+// ellipsoid. If p is nil, a projector is created from the TMS CRS.
+func (t *TileMatrixSet) TilePerimeter(tile grid.Tile, p grid.Projector) (float64, error) {
+	adapter, p, err := t.geodesicMatrix(tile, p)
+	if err != nil {
+		return 0, err
+	}
+	return adapter.GeodesicPerimeter(tile.TileIndex, p)
+}
+
+// TileCentroid returns the lon/lat (degrees) centroid of the tile on the WGS84
+// ellipsoid. If p is nil, a projector is created from the TMS CRS.
+func (t *TileMatrixSet) TileCentroid(tile grid.Tile, p grid.Projector) (orb.Point, error) {
+	adapter, p, err := t.geodesicMatrix(tile, p)
+	if err != nil {
+		return orb.Point{}, err
+	}
+	return adapter.GeodesicCentroid(tile.TileIndex, p)
+}
+
+func (t *TileMatrixSet) geodesicMatrix(tile grid.Tile, p grid.Projector) (grid.TileMatrix, grid.Projector, error) {
+	adapter, err := t.eastingNorthingMatrix(tile)
+	if err != nil {
+		return grid.TileMatrix{}, nil, err
+	}
+	if p == nil {
+		pp, err := grid.ProjectorFromTMS(t.TileMatrixSet)
+		if err != nil {
+			return grid.TileMatrix{}, nil, err
+		}
+		p = pp
+	}
+	return adapter, p, nil
+}
+
+// AreaSummary summarizes the areas in square meters of a group of tiles.
+type AreaSummary struct {
+	Count     int
+	TotalArea float64
+	MinArea   float64
+	MaxArea   float64
+	MeanArea  float64
+}
+
+func (s *AreaSummary) add(area float64) {
+	if s.Count == 0 {
+		s.MinArea, s.MaxArea = area, area
+	}
+	s.Count++
+	s.TotalArea += area
+	s.MinArea = math.Min(s.MinArea, area)
+	s.MaxArea = math.Max(s.MaxArea, area)
+	s.MeanArea = s.TotalArea / float64(s.Count)
+}
+
+// TilesAreaSummary summarizes the areas of a tiles list overall and per zoom
+// level. Overlapping tiles of different zoom levels are counted each.
+type TilesAreaSummary struct {
+	AreaSummary
+	ByZoom map[int]AreaSummary
+}
+
+// Zooms returns the zoom levels of the summary in ascending order.
+func (s TilesAreaSummary) Zooms() []int {
+	zooms := make([]int, 0, len(s.ByZoom))
+	for z := range s.ByZoom {
+		zooms = append(zooms, z)
+	}
+	sort.Ints(zooms)
+	return zooms
+}
+
+// SummarizeTileAreas returns the area summary of the tiles on the WGS84
+// ellipsoid. If p is nil, a projector is created from the TMS CRS.
+func (t *TileMatrixSet) SummarizeTileAreas(tiles grid.TilesList, p grid.Projector) (TilesAreaSummary, error) {
+	summary := TilesAreaSummary{ByZoom: map[int]AreaSummary{}}
+	if p == nil {
+		pp, err := grid.ProjectorFromTMS(t.TileMatrixSet)
+		if err != nil {
+			return TilesAreaSummary{}, err
+		}
+		p = pp
+	}
+	for _, tile := range tiles {
+		area, err := t.TileArea(tile, p)
+		if err != nil {
+			return TilesAreaSummary{}, err
+		}
+		summary.add(area)
+		zs := summary.ByZoom[tile.Zoom]
+		zs.add(area)
+		summary.ByZoom[tile.Zoom] = zs
+	}
+	return summary, nil
+}
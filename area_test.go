@@ -0,0 +1,142 @@
+package gocantile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+const wgs84Area = 5.100656217240886e14
+
+// polarProjector is a north polar azimuthal equidistant projection on the
+// sphere in degrees from the pole, standing in for UPS.
+type polarProjector struct{}
+
+func (polarProjector) Forward(lon, lat float64) (float64, float64, error) {
+	r := 90 - lat
+	s, c := math.Sincos(lon * math.Pi / 180)
+	return r * s, -r * c, nil
+}
+
+func (polarProjector) Inverse(x, y float64) (float64, float64, error) {
+	return math.Atan2(x, -y) * 180 / math.Pi, 90 - math.Hypot(x, y), nil
+}
+
+func zoomTiles(t *testing.T, set *TileMatrixSet, zoom int) grid.TilesList {
+	t.Helper()
+	adapter, err := set.tileMatrix(zoom)
+	if err != nil {
+		t.Fatalf("tile matrix: %v", err)
+	}
+	_, height := adapter.MatrixSize()
+	var tiles grid.TilesList
+	for row := 0; row < height; row++ {
+		for col := 0; col < adapter.RowWidth(row); col++ {
+			tiles = append(tiles, Tile{Zoom: zoom, TileIndex: TileIndex{Col: col, Row: row}})
+		}
+	}
+	return tiles
+}
+
+func TestTileAreaGlobal(t *testing.T) {
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	summary, err := crs84.SummarizeTileAreas(zoomTiles(t, crs84, 0), nil)
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if math.Abs(summary.TotalArea-wgs84Area)/wgs84Area > 1e-9 {
+		t.Fatalf("total area %v, want %v", summary.TotalArea, wgs84Area)
+	}
+
+	// GNOSISGlobalGrid is Lat,Lon with coalesced rows towards the poles:
+	// the tiles still cover the globe, but differ in size.
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	summary, err = gnosis.SummarizeTileAreas(append(zoomTiles(t, gnosis, 1), zoomTiles(t, gnosis, 2)...), nil)
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if got := summary.Zooms(); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("zooms %v", got)
+	}
+	for _, z := range summary.Zooms() {
+		zs := summary.ByZoom[z]
+		if math.Abs(zs.TotalArea-wgs84Area)/wgs84Area > 1e-9 {
+			t.Fatalf("zoom %d: total area %v, want %v", z, zs.TotalArea, wgs84Area)
+		}
+		if math.Abs(zs.MeanArea-wgs84Area/float64(zs.Count))/wgs84Area > 1e-9 {
+			t.Fatalf("zoom %d: mean area %v", z, zs.MeanArea)
+		}
+		if zs.MinArea >= zs.MaxArea {
+			t.Fatalf("zoom %d: min %v, max %v", z, zs.MinArea, zs.MaxArea)
+		}
+	}
+	if summary.Count != summary.ByZoom[1].Count+summary.ByZoom[2].Count {
+		t.Fatalf("count %d", summary.Count)
+	}
+
+	// The perimeter of a zoom 1 WorldCRS84Quad tile: a quarter of the
+	// equator, two meridians from pole to equator and a degenerate pole
+	// edge.
+	p, err := crs84.TilePerimeter(Tile{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 1}}, nil)
+	if err != nil {
+		t.Fatalf("perimeter: %v", err)
+	}
+	if want := math.Pi*6378137.0/2 + 2*10001965.729; math.Abs(p-want) > 1 {
+		t.Fatalf("perimeter %v, want %v", p, want)
+	}
+}
+
+func TestTileAreaPolar(t *testing.T) {
+	set, err := NewCustomTileMatrixSet("polar", "EPSG:5041", grid.Bounds{MinX: -40, MinY: -40, MaxX: 40, MaxY: 40}, 256, 2)
+	if err != nil {
+		t.Fatalf("custom TMS: %v", err)
+	}
+	p := polarProjector{}
+	root, err := set.TileArea(Tile{}, p)
+	if err != nil {
+		t.Fatalf("area: %v", err)
+	}
+	// The tile contains the cap north of 50°N, (1-sin 50°)/2 of the globe,
+	// and lies within the cap north of 90-40*sqrt(2) ≈ 33.4°N.
+	if root < 0.117*wgs84Area || root > 0.225*wgs84Area {
+		t.Fatalf("root area %v", root)
+	}
+	for z := 1; z <= 2; z++ {
+		summary, err := set.SummarizeTileAreas(zoomTiles(t, set, z), p)
+		if err != nil {
+			t.Fatalf("summary: %v", err)
+		}
+		if math.Abs(summary.TotalArea-root)/root > 1e-4 {
+			t.Fatalf("zoom %d: total %v, want %v", z, summary.TotalArea, root)
+		}
+	}
+
+	c, err := set.TileCentroid(Tile{}, p)
+	if err != nil {
+		t.Fatalf("centroid: %v", err)
+	}
+	if c[1] < 89.999 {
+		t.Fatalf("centroid %v, want the pole", c)
+	}
+}
+
+func TestTileCentroid(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	c, err := set.TileCentroid(Tile{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 0}}, nil)
+	if err != nil {
+		t.Fatalf("centroid: %v", err)
+	}
+	if math.Abs(c[0]+90) > 1e-9 || c[1] < 20 || c[1] > 50 {
+		t.Fatalf("centroid %v", c)
+	}
+	if _, err := set.TileCentroid(Tile{}, nil); err == nil {
+		t.Fatalf("expected error for the centroid of a tile around the globe")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func runCustom(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("custom", "", stderr)
+	crs := fs.String("crs", "", "CRS of the TileMatrixSet (e.g. EPSG:3857)")
+	extent := fs.String("extent", "", "extent in CRS units as minx,miny,maxx,maxy")
+	identifier := fs.String("identifier", "Custom", "identifier of the new TileMatrixSet")
+	tileSize := fs.Int("tile-size", 256, "tile width and height in pixels")
+	maxZoom := fs.Int("maxzoom", 24, "maximum zoom level")
+	indent := fs.Int("indent", 2, "JSON indentation level")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *crs == "" || *extent == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	b, err := parseExtent(*extent)
+	if err != nil {
+		return err
+	}
+	set, err := gocantile.NewCustomTileMatrixSet(*identifier, *crs, b, *tileSize, *maxZoom)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, set.TileMatrixSet, *indent)
+}
+
+func parseExtent(s string) (grid.Bounds, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return grid.Bounds{}, fmt.Errorf("invalid extent %q: expected minx,miny,maxx,maxy", s)
+	}
+	var v [4]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return grid.Bounds{}, fmt.Errorf("invalid extent %q: %w", s, err)
+		}
+		v[i] = f
+	}
+	return grid.Bounds{MinX: v[0], MinY: v[1], MaxX: v[2], MaxY: v[3]}, nil
+}
@@ -0,0 +1,96 @@
+// Command gocantile is a command-line companion to the gocantile library,
+// modelled after the morecantile CLI.
+//
+// Usage:
+//
+//	gocantile tiles [flags] ZOOM      GeoJSON features on stdin -> [x, y, z] lines
+//	gocantile shapes [flags]          [x, y, z] lines on stdin -> GeoJSON polygons
+//	gocantile tms [flags]             print an embedded TileMatrixSet
+//	gocantile custom [flags]          build a TileMatrixSet from an extent and CRS
+//	gocantile validate [flags] FILE   validate TileMatrixSet/TileSet JSON files
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{"tiles", "list tiles covering GeoJSON features read from stdin", runTiles},
+	{"shapes", "write GeoJSON polygons for tiles read from stdin", runShapes},
+	{"tms", "print an embedded TileMatrixSet", runTMS},
+	{"custom", "build a custom TileMatrixSet from an extent and CRS", runCustom},
+	{"validate", "validate TileMatrixSet or TileSet JSON files", runValidate},
+}
+
+// errUsage signals that the flag set already reported a usage problem.
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		if err := cmd.run(args[1:], stdin, stdout, stderr); err != nil {
+			if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+				return 2
+			}
+			fmt.Fprintf(stderr, "gocantile %s: %v\n", cmd.name, err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "gocantile: unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: gocantile <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'gocantile <command> -h' for command flags.")
+}
+
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: gocantile %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func writeJSON(w io.Writer, v interface{}, indent int) error {
+	enc := json.NewEncoder(w)
+	if indent > 0 {
+		enc.SetIndent("", fmt.Sprintf("%*s", indent, ""))
+	}
+	return enc.Encode(v)
+}
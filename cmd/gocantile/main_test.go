@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paulmach/orb/geojson"
+)
+
+func runCLI(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestCLITiles(t *testing.T) {
+	feature := `{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[[[1,1],[2,1],[2,2],[1,2],[1,1]]]}}`
+	stdout, stderr, code := runCLI(t, feature+"\n"+feature, "tiles", "1")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	if strings.TrimSpace(stdout) != "[1,0,1]" {
+		t.Fatalf("unexpected tiles output %q", stdout)
+	}
+}
+
+func TestCLITilesFeatureCollection(t *testing.T) {
+	fc := `{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{},"geometry":{"type":"Point","coordinates":[-10,10]}},
+		{"type":"Feature","properties":{},"geometry":{"type":"Point","coordinates":[10,-10]}}
+	]}`
+	stdout, stderr, code := runCLI(t, fc, "tiles", "1")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 || lines[0] != "[0,0,1]" || lines[1] != "[1,1,1]" {
+		t.Fatalf("unexpected tiles output %q", stdout)
+	}
+}
+
+func TestCLIShapes(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "[0, 0, 0]\n", "shapes", "-precision", "6")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	f, err := geojson.UnmarshalFeature([]byte(stdout))
+	if err != nil {
+		t.Fatalf("unmarshal feature: %v", err)
+	}
+	b := f.Geometry.Bound()
+	if b.Min[0] != -180 || b.Max[0] != 180 || b.Max[1] < 85 || b.Max[1] > 85.1 {
+		t.Fatalf("unexpected shape bounds %+v", b)
+	}
+	if f.Properties["title"] != "XYZ tile (0, 0, 0)" {
+		t.Fatalf("unexpected title %v", f.Properties["title"])
+	}
+}
+
+func TestCLIShapesProjectedCollect(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "[0, 0, 1]\n[1, 1, 1]\n", "shapes", "-projected", "-collect")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	fc, err := geojson.UnmarshalFeatureCollection([]byte(stdout))
+	if err != nil {
+		t.Fatalf("unmarshal collection: %v", err)
+	}
+	if len(fc.Features) != 2 {
+		t.Fatalf("expected 2 features, got %d", len(fc.Features))
+	}
+	if fc.Features[0].Properties["grid_crs"] != "EPSG:3857" {
+		t.Fatalf("unexpected grid_crs %v", fc.Features[0].Properties["grid_crs"])
+	}
+	if b := fc.Features[1].Geometry.Bound(); math.Abs(b.Min[0]) > 1e-6 || math.Abs(b.Max[1]) > 1e-6 {
+		t.Fatalf("unexpected projected bounds %+v", b)
+	}
+}
+
+func TestCLITMS(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "tms", "-identifier", "WebMercatorQuad")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+		t.Fatalf("unmarshal tms: %v", err)
+	}
+	if out["id"] != "WebMercatorQuad" {
+		t.Fatalf("unexpected id %v", out["id"])
+	}
+
+	stdout, _, code = runCLI(t, "", "tms")
+	if code != 0 || !strings.Contains(stdout, "WorldCRS84Quad") {
+		t.Fatalf("expected list of identifiers, got %q (exit %d)", stdout, code)
+	}
+}
+
+func TestCLICustomAndValidate(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "custom", "-crs", "EPSG:3857", "-extent", "0,0,1000,1000", "-maxzoom", "2")
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr)
+	}
+	vout, stderr, code := runCLI(t, stdout, "validate", "-")
+	if code != 0 {
+		t.Fatalf("expected custom TMS to validate, got %d: %s %s", code, vout, stderr)
+	}
+
+	path := filepath.Join("..", "..", "data", "tilematrixset", "WorldCRS84Quad.json")
+	vout, stderr, code = runCLI(t, "", "validate", path)
+	if code != 0 {
+		t.Fatalf("expected embedded TMS to validate, got %d: %s %s", code, vout, stderr)
+	}
+	// The sample TileSet encodes geometryDimension as a string.
+	path = filepath.Join("..", "..", "data", "tileset", "tiles.WebMercatorQuad.json")
+	vout, _, code = runCLI(t, "", "validate", "-type", "tileset", path)
+	if code != 1 || !strings.Contains(vout, "geometryDimension") {
+		t.Fatalf("expected tileset schema error, got %d: %s", code, vout)
+	}
+	if _, _, code := runCLI(t, `{"crs":"EPSG:3857"}`, "validate", "-"); code != 1 {
+		t.Fatalf("expected exit 1 for invalid TMS, got %d", code)
+	}
+}
+
+func TestCLIUsageErrors(t *testing.T) {
+	if _, _, code := runCLI(t, ""); code != 2 {
+		t.Fatalf("expected exit 2 without command, got %d", code)
+	}
+	if _, _, code := runCLI(t, "", "nope"); code != 2 {
+		t.Fatalf("expected exit 2 for unknown command, got %d", code)
+	}
+	if _, _, code := runCLI(t, "", "tiles"); code != 2 {
+		t.Fatalf("expected exit 2 for missing zoom, got %d", code)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func runShapes(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("shapes", "", stderr)
+	identifier := fs.String("identifier", "WebMercatorQuad", "embedded TileMatrixSet identifier")
+	projected := fs.Bool("projected", false, "write coordinates in the TileMatrixSet CRS instead of lon/lat")
+	extents := fs.Bool("extents", false, "write [minx, miny, maxx, maxy] extents instead of features")
+	densify := fs.Int("densify", 0, "number of points to add along each tile edge")
+	collect := fs.Bool("collect", false, "write a single FeatureCollection")
+	precision := fs.Int("precision", -1, "decimal places of output coordinates (-1 keeps full precision)")
+	indent := fs.Int("indent", 0, "JSON indentation level")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	set, err := gocantile.LoadTileMatrixSet(*identifier)
+	if err != nil {
+		return err
+	}
+	crs := ""
+	if *projected {
+		p, err := gocantile.ProjectorFromTMS(set)
+		if err != nil {
+			return err
+		}
+		crs = p.TargetCRS
+	}
+
+	tiles, err := readTiles(stdin)
+	if err != nil {
+		return err
+	}
+
+	opts := gocantile.GeoJSONOptions{LonLat: !*projected, Densify: *densify}
+	fc := geojson.NewFeatureCollection()
+	for _, tile := range tiles {
+		if *extents {
+			var b grid.Bounds
+			if *projected {
+				b, err = set.XYBounds(tile)
+			} else {
+				b, err = set.Bounds(tile, nil)
+			}
+			if err != nil {
+				return err
+			}
+			if *precision >= 0 {
+				b = roundBounds(b, *precision)
+			}
+			if err := writeJSON(stdout, []float64{b.MinX, b.MinY, b.MaxX, b.MaxY}, *indent); err != nil {
+				return err
+			}
+			continue
+		}
+
+		f, err := set.TileFeature(tile, opts)
+		if err != nil {
+			return err
+		}
+		if *precision >= 0 {
+			f.Geometry = orb.Round(f.Geometry, int(math.Pow10(*precision)))
+		}
+		f.BBox = geojson.NewBBox(f.Geometry.Bound())
+		f.ID = fmt.Sprintf("(%d, %d, %d)", tile.Col, tile.Row, tile.Zoom)
+		f.Properties["grid_name"] = *identifier
+		if crs != "" {
+			f.Properties["grid_crs"] = crs
+		}
+		if *collect {
+			fc.Append(f)
+			continue
+		}
+		if err := writeJSON(stdout, f, *indent); err != nil {
+			return err
+		}
+	}
+	if *collect && !*extents {
+		return writeJSON(stdout, fc, *indent)
+	}
+	return nil
+}
+
+// readTiles decodes a stream of [x, y, z] JSON arrays.
+func readTiles(r io.Reader) ([]grid.Tile, error) {
+	dec := json.NewDecoder(r)
+	var tiles []grid.Tile
+	for {
+		var xyz []int
+		if err := dec.Decode(&xyz); err == io.EOF {
+			return tiles, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("decode tile: %w", err)
+		}
+		if len(xyz) != 3 {
+			return nil, fmt.Errorf("expected [x, y, z], got %v", xyz)
+		}
+		tiles = append(tiles, grid.Tile{Zoom: xyz[2], TileIndex: grid.TileIndex{Col: xyz[0], Row: xyz[1]}})
+	}
+}
+
+func roundBounds(b grid.Bounds, precision int) grid.Bounds {
+	factor := math.Pow10(precision)
+	r := func(v float64) float64 {
+		return math.Round(v*factor) / factor
+	}
+	return grid.Bounds{MinX: r(b.MinX), MinY: r(b.MinY), MaxX: r(b.MaxX), MaxY: r(b.MaxY)}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func runTiles(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("tiles", "ZOOM", stderr)
+	identifier := fs.String("identifier", "WebMercatorQuad", "embedded TileMatrixSet identifier")
+	crs := fs.String("crs", "EPSG:4326", "CRS of the input features")
+	buffer := fs.Float64("buffer", 0, "buffer around the features in TileMatrixSet CRS units")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	zoom, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid zoom %q", fs.Arg(0))
+	}
+
+	set, err := gocantile.LoadTileMatrixSet(*identifier)
+	if err != nil {
+		return err
+	}
+	geoms, err := readGeometries(stdin)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[grid.Tile]struct{})
+	for _, g := range geoms {
+		tiles, err := set.TilesForGeometryWithEPSG(g, *crs, zoom, zoom, *buffer)
+		if err != nil {
+			return err
+		}
+		for _, tile := range tiles {
+			if _, ok := seen[tile]; ok {
+				continue
+			}
+			seen[tile] = struct{}{}
+			if err := writeJSON(stdout, []int{tile.Col, tile.Row, tile.Zoom}, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readGeometries decodes a stream of GeoJSON objects: FeatureCollections,
+// Features or bare geometries, either as one document or one per line. RFC
+// 8142 record separators are ignored.
+func readGeometries(r io.Reader) ([]orb.Geometry, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(bytes.ReplaceAll(raw, []byte{0x1e}, nil)))
+	var geoms []orb.Geometry
+	for {
+		var msg json.RawMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			return geoms, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("decode GeoJSON: %w", err)
+		}
+		var head struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(msg, &head); err != nil {
+			return nil, fmt.Errorf("decode GeoJSON: %w", err)
+		}
+		switch head.Type {
+		case "FeatureCollection":
+			fc, err := geojson.UnmarshalFeatureCollection(msg)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range fc.Features {
+				if f.Geometry != nil {
+					geoms = append(geoms, f.Geometry)
+				}
+			}
+		case "Feature":
+			f, err := geojson.UnmarshalFeature(msg)
+			if err != nil {
+				return nil, err
+			}
+			if f.Geometry != nil {
+				geoms = append(geoms, f.Geometry)
+			}
+		default:
+			g, err := geojson.UnmarshalGeometry(msg)
+			if err != nil {
+				return nil, err
+			}
+			geoms = append(geoms, g.Geometry())
+		}
+	}
+}
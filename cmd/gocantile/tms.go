@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hafenkran/gocantile"
+)
+
+func runTMS(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("tms", "", stderr)
+	identifier := fs.String("identifier", "", "embedded TileMatrixSet identifier (lists all identifiers if empty)")
+	indent := fs.Int("indent", 2, "JSON indentation level")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	if *identifier == "" {
+		for _, name := range gocantile.AvailableTileMatrixSets() {
+			fmt.Fprintln(stdout, name)
+		}
+		return nil
+	}
+	set, err := gocantile.LoadTileMatrixSet(*identifier)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, set.TileMatrixSet, *indent)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hafenkran/gocantile/validate"
+)
+
+func runValidate(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("validate", "FILE...", stderr)
+	kind := fs.String("type", "tms", "document type: tms (TileMatrixSet) or tileset (TileSet)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	var check func([]byte) error
+	switch *kind {
+	case "tms":
+		check = validate.ValidateTileMatrixSetJSON
+	case "tileset":
+		check = validate.ValidateTileSetJSON
+	default:
+		return fmt.Errorf("unknown type %q", *kind)
+	}
+
+	failed := 0
+	for _, name := range fs.Args() {
+		var raw []byte
+		var err error
+		if name == "-" {
+			raw, err = io.ReadAll(stdin)
+		} else {
+			raw, err = os.ReadFile(name)
+		}
+		if err == nil {
+			err = check(raw)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(stdout, "%s: invalid: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: valid\n", name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files invalid", failed, fs.NArg())
+	}
+	return nil
+}
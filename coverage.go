@@ -0,0 +1,207 @@
+package gocantile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+)
+
+// BufferUnit is the unit of a coverage buffer.
+type BufferUnit string
+
+const (
+	// BufferCRSUnits gives the buffer in units of the TMS CRS, the same at
+	// every zoom level.
+	BufferCRSUnits BufferUnit = "crs"
+	// BufferPixels gives the buffer in pixels of each zoom level, from the
+	// cell size of its tile matrix, e.g. for the gutter of vector tiles.
+	BufferPixels BufferUnit = "pixels"
+	// BufferMeters gives the buffer as a ground distance, converted into CRS
+	// units at the center of the geometry. In geographic sets it is
+	// converted along the parallel, so north and south it is at least the
+	// distance given.
+	BufferMeters BufferUnit = "meters"
+)
+
+// coverTolerance is the share of a tile's area by which parts of a split
+// geometry may fall short of covering it and still count as covering it.
+const coverTolerance = 1e-9
+
+// CoverageOptions controls TilesForGeometryWithOptions.
+type CoverageOptions struct {
+	// SourceCRS is the CRS of the geometry, e.g. "EPSG:4326", with
+	// coordinates easting first. Lon/lat geometries are split at the
+	// antimeridian and closed around the poles as in
+	// TilesForGeometryWithEPSG. Empty means the CRS of the set, with
+	// coordinates in its axis order as in TilesForGeometry, used as they are.
+	SourceCRS string
+	// Mode selects the tiles that cover the geometry and defaults to
+	// grid.CoverIntersects.
+	Mode grid.CoverMode
+	// Buffer is the distance by which the geometry grows before it is
+	// covered. It cannot be used with grid.CoverWithin.
+	Buffer float64
+	// BufferUnit defaults to BufferCRSUnits.
+	BufferUnit BufferUnit
+}
+
+// CoveredTile is a tile with the share of its area covered by a geometry.
+type CoveredTile struct {
+	grid.Tile
+	// Fraction is the share of the tile area covered by the polygons of the
+	// geometry, from 0 to 1. The buffer is not included.
+	Fraction float64
+}
+
+// TilesForGeometryWithOptions returns the tiles across zoom levels
+// [minZoom, maxZoom] inclusive that cover the geometry in opts.Mode, sorted
+// by zoom, row and column. Unlike TilesForGeometry, tiles are tested against
+// the geometry itself rather than its bounds, and the buffer applies to the
+// geometry.
+func (t *TileMatrixSet) TilesForGeometryWithOptions(g orb.Geometry, minZoom, maxZoom int, opts CoverageOptions) (grid.TilesList, error) {
+	covered, err := t.coverGeometry(g, minZoom, maxZoom, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	tiles := make(grid.TilesList, len(covered))
+	for i, c := range covered {
+		tiles[i] = c.Tile
+	}
+	return tiles, nil
+}
+
+// CoveredTilesForGeometry is TilesForGeometryWithOptions with the share of
+// each tile covered by the geometry, e.g. to decide where to subdivide.
+func (t *TileMatrixSet) CoveredTilesForGeometry(g orb.Geometry, minZoom, maxZoom int, opts CoverageOptions) ([]CoveredTile, error) {
+	return t.coverGeometry(g, minZoom, maxZoom, opts, true)
+}
+
+// coverGeometry projects the geometry into the TMS CRS and covers it part by
+// part. Fractions of the parts add up, so a tile is within a split geometry
+// when its parts cover it together, and it contains the geometry when it
+// contains every part.
+func (t *TileMatrixSet) coverGeometry(g orb.Geometry, minZoom, maxZoom int, opts CoverageOptions, fraction bool) ([]CoveredTile, error) {
+	if opts.Buffer < 0 || math.IsNaN(opts.Buffer) || math.IsInf(opts.Buffer, 0) {
+		return nil, fmt.Errorf("invalid buffer %v", opts.Buffer)
+	}
+	switch opts.BufferUnit {
+	case BufferCRSUnits, BufferPixels, BufferMeters, "":
+	default:
+		return nil, fmt.Errorf("unknown buffer unit %q", opts.BufferUnit)
+	}
+	switch opts.Mode {
+	case grid.CoverIntersects, grid.CoverCenter, grid.CoverContains:
+	case grid.CoverWithin:
+		if opts.Buffer > 0 {
+			return nil, fmt.Errorf("buffer not supported in %v mode", opts.Mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown coverage mode %v", opts.Mode)
+	}
+	// Geometries in a source CRS are projected easting first; without one
+	// they are in the axis order of the set and used as they are.
+	eastingFirst := opts.SourceCRS != ""
+	var parts []orb.Geometry
+	if eastingFirst {
+		var err error
+		if parts, err = t.projectParts(sourceParts(g, opts.SourceCRS), opts.SourceCRS, minZoom, maxZoom); err != nil {
+			return nil, err
+		}
+	} else {
+		if minZoom < 0 || maxZoom < minZoom || maxZoom > t.MaxZoom() {
+			return nil, fmt.Errorf("invalid zoom range min=%d max=%d", minZoom, maxZoom)
+		}
+		parts = []orb.Geometry{g}
+	}
+	mats, err := t.sortedMatrices()
+	if err != nil {
+		return nil, err
+	}
+
+	split := len(parts) > 1
+	partOpts := grid.CoverOptions{Mode: opts.Mode, Fraction: fraction}
+	if split && opts.Mode == grid.CoverWithin {
+		partOpts = grid.CoverOptions{Mode: grid.CoverIntersects, Fraction: true}
+	}
+	fractions := map[grid.Tile]float64{}
+	counts := map[grid.Tile]int{}
+	for _, part := range parts {
+		unitsPerMeter := 0.0
+		if opts.BufferUnit == BufferMeters && opts.Buffer > 0 {
+			center := part.Bound().Center()
+			if !eastingFirst {
+				center = t.eastingNorthingPoint(grid.TileMatrix{TM: mats[minZoom]}, center)
+			}
+			if unitsPerMeter, err = t.unitsPerMeterAt(center); err != nil {
+				return nil, err
+			}
+		}
+		for z := minZoom; z <= maxZoom; z++ {
+			switch opts.BufferUnit {
+			case BufferPixels:
+				partOpts.Buffer = opts.Buffer * mats[z].CellSize
+			case BufferMeters:
+				partOpts.Buffer = opts.Buffer * unitsPerMeter
+			default:
+				partOpts.Buffer = opts.Buffer
+			}
+			adapter := grid.TileMatrix{TM: mats[z]}
+			if eastingFirst {
+				adapter = t.eastingNorthing(adapter)
+			}
+			adapter.EachTileCovering(part, partOpts, func(c grid.TileCover) bool {
+				tile := grid.Tile{Zoom: z, TileIndex: c.TileIndex}
+				fractions[tile] += c.Fraction
+				counts[tile]++
+				return true
+			})
+		}
+	}
+
+	covered := make([]CoveredTile, 0, len(fractions))
+	for tile, f := range fractions {
+		if split {
+			switch opts.Mode {
+			case grid.CoverWithin:
+				if f < 1-coverTolerance {
+					continue
+				}
+			case grid.CoverContains:
+				if counts[tile] < len(parts) {
+					continue
+				}
+			}
+		}
+		if !fraction {
+			f = 0
+		}
+		covered = append(covered, CoveredTile{Tile: tile, Fraction: math.Min(f, 1)})
+	}
+	sort.Slice(covered, func(i, j int) bool { return covered[i].Tile.Less(covered[j].Tile) })
+	return covered, nil
+}
+
+// unitsPerMeterAt returns the CRS units per ground meter at a point in the
+// TMS CRS.
+func (t *TileMatrixSet) unitsPerMeterAt(pt orb.Point) (float64, error) {
+	crs, err := grid.ExtractCRS(t.TileMatrixSet)
+	if err != nil {
+		return 0, err
+	}
+	p, err := grid.ProjectorFromTMS(t.TileMatrixSet)
+	if err != nil {
+		return 0, err
+	}
+	lon, lat, err := p.Inverse(pt[0], pt[1])
+	if err != nil {
+		return 0, err
+	}
+	mpu, err := grid.GroundMetersPerUnit(p, crs, lon, lat)
+	if err != nil {
+		return 0, err
+	}
+	return 1 / mpu, nil
+}
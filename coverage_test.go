@@ -0,0 +1,185 @@
+package gocantile
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+// tileCenter returns the center of the tile in the TMS CRS.
+func tileCenter(t *testing.T, set *TileMatrixSet, tile Tile) orb.Point {
+	t.Helper()
+	b, err := set.XYBounds(tile)
+	if err != nil {
+		t.Fatalf("bounds: %v", err)
+	}
+	return orb.Point{(b.MinX + b.MaxX) / 2, (b.MinY + b.MaxY) / 2}
+}
+
+func TestTilesForGeometryWithOptionsPixelBuffer(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	center := tileCenter(t, set, Tile{Zoom: 2, TileIndex: TileIndex{Col: 1, Row: 1}})
+	// The center of a tile at zoom 2 is a tile corner from zoom 3 on, so it
+	// touches 4 tiles there. 127 pixels stay short of the neighbours at every
+	// zoom, 129 pixels reach those beside it but not those off its corners.
+	tiles, err := set.TilesForGeometryWithOptions(center, 2, 5, CoverageOptions{Buffer: 127, BufferUnit: BufferPixels})
+	if err != nil {
+		t.Fatalf("tiles: %v", err)
+	}
+	if len(tiles) != 1+4+4+4 {
+		t.Fatalf("127 px: %d tiles %v", len(tiles), tiles)
+	}
+	tiles, err = set.TilesForGeometryWithOptions(center, 2, 2, CoverageOptions{Buffer: 129, BufferUnit: BufferPixels})
+	if err != nil || len(tiles) != 5 {
+		t.Fatalf("129 px: %v, %v", tiles, err)
+	}
+	// The same buffer in CRS units doubles in pixels at zoom 3, where it
+	// reaches the 8 tiles beside the 4 around the corner.
+	res, _ := set.ResolutionForZoom(2)
+	tiles, err = set.TilesForGeometryWithOptions(center, 2, 3, CoverageOptions{Buffer: 129 * res})
+	if err != nil || len(tiles) != 5+12 {
+		t.Fatalf("CRS buffer: %d tiles, %v", len(tiles), err)
+	}
+}
+
+func TestTilesForGeometryWithOptionsMeterBuffer(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	tile, ok, err := set.TileForLonLat(10, 60, 10, nil)
+	if err != nil || !ok {
+		t.Fatalf("tile: %v", err)
+	}
+	center := tileCenter(t, set, tile)
+	res, _ := set.ResolutionForZoom(10)
+	half := 128 * res
+	// At 60°N a meter on the ground spans about 2 Mercator units, so half a
+	// tile is reached with a little over half/2 meters.
+	for _, tc := range []struct {
+		meters float64
+		want   int
+	}{{half / 2 * 0.98, 1}, {half / 2 * 1.02, 5}} {
+		tiles, err := set.TilesForGeometryWithOptions(center, 10, 10, CoverageOptions{Buffer: tc.meters, BufferUnit: BufferMeters})
+		if err != nil || len(tiles) != tc.want {
+			t.Fatalf("%v m: %v, %v", tc.meters, tiles, err)
+		}
+	}
+}
+
+func TestTilesForGeometryWithOptionsGeometry(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	// A diagonal across the north-west quarter of the world at zoom 3 in
+	// lon/lat: its bounds hold 16 tiles, the line only crosses some of them.
+	line := orb.LineString{{-170, 80}, {-10, 5}}
+	bbox, err := set.TilesForGeometryWithEPSG(line, "EPSG:4326", 3, 3, 0)
+	if err != nil {
+		t.Fatalf("bbox tiles: %v", err)
+	}
+	tiles, err := set.TilesForGeometryWithOptions(line, 3, 3, CoverageOptions{SourceCRS: "EPSG:4326"})
+	if err != nil {
+		t.Fatalf("tiles: %v", err)
+	}
+	if len(tiles) == 0 || len(tiles) >= len(bbox) {
+		t.Fatalf("expected fewer tiles than the %d of the bounds, got %v", len(bbox), tiles)
+	}
+	for _, tile := range tiles {
+		if !bbox.Contains(tile) {
+			t.Fatalf("tile %v outside the bounds", tile)
+		}
+	}
+	// Antimeridian splitting applies as in TilesForGeometryWithEPSG.
+	fiji := orb.Polygon{{{177, -16}, {-178, -16}, {-178, -19}, {177, -19}, {177, -16}}}
+	tiles, err = set.TilesForGeometryWithOptions(fiji, 3, 3, CoverageOptions{SourceCRS: "EPSG:4326"})
+	want := TilesList{
+		{Zoom: 3, TileIndex: TileIndex{Col: 0, Row: 4}},
+		{Zoom: 3, TileIndex: TileIndex{Col: 7, Row: 4}},
+	}
+	if err != nil || !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("fiji tiles = %v, %v", tiles, err)
+	}
+}
+
+func TestTilesForGeometryWithOptionsInvalid(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	for _, opts := range []CoverageOptions{
+		{Buffer: -1},
+		{Buffer: math.NaN()},
+		{Buffer: 1, BufferUnit: "furlongs"},
+		{Buffer: 1, Mode: CoverWithin},
+		{Mode: CoverMode(9)},
+	} {
+		if _, err := set.TilesForGeometryWithOptions(orb.Point{0, 0}, 0, 1, opts); err == nil {
+			t.Fatalf("expected error for %+v", opts)
+		}
+	}
+	if _, err := set.TilesForGeometryWithOptions(orb.Point{0, 0}, 2, 1, CoverageOptions{}); err == nil {
+		t.Fatal("expected error for an invalid zoom range")
+	}
+}
+
+func TestTilesForGeometryWithOptionsModes(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	// At zoom 2 the polygon holds tile 2/2/1, which spans 0 to about 1e7 on
+	// both axes, and only its center.
+	poly := orb.Polygon{{{-1e6, -1e6}, {1.5e7, -1e6}, {1.5e7, 1.5e7}, {-1e6, 1.5e7}, {-1e6, -1e6}}}
+	ne := TilesList{{Zoom: 2, TileIndex: TileIndex{Col: 2, Row: 1}}}
+	cases := []struct {
+		mode CoverMode
+		want int
+	}{
+		{CoverIntersects, 9},
+		{CoverWithin, 1},
+		{CoverCenter, 1},
+		{CoverContains, 0},
+	}
+	for _, tc := range cases {
+		tiles, err := set.TilesForGeometryWithOptions(poly, 2, 2, CoverageOptions{Mode: tc.mode})
+		if err != nil || len(tiles) != tc.want {
+			t.Fatalf("%v: %v, %v", tc.mode, tiles, err)
+		}
+		if tc.want == 1 && !reflect.DeepEqual(tiles, ne) {
+			t.Fatalf("%v: %v, want %v", tc.mode, tiles, ne)
+		}
+	}
+	small := orb.Polygon{{{1e6, 1e6}, {2e6, 1e6}, {2e6, 2e6}, {1e6, 1e6}}}
+	tiles, err := set.TilesForGeometryWithOptions(small, 0, 2, CoverageOptions{Mode: CoverContains})
+	if err != nil || len(tiles) != 3 || tiles[2] != ne[0] {
+		t.Fatalf("contains: %v, %v", tiles, err)
+	}
+}
+
+func TestCoveredTilesForGeometry(t *testing.T) {
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// Tiles at zoom 2 span 45° x 45°; Fiji covers 3° x 3° east and 2° x 3°
+	// west of the antimeridian.
+	fiji := orb.Polygon{{{177, -16}, {-178, -16}, {-178, -19}, {177, -19}, {177, -16}}}
+	covered, err := crs84.CoveredTilesForGeometry(fiji, 2, 2, CoverageOptions{SourceCRS: "EPSG:4326"})
+	if err != nil || len(covered) != 2 {
+		t.Fatalf("covered: %v, %v", covered, err)
+	}
+	for i, f := range []float64{6.0 / 2025, 9.0 / 2025} {
+		if math.Abs(covered[i].Fraction-f) > 1e-9 {
+			t.Fatalf("fraction of %v = %v, want %v", covered[i].Tile, covered[i].Fraction, f)
+		}
+	}
+
+	// A cap down to 80°S is split into two halves, which together hold the
+	// 4 tiles around the pole at zoom 6.
+	ups, err := LoadTileMatrixSet("UPSAntarcticWGS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	cap := orb.Polygon{{{0, -80}, {90, -80}, {180, -80}, {-90, -80}, {0, -80}}}
+	covered, err = ups.CoveredTilesForGeometry(cap, 6, 6, CoverageOptions{SourceCRS: "EPSG:4326", Mode: CoverWithin})
+	if err != nil || len(covered) != 4 {
+		t.Fatalf("within cap: %v, %v", covered, err)
+	}
+	for _, c := range covered {
+		if c.Col < 31 || c.Col > 32 || c.Row < 31 || c.Row > 32 || math.Abs(c.Fraction-1) > 1e-9 {
+			t.Fatalf("unexpected tile %+v", c)
+		}
+	}
+}
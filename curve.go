@@ -0,0 +1,88 @@
+package gocantile
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// CurveShift returns the shift of the curve tile ids of the set: the smallest
+// s such that every matrix at zoom z fits into 2^(z+s) x 2^(z+s) tiles. It is
+// 0 for quadtree sets such as WebMercatorQuad, 1 for WorldCRS84Quad and
+// larger for sets that start with several tiles at zoom 0.
+func (t *TileMatrixSet) CurveShift() (int, error) {
+	mats, err := t.sortedMatrices()
+	if err != nil {
+		return 0, err
+	}
+	shift := 0
+	for z, tm := range mats {
+		side := int(max(tm.MatrixWidth, tm.MatrixHeight))
+		if side < 1 {
+			return 0, fmt.Errorf("zoom %d: empty tile matrix", z)
+		}
+		shift = max(shift, bits.Len(uint(side-1))-z)
+	}
+	if top := len(mats) - 1 + shift; top > grid.MaxCurveOrder {
+		return 0, fmt.Errorf("curve order %d exceeds %d", top, grid.MaxCurveOrder)
+	}
+	return shift, nil
+}
+
+// TileCurveID returns the id of the tile along curve c, unique across the
+// zoom levels of the set. Tiles are placed by their XYZ x and y, so for
+// WebMercatorQuad the Hilbert ids are PMTiles tile ids. Other sets use the
+// shift of CurveShift; their ids only compare within the same set.
+func (t *TileMatrixSet) TileCurveID(c grid.Curve, tile grid.Tile) (uint64, error) {
+	shift, err := t.CurveShift()
+	if err != nil {
+		return 0, err
+	}
+	return t.tileCurveID(c, tile, shift)
+}
+
+func (t *TileMatrixSet) tileCurveID(c grid.Curve, tile grid.Tile, shift int) (uint64, error) {
+	adapter, err := t.validTileMatrix(tile)
+	if err != nil {
+		return 0, err
+	}
+	x, y := adapter.XYZ(tile.TileIndex)
+	return grid.CurveTileID(c, grid.Tile{Zoom: tile.Zoom, TileIndex: grid.TileIndex{Col: x, Row: y}}, shift)
+}
+
+// TileFromCurveID returns the tile of an id returned by TileCurveID.
+func (t *TileMatrixSet) TileFromCurveID(c grid.Curve, id uint64) (grid.Tile, error) {
+	shift, err := t.CurveShift()
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	xyz, err := grid.CurveTileFromID(c, id, shift)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	tile, err := t.TileFromXYZ(xyz.Col, xyz.Row, xyz.Zoom)
+	if err != nil {
+		return grid.Tile{}, fmt.Errorf("tile id %d: %w", id, err)
+	}
+	return tile, nil
+}
+
+// SortByCurve sorts the tiles in place by their ids along curve c.
+func (t *TileMatrixSet) SortByCurve(tiles grid.TilesList, c grid.Curve) error {
+	shift, err := t.CurveShift()
+	if err != nil {
+		return err
+	}
+	ids := make(map[grid.Tile]uint64, len(tiles))
+	for _, tile := range tiles {
+		id, err := t.tileCurveID(c, tile, shift)
+		if err != nil {
+			return err
+		}
+		ids[tile] = id
+	}
+	sort.SliceStable(tiles, func(i, j int) bool { return ids[tiles[i]] < ids[tiles[j]] })
+	return nil
+}
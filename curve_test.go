@@ -0,0 +1,94 @@
+package gocantile
+
+import (
+	"testing"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func TestCurveShift(t *testing.T) {
+	cases := map[string]int{
+		"WebMercatorQuad":  0,
+		"WorldCRS84Quad":   1,
+		"GNOSISGlobalGrid": 2,
+	}
+	for id, want := range cases {
+		set, err := LoadTileMatrixSet(id)
+		if err != nil {
+			t.Fatalf("load %s: %v", id, err)
+		}
+		shift, err := set.CurveShift()
+		if err != nil || shift != want {
+			t.Fatalf("%s: CurveShift() = %d, %v; want %d", id, shift, err, want)
+		}
+	}
+}
+
+func TestTileCurveIDMatchesPMTiles(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	tile := Tile{Zoom: 12, TileIndex: TileIndex{Col: 3423, Row: 1763}}
+	id, err := set.TileCurveID(HilbertCurve, tile)
+	if err != nil {
+		t.Fatalf("TileCurveID: %v", err)
+	}
+	want, _ := grid.CurveTileID(grid.HilbertCurve, tile, 0)
+	if id != want || id != 19078479 {
+		t.Fatalf("TileCurveID = %d, want %d", id, want)
+	}
+}
+
+func TestTileCurveIDUnique(t *testing.T) {
+	for _, setID := range []string{"WorldCRS84Quad", "GNOSISGlobalGrid"} {
+		set, err := LoadTileMatrixSet(setID)
+		if err != nil {
+			t.Fatalf("load %s: %v", setID, err)
+		}
+		for _, c := range []grid.Curve{HilbertCurve, MortonCurve} {
+			seen := map[uint64]Tile{}
+			for z := 0; z <= 3; z++ {
+				for _, tile := range zoomTiles(t, set, z) {
+					id, err := set.TileCurveID(c, tile)
+					if err != nil {
+						t.Fatalf("%s %v: TileCurveID(%+v): %v", setID, c, tile, err)
+					}
+					if prev, ok := seen[id]; ok {
+						t.Fatalf("%s %v: id %d for %+v and %+v", setID, c, id, prev, tile)
+					}
+					seen[id] = tile
+					back, err := set.TileFromCurveID(c, id)
+					if err != nil || back != tile {
+						t.Fatalf("%s %v: round trip %+v -> %d -> %+v, %v", setID, c, tile, id, back, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTileFromCurveIDOutsideMatrix(t *testing.T) {
+	set, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// Shift 1 gives zoom 0 a 2x2 square, but the matrix has a single row.
+	if _, err := set.TileFromCurveID(MortonCurve, 3); err == nil {
+		t.Fatal("expected error for id outside the matrix")
+	}
+}
+
+func TestSortByCurve(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	tiles := zoomTiles(t, set, 2)
+	if err := set.SortByCurve(tiles, HilbertCurve); err != nil {
+		t.Fatalf("SortByCurve: %v", err)
+	}
+	for i, tile := range tiles {
+		id, _ := grid.CurveTileID(grid.HilbertCurve, tile, 0)
+		if id != uint64(5+i) {
+			t.Fatalf("tile %d = %+v with id %d", i, tile, id)
+		}
+	}
+	if err := set.SortByCurve(TilesList{{Zoom: 2, TileIndex: TileIndex{Col: 9}}}, HilbertCurve); err == nil {
+		t.Fatal("expected error for invalid tile")
+	}
+}
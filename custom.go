@@ -0,0 +1,79 @@
+package gocantile
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/hafenkran/gocantile/tms"
+)
+
+// ogcPixelSize is the standardized rendering pixel size (0.28 mm) used to
+// derive scale denominators from cell sizes.
+const ogcPixelSize = 0.00028
+
+// NewCustomTileMatrixSet builds a quadtree TileMatrixSet covering extent (in
+// crs units) with square tiles of tileSize pixels for zoom levels 0..maxZoom.
+// The matrix at zoom 0 is as many tiles wide as the extent's aspect ratio
+// allows (e.g. 2x1 for a global lon/lat extent); each level doubles it. The
+// unit of crs must be known to grid.MetersPerUnit to derive scale
+// denominators.
+func NewCustomTileMatrixSet(id, crs string, extent grid.Bounds, tileSize, maxZoom int) (*TileMatrixSet, error) {
+	width := extent.MaxX - extent.MinX
+	height := extent.MaxY - extent.MinY
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid extent %+v", extent)
+	}
+	if tileSize <= 0 {
+		return nil, fmt.Errorf("invalid tile size %d", tileSize)
+	}
+	if maxZoom < 0 {
+		return nil, fmt.Errorf("invalid max zoom %d", maxZoom)
+	}
+
+	matrixWidth, matrixHeight := 1, 1
+	if width >= height {
+		matrixWidth = int(math.Max(1, math.Round(width/height)))
+	} else {
+		matrixHeight = int(math.Max(1, math.Round(height/width)))
+	}
+	cellSize0 := math.Max(
+		width/float64(matrixWidth*tileSize),
+		height/float64(matrixHeight*tileSize),
+	)
+	mpu, err := grid.MetersPerUnit(crs)
+	if err != nil {
+		return nil, err
+	}
+
+	mats := make([]tms.TileMatrix, 0, maxZoom+1)
+	for z := 0; z <= maxZoom; z++ {
+		factor := math.Pow(2, float64(z))
+		cellSize := cellSize0 / factor
+		mats = append(mats, tms.TileMatrix{
+			Id:               strconv.Itoa(z),
+			CellSize:         cellSize,
+			ScaleDenominator: cellSize * mpu / ogcPixelSize,
+			CornerOfOrigin:   tms.TileMatrixJsonCornerOfOriginTopLeft,
+			PointOfOrigin:    []float64{extent.MinX, extent.MaxY},
+			TileWidth:        float64(tileSize),
+			TileHeight:       float64(tileSize),
+			MatrixWidth:      float64(matrixWidth) * factor,
+			MatrixHeight:     float64(matrixHeight) * factor,
+		})
+	}
+
+	set := tms.TileMatrixSet{
+		Crs: crs,
+		BoundingBox: &tms.TileMatrixSetJsonBoundingBox{
+			LowerLeft:  tms.A2DPointJson{extent.MinX, extent.MinY},
+			UpperRight: tms.A2DPointJson{extent.MaxX, extent.MaxY},
+		},
+		TileMatrices: mats,
+	}
+	if id != "" {
+		set.Id = &id
+	}
+	return WrapTileMatrixSet(set), nil
+}
@@ -0,0 +1,55 @@
+package gocantile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewCustomTileMatrixSetMatchesWorldCRS84Quad(t *testing.T) {
+	extent := Bounds{MinX: -180, MinY: -90, MaxX: 180, MaxY: 90}
+	custom, err := NewCustomTileMatrixSet("MyCRS84", "OGC:CRS84", extent, 256, 3)
+	if err != nil {
+		t.Fatalf("custom err: %v", err)
+	}
+	if custom.MaxZoom() != 3 {
+		t.Fatalf("expected max zoom 3, got %d", custom.MaxZoom())
+	}
+	ref, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	for z := 0; z <= 3; z++ {
+		got := custom.TileMatrices[z]
+		want := ref.TileMatrices[z]
+		if got.MatrixWidth != want.MatrixWidth || got.MatrixHeight != want.MatrixHeight {
+			t.Fatalf("zoom %d: expected matrix %vx%v, got %vx%v", z, want.MatrixWidth, want.MatrixHeight, got.MatrixWidth, got.MatrixHeight)
+		}
+		if math.Abs(got.CellSize-want.CellSize) > 1e-12 {
+			t.Fatalf("zoom %d: expected cell size %v, got %v", z, want.CellSize, got.CellSize)
+		}
+		if math.Abs(got.ScaleDenominator-want.ScaleDenominator)/want.ScaleDenominator > 1e-6 {
+			t.Fatalf("zoom %d: expected scale %v, got %v", z, want.ScaleDenominator, got.ScaleDenominator)
+		}
+	}
+
+	bbox, err := custom.XYBBox()
+	if err != nil {
+		t.Fatalf("bbox err: %v", err)
+	}
+	if bbox != extent {
+		t.Fatalf("expected bbox %+v, got %+v", extent, bbox)
+	}
+}
+
+func TestNewCustomTileMatrixSetInvalid(t *testing.T) {
+	extent := Bounds{MinX: 0, MinY: 0, MaxX: 100, MaxY: 100}
+	if _, err := NewCustomTileMatrixSet("", "EPSG:3857", Bounds{}, 256, 1); err == nil {
+		t.Fatalf("expected error for empty extent")
+	}
+	if _, err := NewCustomTileMatrixSet("", "EPSG:3857", extent, 0, 1); err == nil {
+		t.Fatalf("expected error for zero tile size")
+	}
+	if _, err := NewCustomTileMatrixSet("", "EPSG:3857", extent, 256, -1); err == nil {
+		t.Fatalf("expected error for negative max zoom")
+	}
+}
@@ -0,0 +1,142 @@
+package gocantile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+)
+
+// ExpiryBuilder collects the tiles to expire when features change, across a
+// range of zoom levels. Each geometry expires the tiles of
+// TilesForGeometryWithOptions within a buffer in pixels of each zoom level,
+// so that labels and symbols drawn past the feature are refreshed too. The
+// zero value is not usable; use NewExpiryBuilder.
+type ExpiryBuilder struct {
+	set              *TileMatrixSet
+	minZoom, maxZoom int
+	buffer           float64
+	tiles            map[grid.Tile]struct{}
+}
+
+// NewExpiryBuilder returns a builder expiring tiles of zoom levels
+// [minZoom, maxZoom] inclusive around changed geometries grown by
+// bufferPixels.
+func (t *TileMatrixSet) NewExpiryBuilder(minZoom, maxZoom int, bufferPixels float64) (*ExpiryBuilder, error) {
+	if minZoom < 0 || maxZoom < minZoom || maxZoom > t.MaxZoom() {
+		return nil, fmt.Errorf("invalid zoom range min=%d max=%d", minZoom, maxZoom)
+	}
+	if bufferPixels < 0 {
+		return nil, fmt.Errorf("invalid buffer %v", bufferPixels)
+	}
+	return &ExpiryBuilder{
+		set:     t,
+		minZoom: minZoom,
+		maxZoom: maxZoom,
+		buffer:  bufferPixels,
+		tiles:   map[grid.Tile]struct{}{},
+	}, nil
+}
+
+// Add expires the tiles of the geometry in sourceCRS, e.g. "EPSG:4326", with
+// coordinates easting first. An empty sourceCRS is the CRS of the set, with
+// coordinates in its axis order as in TilesForGeometry.
+func (e *ExpiryBuilder) Add(g orb.Geometry, sourceCRS string) error {
+	if g == nil {
+		return nil
+	}
+	tiles, err := e.set.TilesForGeometryWithOptions(g, e.minZoom, e.maxZoom, CoverageOptions{
+		SourceCRS:  sourceCRS,
+		Buffer:     e.buffer,
+		BufferUnit: BufferPixels,
+	})
+	if err != nil {
+		return err
+	}
+	for _, tile := range tiles {
+		e.tiles[tile] = struct{}{}
+	}
+	return nil
+}
+
+// AddChange expires the tiles of a feature before and after a change. Either
+// geometry may be nil, for features that were created or deleted.
+func (e *ExpiryBuilder) AddChange(old, new orb.Geometry, sourceCRS string) error {
+	if err := e.Add(old, sourceCRS); err != nil {
+		return err
+	}
+	return e.Add(new, sourceCRS)
+}
+
+// Len returns the number of tiles collected, including those within another
+// expired tile.
+func (e *ExpiryBuilder) Len() int {
+	return len(e.tiles)
+}
+
+// Tiles returns the expired tiles sorted by zoom, row and column. Tiles
+// within an expired ancestor are dropped, since expiring a tile expires its
+// descendants. In sets whose zoom levels nest, every expired tile lies within
+// one of minZoom, so only minZoom tiles remain; see AllTiles for the tiles of
+// every zoom level.
+func (e *ExpiryBuilder) Tiles() grid.TilesList {
+	return e.AllTiles().Union(nil, e.set)
+}
+
+// AllTiles returns the expired tiles of every zoom level sorted by zoom, row
+// and column, as listed by osm2pgsql for a range of expiry zoom levels.
+func (e *ExpiryBuilder) AllTiles() grid.TilesList {
+	all := make(grid.TilesList, 0, len(e.tiles))
+	for tile := range e.tiles {
+		all = append(all, tile)
+	}
+	all.Sort()
+	return all
+}
+
+// Coverage returns the tiles of Tiles as a Coverage.
+func (e *ExpiryBuilder) Coverage() *grid.Coverage {
+	c := grid.NewCoverage()
+	for _, tile := range e.Tiles() {
+		c.Add(tile)
+	}
+	return c
+}
+
+// WriteTo writes the tiles of Tiles in the expire-file format of osm2pgsql
+// and imposm: one z/x/y line per tile in XYZ numbering. It returns the number
+// of bytes written to w.
+func (e *ExpiryBuilder) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, tile := range e.Tiles() {
+		x, y, z, err := e.set.TileToXYZ(tile)
+		if err != nil {
+			return cw.n, err
+		}
+		if _, err := fmt.Fprintf(bw, "%d/%d/%d\n", z, x, y); err != nil {
+			return cw.n, err
+		}
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Reset drops the collected tiles, e.g. after the expire list is written.
+func (e *ExpiryBuilder) Reset() {
+	clear(e.tiles)
+}
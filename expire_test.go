@@ -0,0 +1,200 @@
+package gocantile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func TestExpiryBuilderPixelBuffer(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	tile, err := set.TileFromXYZ(2200, 1343, 12)
+	if err != nil {
+		t.Fatalf("tile: %v", err)
+	}
+	center := tileCenter(t, set, tile)
+
+	e, err := set.NewExpiryBuilder(12, 12, 0)
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+	if err := e.Add(center, ""); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if tiles := e.Tiles(); len(tiles) != 1 || tiles[0] != tile {
+		t.Fatalf("tiles = %v, want %v", tiles, tile)
+	}
+
+	// 300 pixels reach past the 128 to the edge of the tile into the
+	// neighbours, but not the tiles beyond them.
+	e, err = set.NewExpiryBuilder(12, 12, 300)
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+	if err := e.Add(center, ""); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if n := len(e.Tiles()); n != 9 {
+		t.Fatalf("buffered tiles = %d, want 9", n)
+	}
+}
+
+func TestExpiryBuilderDropsCoveredTiles(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	e, err := set.NewExpiryBuilder(10, 12, 0)
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+	if err := e.Add(orb.Point{13.4, 52.5}, "EPSG:4326"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if e.Len() != 3 {
+		t.Fatalf("collected %d tiles, want one per zoom", e.Len())
+	}
+	tiles := e.Tiles()
+	if len(tiles) != 1 || tiles[0].Zoom != 10 {
+		t.Fatalf("tiles = %v, want the zoom 10 tile only", tiles)
+	}
+	if c := e.Coverage(); c.Count() != 1 || !c.Contains(tiles[0]) {
+		t.Fatalf("coverage = %v", c.TilesList())
+	}
+
+	if all := e.AllTiles(); len(all) != 3 || all[0] != tiles[0] || all[2].Zoom != 12 {
+		t.Fatalf("all tiles = %v", all)
+	}
+
+	e.Reset()
+	if e.Len() != 0 || len(e.Tiles()) != 0 {
+		t.Fatalf("tiles after reset = %v", e.Tiles())
+	}
+}
+
+func TestExpiryBuilderDiagonal(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	e, err := set.NewExpiryBuilder(12, 14, 0)
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+	// A diagonal road expires the tiles along it, not all tiles of its
+	// bounds.
+	a := tileCenter(t, set, Tile{Zoom: 12, TileIndex: TileIndex{Col: 2200, Row: 1340}})
+	b := tileCenter(t, set, Tile{Zoom: 12, TileIndex: TileIndex{Col: 2205, Row: 1345}})
+	// Keep the road off the tile corners its diagonal would pass through.
+	road := orb.LineString{a, {b[0], b[1] + 1000}}
+	if err := e.Add(road, ""); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	bounds, err := set.TilesForGeometry(road, 12, 12, 0)
+	if err != nil {
+		t.Fatalf("bounds tiles: %v", err)
+	}
+	tiles := e.Tiles()
+	if len(tiles) >= len(bounds) || len(tiles) < 6 {
+		t.Fatalf("%d tiles along the road, %d in its bounds", len(tiles), len(bounds))
+	}
+	for _, tile := range tiles {
+		if tile.Zoom != 12 {
+			t.Fatalf("tile %v not merged into its zoom 12 ancestor", tile)
+		}
+	}
+	if e.Len() <= len(tiles) {
+		t.Fatalf("collected %d tiles over 3 zoom levels", e.Len())
+	}
+}
+
+func TestExpiryBuilderChangesAndExpireFile(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	e, err := set.NewExpiryBuilder(12, 12, 0)
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+	// A moved feature expires where it was and where it is; a created one
+	// has no old geometry.
+	if err := e.AddChange(orb.Point{13.4, 52.5}, orb.Point{2.35, 48.85}, "EPSG:4326"); err != nil {
+		t.Fatalf("change: %v", err)
+	}
+	if err := e.AddChange(nil, orb.Point{13.4, 52.5}, "EPSG:4326"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	tiles := e.Tiles()
+	if len(tiles) != 2 {
+		t.Fatalf("tiles = %v", tiles)
+	}
+
+	var want bytes.Buffer
+	for _, tile := range tiles {
+		x, y, z, err := set.TileToXYZ(tile)
+		if err != nil {
+			t.Fatalf("xyz: %v", err)
+		}
+		fmt.Fprintf(&want, "%d/%d/%d\n", z, x, y)
+	}
+	var got bytes.Buffer
+	n, err := e.WriteTo(&got)
+	if err != nil || n != int64(got.Len()) {
+		t.Fatalf("write: %d, %v", n, err)
+	}
+	if got.String() != want.String() {
+		t.Fatalf("expire file:\n%s\nwant:\n%s", got.String(), want.String())
+	}
+	if got.String() != "12/2200/1343\n12/2074/1409\n" {
+		t.Fatalf("expire file:\n%s", got.String())
+	}
+
+	// A writer failing part way through gets only the bytes it took counted.
+	short := &shortWriter{limit: 10}
+	if n, err := e.WriteTo(short); err == nil || n != 10 || short.Len() != 10 {
+		t.Fatalf("short write: %d, %v; wrote %d bytes", n, err, short.Len())
+	}
+}
+
+// shortWriter takes up to limit bytes and then fails.
+type shortWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.Len(); len(p) > room {
+		w.Buffer.Write(p[:room])
+		return room, io.ErrShortWrite
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestExpiryBuilderAntimeridian(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	e, err := set.NewExpiryBuilder(4, 4, 0)
+	if err != nil {
+		t.Fatalf("builder: %v", err)
+	}
+	fiji := orb.Polygon{{{177, -19}, {-179, -19}, {-179, -16}, {177, -16}, {177, -19}}}
+	if err := e.Add(fiji, "EPSG:4326"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	for _, tile := range e.Tiles() {
+		x, _, _, _ := set.TileToXYZ(tile)
+		if x != 0 && x != 15 {
+			t.Fatalf("tile %v away from the antimeridian", tile)
+		}
+	}
+	if len(e.Tiles()) != 2 {
+		t.Fatalf("tiles = %v", e.Tiles())
+	}
+}
+
+func TestExpiryBuilderInvalid(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	if _, err := set.NewExpiryBuilder(5, 4, 0); err == nil {
+		t.Fatal("expected error for invalid zoom range")
+	}
+	if _, err := set.NewExpiryBuilder(0, set.MaxZoom()+1, 0); err == nil {
+		t.Fatal("expected error for zoom past the set")
+	}
+	if _, err := set.NewExpiryBuilder(0, 4, -1); err == nil {
+		t.Fatal("expected error for negative buffer")
+	}
+}
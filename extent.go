@@ -0,0 +1,91 @@
+package gocantile
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// rangeBoundsDensify is the number of points inserted per edge when an XY
+// extent is unprojected to lon/lat.
+const rangeBoundsDensify = 21
+
+// TileRangesBounds returns the lon/lat bounds (degrees) of tile ranges keyed by
+// zoom level, e.g. the tiles written to an archive. The XY extent is
+// densified before unprojecting so that curved edges are covered. If p is
+// nil, a projector is created from the TMS CRS.
+func (t *TileMatrixSet) TileRangesBounds(ranges map[int]grid.TileRange, p grid.Projector) (grid.Bounds, error) {
+	if len(ranges) == 0 {
+		return grid.Bounds{}, fmt.Errorf("no tile ranges")
+	}
+	xy := grid.Bounds{MinX: math.Inf(1), MinY: math.Inf(1), MaxX: math.Inf(-1), MaxY: math.Inf(-1)}
+	for z, r := range ranges {
+		for _, idx := range []grid.TileIndex{{Col: r.MinCol, Row: r.MinRow}, {Col: r.MaxCol, Row: r.MaxRow}} {
+			b, err := t.XYBounds(grid.Tile{Zoom: z, TileIndex: idx})
+			if err != nil {
+				return grid.Bounds{}, err
+			}
+			xy.MinX, xy.MinY = math.Min(xy.MinX, b.MinX), math.Min(xy.MinY, b.MinY)
+			xy.MaxX, xy.MaxY = math.Max(xy.MaxX, b.MaxX), math.Max(xy.MaxY, b.MaxY)
+		}
+	}
+	if p == nil {
+		pp, err := grid.ProjectorFromTMS(t.TileMatrixSet)
+		if err != nil {
+			return grid.Bounds{}, err
+		}
+		p = pp
+	}
+	poly, err := grid.InversePolygon(grid.PolygonForBounds(xy, rangeBoundsDensify), p)
+	if err != nil {
+		return grid.Bounds{}, err
+	}
+	b := poly.Bound()
+	return grid.Bounds{MinX: b.Min.X(), MinY: b.Min.Y(), MaxX: b.Max.X(), MaxY: b.Max.Y()}, nil
+}
+
+// lonLatExtent returns the lon/lat bounds of the lowest zoom level. Extents
+// around a pole, as in polar stereographic sets, reach the pole and span all
+// longitudes.
+func (t *TileMatrixSet) lonLatExtent(p grid.Projector) (grid.Bounds, error) {
+	adapter, err := t.tileMatrix(0)
+	if err != nil {
+		return grid.Bounds{}, err
+	}
+	adapter = t.eastingNorthing(adapter)
+	// The corner tiles span the extent; the last row may be coalesced.
+	_, height := adapter.MatrixSize()
+	first, err := adapter.BoundsForTile(grid.TileIndex{})
+	if err != nil {
+		return grid.Bounds{}, err
+	}
+	last, err := adapter.BoundsForTile(grid.TileIndex{Col: adapter.RowWidth(height-1) - 1, Row: height - 1})
+	if err != nil {
+		return grid.Bounds{}, err
+	}
+	xy := grid.Bounds{
+		MinX: math.Min(first.MinX, last.MinX), MinY: math.Min(first.MinY, last.MinY),
+		MaxX: math.Max(first.MaxX, last.MaxX), MaxY: math.Max(first.MaxY, last.MaxY),
+	}
+	if p == nil {
+		pp, err := grid.ProjectorFromTMS(t.TileMatrixSet)
+		if err != nil {
+			return grid.Bounds{}, err
+		}
+		p = pp
+	}
+	poly, err := grid.InversePolygon(grid.PolygonForBounds(xy, rangeBoundsDensify), p)
+	if err != nil {
+		return grid.Bounds{}, err
+	}
+	b := poly.Bound()
+	out := grid.Bounds{MinX: b.Min.X(), MinY: b.Min.Y(), MaxX: b.Max.X(), MaxY: b.Max.Y()}
+	switch grid.PoleWithin(xy, p) {
+	case 1:
+		out = grid.Bounds{MinX: -180, MinY: out.MinY, MaxX: 180, MaxY: 90}
+	case -1:
+		out = grid.Bounds{MinX: -180, MinY: -90, MaxX: 180, MaxY: out.MaxY}
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package gocantile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func TestTileRangesBounds(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	// The north-west quarter at zoom 1 and the south-east tile at zoom 2.
+	ranges := map[int]grid.TileRange{
+		1: {MinCol: 0, MaxCol: 0, MinRow: 0, MaxRow: 0},
+		2: {MinCol: 3, MaxCol: 3, MinRow: 3, MaxRow: 3},
+	}
+	b, err := set.TileRangesBounds(ranges, nil)
+	if err != nil {
+		t.Fatalf("bounds: %v", err)
+	}
+	const maxLat = 85.0511287798
+	if math.Abs(b.MinX+180) > 1e-6 || math.Abs(b.MaxX-180) > 1e-6 || math.Abs(b.MinY+maxLat) > 1e-6 || math.Abs(b.MaxY-maxLat) > 1e-6 {
+		t.Fatalf("unexpected bounds %+v", b)
+	}
+
+	b, err = set.TileRangesBounds(map[int]grid.TileRange{1: {MinCol: 1, MaxCol: 1, MinRow: 0, MaxRow: 1}}, nil)
+	if err != nil {
+		t.Fatalf("bounds: %v", err)
+	}
+	if math.Abs(b.MinX) > 1e-9 || math.Abs(b.MaxX-180) > 1e-6 {
+		t.Fatalf("expected eastern half, got %+v", b)
+	}
+
+	if _, err := set.TileRangesBounds(nil, nil); err == nil {
+		t.Fatalf("expected error for empty ranges")
+	}
+	if _, err := set.TileRangesBounds(map[int]grid.TileRange{99: {}}, nil); err == nil {
+		t.Fatalf("expected error for zoom out of range")
+	}
+}
+
+func TestLonLatExtentPolarAndNorthingFirst(t *testing.T) {
+	ups, err := LoadTileMatrixSet("UPSAntarcticWGS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	b, err := ups.lonLatExtent(nil)
+	if err != nil {
+		t.Fatalf("extent: %v", err)
+	}
+	// The extent holds the South Pole, so it reaches -90 across all longitudes.
+	if b.MinX != -180 || b.MaxX != 180 || b.MinY != -90 || b.MaxY <= 0 {
+		t.Fatalf("unexpected UPS extent %+v", b)
+	}
+
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	b, err = gnosis.lonLatExtent(nil)
+	if err != nil {
+		t.Fatalf("extent: %v", err)
+	}
+	if math.Abs(b.MinX+180) > 1e-9 || math.Abs(b.MaxX-180) > 1e-9 || math.Abs(b.MinY+90) > 1e-9 || math.Abs(b.MaxY-90) > 1e-9 {
+		t.Fatalf("unexpected GNOSIS extent %+v", b)
+	}
+}
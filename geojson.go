@@ -0,0 +1,141 @@
+package gocantile
+
+import (
+	"fmt"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/clip"
+	"github.com/paulmach/orb/geojson"
+)
+
+// GeoJSONOptions controls the geometry of exported tile features.
+type GeoJSONOptions struct {
+	// LonLat reprojects geometries to lon/lat (degrees). Otherwise coordinates
+	// are written in the TileMatrixSet CRS.
+	LonLat bool
+	// Densify inserts extra points along each tile edge so that edges stay
+	// curved after reprojection.
+	Densify int
+	// Projector is used for LonLat output. If nil, a projector is created from
+	// the TMS CRS.
+	Projector grid.Projector
+}
+
+func (o *GeoJSONOptions) projector(t *TileMatrixSet) (grid.Projector, error) {
+	if !o.LonLat || o.Projector != nil {
+		return o.Projector, nil
+	}
+	p, err := grid.ProjectorFromTMS(t.TileMatrixSet)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// TileFeature returns the tile as a GeoJSON Polygon feature with zoom,
+// tileMatrix, col, row and title properties.
+func (t *TileMatrixSet) TileFeature(tile grid.Tile, opts GeoJSONOptions) (*geojson.Feature, error) {
+	p, err := opts.projector(t)
+	if err != nil {
+		return nil, err
+	}
+	return t.tileFeature(tile, nil, opts.Densify, p)
+}
+
+// TilesFeatureCollection returns a FeatureCollection with one feature per
+// tile; see TileFeature.
+func (t *TileMatrixSet) TilesFeatureCollection(tiles grid.TilesList, opts GeoJSONOptions) (*geojson.FeatureCollection, error) {
+	p, err := opts.projector(t)
+	if err != nil {
+		return nil, err
+	}
+	fc := geojson.NewFeatureCollection()
+	for _, tile := range tiles {
+		f, err := t.tileFeature(tile, nil, opts.Densify, p)
+		if err != nil {
+			return nil, err
+		}
+		fc.Append(f)
+	}
+	return fc, nil
+}
+
+// TilesGeoJSON returns the tiles as an encoded GeoJSON FeatureCollection.
+func (t *TileMatrixSet) TilesGeoJSON(tiles grid.TilesList, opts GeoJSONOptions) ([]byte, error) {
+	fc, err := t.TilesFeatureCollection(tiles, opts)
+	if err != nil {
+		return nil, err
+	}
+	return fc.MarshalJSON()
+}
+
+// GridFeatureCollection returns all tiles of the zoom level that intersect
+// extent (in the TMS CRS), with tile geometries clipped to the extent.
+func (t *TileMatrixSet) GridFeatureCollection(zoom int, extent grid.Bounds, opts GeoJSONOptions) (*geojson.FeatureCollection, error) {
+	adapter, err := t.tileMatrix(zoom)
+	if err != nil {
+		return nil, err
+	}
+	p, err := opts.projector(t)
+	if err != nil {
+		return nil, err
+	}
+	clipBound := orb.Bound{
+		Min: orb.Point{extent.MinX, extent.MinY},
+		Max: orb.Point{extent.MaxX, extent.MaxY},
+	}
+	fc := geojson.NewFeatureCollection()
+	tr, ok := adapter.TileRangeForBounds(extent)
+	if !ok {
+		return fc, nil
+	}
+	for r := tr.MinRow; r <= tr.MaxRow; r++ {
+		first, last := adapter.RowTileRange(tr, r)
+		for c := first; c <= last; c++ {
+			tile := grid.Tile{Zoom: zoom, TileIndex: grid.TileIndex{Col: c, Row: r}}
+			f, err := t.tileFeature(tile, &clipBound, opts.Densify, p)
+			if err != nil {
+				return nil, err
+			}
+			if f != nil {
+				fc.Append(f)
+			}
+		}
+	}
+	return fc, nil
+}
+
+// tileFeature builds the feature for a tile. The footprint is clipped to
+// clipBound (in the TMS CRS) when set; a nil feature is returned if nothing
+// remains. A nil projector keeps TMS CRS coordinates.
+func (t *TileMatrixSet) tileFeature(tile grid.Tile, clipBound *orb.Bound, densify int, p grid.Projector) (*geojson.Feature, error) {
+	adapter, err := t.tileMatrix(tile.Zoom)
+	if err != nil {
+		return nil, err
+	}
+	poly, err := adapter.PolygonForTile(tile.TileIndex, densify)
+	if err != nil {
+		return nil, err
+	}
+	if clipBound != nil {
+		poly = clip.Polygon(*clipBound, poly)
+		if len(poly) == 0 {
+			return nil, nil
+		}
+	}
+	if p != nil {
+		poly, err = grid.InversePolygon(poly, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f := geojson.NewFeature(poly)
+	f.Properties["zoom"] = tile.Zoom
+	f.Properties["tileMatrix"] = adapter.TM.Id
+	f.Properties["col"] = tile.Col
+	f.Properties["row"] = tile.Row
+	f.Properties["title"] = fmt.Sprintf("XYZ tile (%d, %d, %d)", tile.Col, tile.Row, tile.Zoom)
+	return f, nil
+}
@@ -0,0 +1,150 @@
+package gocantile
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+func TestTileFeatureProperties(t *testing.T) {
+	tms := loadWebMercatorQuad(t)
+	tile := Tile{Zoom: 2, TileIndex: TileIndex{Col: 1, Row: 3}}
+
+	f, err := tms.TileFeature(tile, GeoJSONOptions{})
+	if err != nil {
+		t.Fatalf("feature err: %v", err)
+	}
+	if f.Properties["zoom"] != 2 || f.Properties["tileMatrix"] != "2" || f.Properties["col"] != 1 || f.Properties["row"] != 3 {
+		t.Fatalf("unexpected properties %v", f.Properties)
+	}
+	if f.Properties["title"] != "XYZ tile (1, 3, 2)" {
+		t.Fatalf("unexpected title %v", f.Properties["title"])
+	}
+	xyb, err := tms.XYBounds(tile)
+	if err != nil {
+		t.Fatalf("xy bounds err: %v", err)
+	}
+	b := f.Geometry.Bound()
+	if b.Min[0] != xyb.MinX || b.Min[1] != xyb.MinY || b.Max[0] != xyb.MaxX || b.Max[1] != xyb.MaxY {
+		t.Fatalf("expected native bounds %+v, got %+v", xyb, b)
+	}
+}
+
+func TestTileFeatureLonLatDensified(t *testing.T) {
+	tms := loadWebMercatorQuad(t)
+	tile := Tile{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 0}}
+
+	f, err := tms.TileFeature(tile, GeoJSONOptions{LonLat: true, Densify: 8})
+	if err != nil {
+		t.Fatalf("feature err: %v", err)
+	}
+	poly, ok := f.Geometry.(orb.Polygon)
+	if !ok {
+		t.Fatalf("expected polygon, got %T", f.Geometry)
+	}
+	if len(poly[0]) != 4*9+1 {
+		t.Fatalf("expected densified ring with %d points, got %d", 4*9+1, len(poly[0]))
+	}
+	b := poly.Bound()
+	if math.Abs(b.Min[0]+180) > 1e-6 || math.Abs(b.Max[0]) > 1e-6 || math.Abs(b.Max[1]-85.0511287798) > 1e-6 {
+		t.Fatalf("unexpected lon/lat bounds %+v", b)
+	}
+}
+
+func TestTilesGeoJSON(t *testing.T) {
+	tms := loadWebMercatorQuad(t)
+	tiles := TilesList{
+		{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 0}},
+		{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 1}},
+	}
+	raw, err := tms.TilesGeoJSON(tiles, GeoJSONOptions{LonLat: true})
+	if err != nil {
+		t.Fatalf("geojson err: %v", err)
+	}
+	fc, err := geojson.UnmarshalFeatureCollection(raw)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(fc.Features) != 2 {
+		t.Fatalf("expected 2 features, got %d", len(fc.Features))
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil || doc["type"] != "FeatureCollection" {
+		t.Fatalf("unexpected document %s", raw)
+	}
+
+	if _, err := tms.TilesGeoJSON(TilesList{{Zoom: 99}}, GeoJSONOptions{}); err == nil {
+		t.Fatalf("expected error for zoom out of range")
+	}
+}
+
+func TestGridFeatureCollectionClipped(t *testing.T) {
+	tms := loadWebMercatorQuad(t)
+	extent := Bounds{MinX: -1_000_000, MinY: -1_000_000, MaxX: 1_000_000, MaxY: 500_000}
+
+	fc, err := tms.GridFeatureCollection(2, extent, GeoJSONOptions{})
+	if err != nil {
+		t.Fatalf("grid err: %v", err)
+	}
+	if len(fc.Features) != 4 {
+		t.Fatalf("expected 4 features, got %d", len(fc.Features))
+	}
+	for _, f := range fc.Features {
+		b := f.Geometry.Bound()
+		if b.Min[0] < extent.MinX || b.Max[0] > extent.MaxX || b.Min[1] < extent.MinY || b.Max[1] > extent.MaxY {
+			t.Fatalf("feature %v not clipped to extent: %+v", f.Properties, b)
+		}
+	}
+}
+
+func TestGridFeatureCollectionCoalescedRows(t *testing.T) {
+	set, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// Zoom 1 is 8x4 tiles with the polar rows coalesced into 4 tiles each.
+	first, err := set.XYBounds(Tile{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 0}})
+	if err != nil {
+		t.Fatalf("bounds: %v", err)
+	}
+	last, err := set.XYBounds(Tile{Zoom: 1, TileIndex: TileIndex{Col: 3, Row: 3}})
+	if err != nil {
+		t.Fatalf("bounds: %v", err)
+	}
+	extent := Bounds{
+		MinX: min(first.MinX, last.MinX), MinY: min(first.MinY, last.MinY),
+		MaxX: max(first.MaxX, last.MaxX), MaxY: max(first.MaxY, last.MaxY),
+	}
+	fc, err := set.GridFeatureCollection(1, extent, GeoJSONOptions{})
+	if err != nil {
+		t.Fatalf("grid err: %v", err)
+	}
+	if len(fc.Features) != 4+8+8+4 {
+		t.Fatalf("expected 24 features, got %d", len(fc.Features))
+	}
+	for _, f := range fc.Features {
+		row, col := f.Properties.MustInt("row"), f.Properties.MustInt("col")
+		if (row == 0 || row == 3) && col > 3 {
+			t.Fatalf("tile %d/%d beyond the coalesced row", col, row)
+		}
+	}
+	// The eastern half holds matrix columns 4 to 7, which are the coalesced
+	// tiles 2 and 3 of the polar rows.
+	extent.MinX = (extent.MinX + extent.MaxX) / 2
+	fc, err = set.GridFeatureCollection(1, extent, GeoJSONOptions{})
+	if err != nil {
+		t.Fatalf("grid err: %v", err)
+	}
+	if len(fc.Features) != 2+4+4+2 {
+		t.Fatalf("expected 12 features, got %d", len(fc.Features))
+	}
+	for _, f := range fc.Features {
+		row, col := f.Properties.MustInt("row"), f.Properties.MustInt("col")
+		if (row == 0 || row == 3) && col != 2 && col != 3 {
+			t.Fatalf("tile %d/%d outside the eastern half", col, row)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package gocantile
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+)
+
+// GeoTransform is a GDAL affine geotransform mapping pixel/line positions of
+// an image, counted from its top-left corner, to CRS coordinates:
+//
+//	x = GT[0] + col*GT[1] + row*GT[2]
+//	y = GT[3] + col*GT[4] + row*GT[5]
+//
+// x and y are easting and northing regardless of the CRS axis order, as in
+// GDAL's traditional GIS order.
+type GeoTransform [6]float64
+
+// TileGeoTransform returns the geotransform of a tile image:
+// [minX, cellSize*coalesce, 0, maxY, 0, -cellSize]. Origins given northing
+// first in orderedAxes are swapped; bottomLeft origins and coalesced rows are
+// handled through the tile bounds.
+func (t *TileMatrixSet) TileGeoTransform(tile grid.Tile) (GeoTransform, error) {
+	adapter, err := t.eastingNorthingMatrix(tile)
+	if err != nil {
+		return GeoTransform{}, err
+	}
+	b, err := adapter.BoundsForTile(tile.TileIndex)
+	if err != nil {
+		return GeoTransform{}, err
+	}
+	cellSize := adapter.Resolution()
+	k := float64(adapter.Coalesce(tile.Row))
+	return GeoTransform{b.MinX, cellSize * k, 0, b.MaxY, 0, -cellSize}, nil
+}
+
+// eastingNorthingMatrix returns the tile matrix of the tile with its origin
+// given easting first, so that tile bounds come out as x=easting, y=northing
+// as expected by projectors.
+func (t *TileMatrixSet) eastingNorthingMatrix(tile grid.Tile) (grid.TileMatrix, error) {
+	adapter, err := t.validTileMatrix(tile)
+	if err != nil {
+		return grid.TileMatrix{}, err
+	}
+	return t.eastingNorthing(adapter), nil
+}
+
+// eastingNorthing returns the tile matrix with its origin given easting first.
+func (t *TileMatrixSet) eastingNorthing(adapter grid.TileMatrix) grid.TileMatrix {
+	if grid.NorthingFirst(t.OrderedAxes) && len(adapter.TM.PointOfOrigin) >= 2 {
+		origin := adapter.TM.PointOfOrigin
+		adapter.TM.PointOfOrigin = []float64{origin[1], origin[0]}
+	}
+	return adapter
+}
+
+// eastingNorthingPoint moves a point from the coordinates of the tile matrix,
+// in the axis order of the set as used by XYBounds, to those of its
+// eastingNorthing matrix.
+func (t *TileMatrixSet) eastingNorthingPoint(adapter grid.TileMatrix, pt orb.Point) orb.Point {
+	o, e := adapter.TM.PointOfOrigin, t.eastingNorthing(adapter).TM.PointOfOrigin
+	if len(o) < 2 {
+		return pt
+	}
+	return orb.Point{pt[0] - o[0] + e[0], pt[1] - o[1] + e[1]}
+}
+
+// WorldFile returns the six lines of an ESRI world file (.pgw, .tfw, ...).
+// Unlike the geotransform, a world file refers to the center of the top-left
+// pixel.
+func (g GeoTransform) WorldFile() string {
+	vals := []float64{g[1], g[4], g[2], g[5], g[0] + g[1]/2 + g[2]/2, g[3] + g[4]/2 + g[5]/2}
+	var sb strings.Builder
+	for _, v := range vals {
+		sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// WorldFileExt returns the world file extension for an image extension: the
+// first and last letter followed by "w", e.g. "pgw" for "png" and "tfw" for
+// "tif".
+func WorldFileExt(imageExt string) string {
+	ext := strings.ToLower(strings.TrimPrefix(imageExt, "."))
+	if len(ext) < 2 {
+		return ext + "w"
+	}
+	return ext[:1] + ext[len(ext)-1:] + "w"
+}
+
+type pamDataset struct {
+	XMLName      xml.Name `xml:"PAMDataset"`
+	SRS          pamSRS   `xml:"SRS"`
+	GeoTransform string   `xml:"GeoTransform"`
+}
+
+type pamSRS struct {
+	DataAxisToSRSAxisMapping string `xml:"dataAxisToSRSAxisMapping,attr"`
+	Value                    string `xml:",chardata"`
+}
+
+// TileAuxXML returns a GDAL .aux.xml sidecar with the CRS and geotransform of
+// a tile image. srs is written as the SRS; it should be WKT, but anything GDAL
+// accepts as user input works. If empty, the CRS definition of the set is
+// used: its WKT or PROJJSON if given, otherwise its identifier, e.g.
+// "EPSG:3857". The axis mapping records that the geotransform is easting
+// first for CRSs with northing-first axes.
+func (t *TileMatrixSet) TileAuxXML(tile grid.Tile, srs string) ([]byte, error) {
+	gt, err := t.TileGeoTransform(tile)
+	if err != nil {
+		return nil, err
+	}
+	if srs == "" {
+		if srs, err = grid.ExtractCRS(t.TileMatrixSet); err != nil {
+			return nil, err
+		}
+	}
+	mapping := "1,2"
+	if grid.NorthingFirst(t.OrderedAxes) {
+		mapping = "2,1"
+	}
+	parts := make([]string, len(gt))
+	for i, v := range gt {
+		parts[i] = fmt.Sprintf("%24.16e", v)
+	}
+	doc := pamDataset{
+		SRS:          pamSRS{DataAxisToSRSAxisMapping: mapping, Value: srs},
+		GeoTransform: strings.Join(parts, ","),
+	}
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,112 @@
+package gocantile
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/hafenkran/gocantile/tms"
+)
+
+func geoTransformClose(a, b GeoTransform) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTileGeoTransform(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	gt, err := set.TileGeoTransform(Tile{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 0}})
+	if err != nil {
+		t.Fatalf("geotransform: %v", err)
+	}
+	res, _ := set.ResolutionForZoom(1)
+	if want := (GeoTransform{0, res, 0, webMercatorHalfExtent, 0, -res}); !geoTransformClose(gt, want) {
+		t.Fatalf("got %v, want %v", gt, want)
+	}
+	if _, err := set.TileGeoTransform(Tile{Zoom: 1, TileIndex: TileIndex{Col: 2}}); err == nil {
+		t.Fatalf("expected error for tile outside matrix")
+	}
+
+	// GNOSISGlobalGrid is Lat,Lon with origin [90, -180]; zoom 1 row 0 is
+	// coalesced by 2, so the tile 1 starts at -90 with doubled cell width.
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	gt, err = gnosis.TileGeoTransform(Tile{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 0}})
+	if err != nil {
+		t.Fatalf("geotransform: %v", err)
+	}
+	res, _ = gnosis.ResolutionForZoom(1)
+	if want := (GeoTransform{-90, 2 * res, 0, 90, 0, -res}); !geoTransformClose(gt, want) {
+		t.Fatalf("got %v, want %v", gt, want)
+	}
+}
+
+func TestTileGeoTransformBottomLeft(t *testing.T) {
+	set := WrapTileMatrixSet(tms.TileMatrixSet{
+		Crs: "EPSG:3857",
+		TileMatrices: []tms.TileMatrix{{
+			Id:             "0",
+			CellSize:       10,
+			TileWidth:      2,
+			TileHeight:     2,
+			MatrixWidth:    4,
+			MatrixHeight:   4,
+			PointOfOrigin:  []float64{100, 200},
+			CornerOfOrigin: tms.TileMatrixJsonCornerOfOriginBottomLeft,
+		}},
+	})
+	// Row 1 from the bottom spans y 220..240.
+	gt, err := set.TileGeoTransform(Tile{Zoom: 0, TileIndex: TileIndex{Col: 2, Row: 1}})
+	if err != nil {
+		t.Fatalf("geotransform: %v", err)
+	}
+	if want := (GeoTransform{140, 10, 0, 240, 0, -10}); gt != want {
+		t.Fatalf("got %v, want %v", gt, want)
+	}
+	if got := gt.WorldFile(); got != "10\n0\n0\n-10\n145\n235\n" {
+		t.Fatalf("unexpected world file %q", got)
+	}
+}
+
+func TestWorldFileExt(t *testing.T) {
+	for ext, want := range map[string]string{"png": "pgw", ".tif": "tfw", "JPEG": "jgw", "jpg": "jgw", "webp": "wpw"} {
+		if got := WorldFileExt(ext); got != want {
+			t.Fatalf("WorldFileExt(%q) = %q, want %q", ext, got, want)
+		}
+	}
+}
+
+func TestTileAuxXML(t *testing.T) {
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	b, err := gnosis.TileAuxXML(Tile{Zoom: 0}, "")
+	if err != nil {
+		t.Fatalf("aux xml: %v", err)
+	}
+	s := string(b)
+	for _, want := range []string{
+		"<PAMDataset>",
+		`<SRS dataAxisToSRSAxisMapping="2,1">EPSG:4326</SRS>`,
+		"<GeoTransform> -1.8000000000000000e+02,",
+	} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("expected %q in\n%s", want, s)
+		}
+	}
+
+	b, err = loadWebMercatorQuad(t).TileAuxXML(Tile{Zoom: 0}, `PROJCS["WGS 84 / Pseudo-Mercator"]`)
+	if err != nil {
+		t.Fatalf("aux xml: %v", err)
+	}
+	if !strings.Contains(string(b), `<SRS dataAxisToSRSAxisMapping="1,2">PROJCS[&#34;WGS 84 / Pseudo-Mercator&#34;]</SRS>`) {
+		t.Fatalf("unexpected aux xml\n%s", b)
+	}
+}
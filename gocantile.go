@@ -17,10 +17,29 @@ type (
 	TileMatrix    = grid.TileMatrix
 	TileIndex     = grid.TileIndex
 	Tile          = grid.Tile
+	TilesList     = grid.TilesList
 	Bounds        = grid.Bounds
 	TileRange     = grid.TileRange
 	Projector     = grid.Projector
 	ProjProjector = grid.ProjProjector
+	TileHierarchy = grid.TileHierarchy
+	Coverage      = grid.Coverage
+	Curve         = grid.Curve
+	CoverMode     = grid.CoverMode
+)
+
+// Re-export grid curves
+const (
+	HilbertCurve = grid.HilbertCurve
+	MortonCurve  = grid.MortonCurve
+)
+
+// Re-export grid coverage modes
+const (
+	CoverIntersects = grid.CoverIntersects
+	CoverWithin     = grid.CoverWithin
+	CoverCenter     = grid.CoverCenter
+	CoverContains   = grid.CoverContains
 )
 
 // NewProjProjector creates a PROJ-backed projector from source CRS to target CRS.
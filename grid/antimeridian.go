@@ -0,0 +1,207 @@
+package grid
+
+import (
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/clip"
+	"github.com/paulmach/orb/planar"
+)
+
+// worldLonLat is the lon/lat extent that SplitAntimeridian cuts to.
+var worldLonLat = orb.Bound{Min: orb.Point{-180, -90}, Max: orb.Point{180, 90}}
+
+// NormalizeLongitude wraps lon in degrees into [-180, 180).
+func NormalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// SplitLonLatBounds splits lon/lat bounds in degrees at the antimeridian into
+// bounds within [-180, 180]. A MinX greater than MaxX crosses 180°, and
+// longitudes past ±180 are wrapped; bounds spanning 360° or more cover all
+// longitudes. Latitudes are kept as they are.
+func SplitLonLatBounds(b Bounds) []Bounds {
+	span := b.MaxX - b.MinX
+	if span >= 360 {
+		return []Bounds{{MinX: -180, MinY: b.MinY, MaxX: 180, MaxY: b.MaxY}}
+	}
+	west := NormalizeLongitude(b.MinX)
+	if span < 0 {
+		span = math.Mod(span, 360) + 360
+	}
+	east := west + span
+	if east <= 180 {
+		return []Bounds{{MinX: west, MinY: b.MinY, MaxX: east, MaxY: b.MaxY}}
+	}
+	return []Bounds{
+		{MinX: west, MinY: b.MinY, MaxX: 180, MaxY: b.MaxY},
+		{MinX: -180, MinY: b.MinY, MaxX: east - 360, MaxY: b.MaxY},
+	}
+}
+
+// SplitAntimeridian splits a lon/lat geometry in degrees into parts whose
+// longitudes lie within [-180, 180], so that each part can be projected and
+// tiled on its own. Lines and rings are first unwrapped so that no segment
+// spans more than 180° of longitude: a segment from 179° to -179° crosses the
+// antimeridian rather than the rest of the globe. Points are normalised.
+// Rings that wind around a pole, see RingPole, are closed along the pole so
+// that they cover the polar cap.
+func SplitAntimeridian(g orb.Geometry) []orb.Geometry {
+	switch geom := g.(type) {
+	case nil:
+		return nil
+	case orb.Point:
+		return []orb.Geometry{orb.Point{NormalizeLongitude(geom[0]), geom[1]}}
+	case orb.MultiPoint:
+		out := make(orb.MultiPoint, len(geom))
+		for i, pt := range geom {
+			out[i] = orb.Point{NormalizeLongitude(pt[0]), pt[1]}
+		}
+		return []orb.Geometry{out}
+	case orb.LineString:
+		return splitWrapped(orb.LineString(unwrapLongitudes(geom)))
+	case orb.MultiLineString:
+		var parts []orb.Geometry
+		for _, ls := range geom {
+			parts = append(parts, SplitAntimeridian(ls)...)
+		}
+		return parts
+	case orb.Ring:
+		return SplitAntimeridian(orb.Polygon{geom})
+	case orb.Bound:
+		return SplitAntimeridian(geom.ToPolygon())
+	case orb.Polygon:
+		return splitWrapped(unwrapPolygon(geom))
+	case orb.MultiPolygon:
+		var parts []orb.Geometry
+		for _, poly := range geom {
+			parts = append(parts, SplitAntimeridian(poly)...)
+		}
+		return parts
+	case orb.Collection:
+		var parts []orb.Geometry
+		for _, sub := range geom {
+			parts = append(parts, SplitAntimeridian(sub)...)
+		}
+		return parts
+	}
+	return []orb.Geometry{g}
+}
+
+// unwrapLongitudes returns the points with each longitude moved by a multiple
+// of 360° to within 180° of the previous one.
+func unwrapLongitudes(pts []orb.Point) []orb.Point {
+	out := make([]orb.Point, len(pts))
+	for i, pt := range pts {
+		if i > 0 {
+			prev := out[i-1][0]
+			pt[0] = prev + math.Remainder(pt[0]-prev, 360)
+		}
+		out[i] = pt
+	}
+	return out
+}
+
+// unwrapPolygon unwraps the rings of the polygon and moves the holes next to
+// the exterior ring. Rings that wind around a pole are closed along the pole.
+func unwrapPolygon(poly orb.Polygon) orb.Polygon {
+	if len(poly) == 0 {
+		return poly
+	}
+	out := make(orb.Polygon, len(poly))
+	var center float64
+	for i, r := range poly {
+		var ring orb.Ring
+		switch pole := RingPole(r); {
+		case seamOnly(r):
+			ring = orb.Clone(r).(orb.Ring)
+		case pole != 0:
+			ring = closePolarRing(unwrapLongitudes(r), pole)
+		default:
+			ring = unwrapLongitudes(r)
+		}
+		mid := (ring.Bound().Min[0] + ring.Bound().Max[0]) / 2
+		if i == 0 {
+			center = mid
+		} else if shift := 360 * math.Round((center-mid)/360); shift != 0 {
+			for j := range ring {
+				ring[j][0] += shift
+			}
+		}
+		out[i] = ring
+	}
+	return out
+}
+
+// seamOnly reports whether the ring is drawn within [-180, 180] with its only
+// long segments running along the antimeridian, as in the bounds of a polar
+// cap from (-180, -90) to (180, -60). Such rings are kept as drawn unless
+// they have no area, like a parallel from -180 to 180 around a pole.
+func seamOnly(r orb.Ring) bool {
+	for i, pt := range r {
+		if math.Abs(pt[0]) > 180 {
+			return false
+		}
+		if i > 0 && math.Abs(pt[0]-r[i-1][0]) > 180 && (math.Abs(pt[0]) != 180 || math.Abs(r[i-1][0]) != 180) {
+			return false
+		}
+	}
+	return planar.Area(r) > 0
+}
+
+// splitWrapped cuts an unwrapped geometry into the parts falling into each
+// 360° window of longitude and moves them back into [-180, 180].
+func splitWrapped(g orb.Geometry) []orb.Geometry {
+	b := g.Bound()
+	lo, hi := b.Min[0], b.Max[0]
+	var parts []orb.Geometry
+	for k := math.Floor((lo + 180) / 360); 360*k-180 <= hi; k++ {
+		// Parts that only touch the window edge are found in the next one.
+		if lo < hi && (hi <= 360*k-180 || lo >= 360*k+180) {
+			continue
+		}
+		shifted := orb.Clone(g)
+		shiftLongitudes(shifted, -360*k)
+		if part := clip.Geometry(worldLonLat, shifted); !emptyGeometry(part) {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// shiftLongitudes adds d to the longitudes of the geometry in place.
+func shiftLongitudes(g orb.Geometry, d float64) {
+	switch geom := g.(type) {
+	case orb.LineString:
+		for i := range geom {
+			geom[i][0] += d
+		}
+	case orb.Polygon:
+		for _, r := range geom {
+			for i := range r {
+				r[i][0] += d
+			}
+		}
+	}
+}
+
+// emptyGeometry reports whether a clipped geometry has no points.
+func emptyGeometry(g orb.Geometry) bool {
+	switch geom := g.(type) {
+	case nil:
+		return true
+	case orb.LineString:
+		return len(geom) == 0
+	case orb.MultiLineString:
+		return len(geom) == 0
+	case orb.Polygon:
+		return len(geom) == 0
+	case orb.MultiPolygon:
+		return len(geom) == 0
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package grid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+func TestNormalizeLongitude(t *testing.T) {
+	cases := map[float64]float64{0: 0, 179: 179, 180: -180, 181: -179, -181: 179, 540: -180, -725: -5}
+	for in, want := range cases {
+		if got := NormalizeLongitude(in); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("NormalizeLongitude(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestSplitLonLatBounds(t *testing.T) {
+	cases := []struct {
+		in   Bounds
+		want []Bounds
+	}{
+		{Bounds{MinX: 10, MinY: 0, MaxX: 20, MaxY: 5}, []Bounds{{MinX: 10, MinY: 0, MaxX: 20, MaxY: 5}}},
+		{Bounds{MinX: 170, MinY: 0, MaxX: -170, MaxY: 5}, []Bounds{
+			{MinX: 170, MinY: 0, MaxX: 180, MaxY: 5},
+			{MinX: -180, MinY: 0, MaxX: -170, MaxY: 5},
+		}},
+		{Bounds{MinX: 170, MinY: 0, MaxX: 190, MaxY: 5}, []Bounds{
+			{MinX: 170, MinY: 0, MaxX: 180, MaxY: 5},
+			{MinX: -180, MinY: 0, MaxX: -170, MaxY: 5},
+		}},
+		{Bounds{MinX: -190, MinY: 0, MaxX: -175, MaxY: 5}, []Bounds{
+			{MinX: 170, MinY: 0, MaxX: 180, MaxY: 5},
+			{MinX: -180, MinY: 0, MaxX: -175, MaxY: 5},
+		}},
+		{Bounds{MinX: 160, MinY: 0, MaxX: 180, MaxY: 5}, []Bounds{{MinX: 160, MinY: 0, MaxX: 180, MaxY: 5}}},
+		{Bounds{MinX: -200, MinY: 0, MaxX: 200, MaxY: 5}, []Bounds{{MinX: -180, MinY: 0, MaxX: 180, MaxY: 5}}},
+	}
+	for _, tc := range cases {
+		got := SplitLonLatBounds(tc.in)
+		if len(got) != len(tc.want) {
+			t.Fatalf("SplitLonLatBounds(%+v) = %+v, want %+v", tc.in, got, tc.want)
+		}
+		for i := range got {
+			g, w := got[i], tc.want[i]
+			if math.Abs(g.MinX-w.MinX) > 1e-9 || math.Abs(g.MaxX-w.MaxX) > 1e-9 || g.MinY != w.MinY || g.MaxY != w.MaxY {
+				t.Fatalf("SplitLonLatBounds(%+v) = %+v, want %+v", tc.in, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestSplitAntimeridianPolygon(t *testing.T) {
+	fiji := orb.Polygon{{{177, -16}, {-178, -16}, {-178, -19}, {177, -19}, {177, -16}}}
+	wrapped := orb.Polygon{{{177, -16}, {182, -16}, {182, -19}, {177, -19}, {177, -16}}}
+	for _, poly := range []orb.Polygon{fiji, wrapped} {
+		parts := SplitAntimeridian(poly)
+		if len(parts) != 2 {
+			t.Fatalf("expected 2 parts, got %v", parts)
+		}
+		east, west := parts[0].Bound(), parts[1].Bound()
+		if east.Min[0] != 177 || east.Max[0] != 180 || west.Min[0] != -180 || math.Abs(west.Max[0]+178) > 1e-9 {
+			t.Fatalf("unexpected parts %v and %v", east, west)
+		}
+		if east.Min[1] != -19 || east.Max[1] != -16 || west.Min[1] != -19 || west.Max[1] != -16 {
+			t.Fatalf("unexpected latitudes %v and %v", east, west)
+		}
+	}
+	// The input is not modified.
+	if fiji[0][1][0] != -178 {
+		t.Fatalf("input modified: %v", fiji)
+	}
+}
+
+func TestSplitAntimeridianHole(t *testing.T) {
+	poly := orb.Polygon{
+		{{170, -10}, {-170, -10}, {-170, 10}, {170, 10}, {170, -10}},
+		{{-178, -1}, {-179, -1}, {-179, 1}, {-178, 1}, {-178, -1}},
+	}
+	parts := SplitAntimeridian(poly)
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %v", parts)
+	}
+	// The hole lies west of the antimeridian, in the second part.
+	if p := parts[0].(orb.Polygon); len(p) != 1 {
+		t.Fatalf("eastern part has %d rings", len(p))
+	}
+	if p := parts[1].(orb.Polygon); len(p) != 2 || p[1].Bound().Min[0] != -179 {
+		t.Fatalf("western part = %v", p)
+	}
+}
+
+func TestSplitAntimeridianLineAndPoints(t *testing.T) {
+	line := orb.LineString{{170, 0}, {-170, 10}}
+	parts := SplitAntimeridian(line)
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %v", parts)
+	}
+	if b := parts[1].Bound(); b.Min[0] != -180 || b.Max[0] != -170 {
+		t.Fatalf("western part = %v", b)
+	}
+	// A line that does not cross stays whole.
+	if parts := SplitAntimeridian(orb.LineString{{-10, 0}, {10, 0}}); len(parts) != 1 {
+		t.Fatalf("expected 1 part, got %v", parts)
+	}
+	parts = SplitAntimeridian(orb.MultiPoint{{190, 1}, {-5, 2}})
+	if mp := parts[0].(orb.MultiPoint); mp[0] != (orb.Point{-170, 1}) || mp[1] != (orb.Point{-5, 2}) {
+		t.Fatalf("points = %v", mp)
+	}
+}
+
+func TestSplitAntimeridianPolarRing(t *testing.T) {
+	// Rings around a pole are closed along it and cover the polar cap.
+	rings := []orb.Ring{
+		{{0, 80}, {90, 80}, {180, 80}, {-90, 80}, {0, 80}},
+		{{-180, -70}, {-90, -70}, {0, -70}, {90, -70}, {180, -70}, {-180, -70}},
+	}
+	wantLat := [][2]float64{{80, 90}, {-90, -70}}
+	for i, ring := range rings {
+		parts := SplitAntimeridian(orb.Polygon{ring})
+		lon := orb.Bound{Min: orb.Point{math.Inf(1), math.Inf(1)}, Max: orb.Point{math.Inf(-1), math.Inf(-1)}}
+		area := 0.0
+		for _, part := range parts {
+			lon = lon.Union(part.Bound())
+			area += planar.Area(part)
+		}
+		if lon.Min[0] != -180 || lon.Max[0] != 180 || lon.Min[1] != wantLat[i][0] || lon.Max[1] != wantLat[i][1] {
+			t.Fatalf("ring %d: parts span %v", i, lon)
+		}
+		if want := 360 * (wantLat[i][1] - wantLat[i][0]); math.Abs(area-want) > 1e-6 {
+			t.Fatalf("ring %d: area %v, want %v", i, area, want)
+		}
+		if pole := RingPole(ring); pole != []int{1, -1}[i] {
+			t.Fatalf("ring %d: RingPole = %d", i, pole)
+		}
+	}
+	// A polar cap drawn as bounds from -180 to 180 is kept as drawn.
+	cap := orb.Polygon{{{-180, -90}, {180, -90}, {180, -60}, {-180, -60}, {-180, -90}}}
+	parts := SplitAntimeridian(cap)
+	if len(parts) != 1 || parts[0].Bound() != cap.Bound() {
+		t.Fatalf("cap parts = %v", parts)
+	}
+	if RingPole(orb.Ring{{10, 10}, {20, 10}, {20, 20}, {10, 10}}) != 0 {
+		t.Fatal("expected no pole")
+	}
+}
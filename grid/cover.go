@@ -0,0 +1,201 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/clip"
+	"github.com/paulmach/orb/planar"
+)
+
+// CoverMode selects the tiles that cover a geometry.
+type CoverMode int
+
+const (
+	// CoverIntersects selects the tiles that intersect the geometry.
+	CoverIntersects CoverMode = iota
+	// CoverWithin selects the tiles that lie entirely inside the polygons
+	// of the geometry.
+	CoverWithin
+	// CoverCenter selects the tiles whose center lies inside the geometry.
+	CoverCenter
+	// CoverContains selects the tiles that contain the whole geometry.
+	CoverContains
+)
+
+func (m CoverMode) String() string {
+	switch m {
+	case CoverIntersects:
+		return "intersects"
+	case CoverWithin:
+		return "within"
+	case CoverCenter:
+		return "center"
+	case CoverContains:
+		return "contains"
+	}
+	return fmt.Sprintf("CoverMode(%d)", int(m))
+}
+
+// CoverOptions controls EachTileCovering.
+type CoverOptions struct {
+	// Mode defaults to CoverIntersects.
+	Mode CoverMode
+	// Buffer grows the geometry by a distance in CRS units. It does not
+	// apply to CoverWithin.
+	Buffer float64
+	// Fraction sets TileCover.Fraction, which clips the geometry to each
+	// tile found.
+	Fraction bool
+}
+
+// TileCover is a tile found by EachTileCovering.
+type TileCover struct {
+	TileIndex
+	// Fraction is the share of the tile area covered by the polygons of the
+	// unbuffered geometry, from 0 to 1. It is only set with
+	// CoverOptions.Fraction or in CoverWithin mode.
+	Fraction float64
+}
+
+// EachTileCovering calls fn for the tiles covering the geometry in the given
+// mode, row by row until fn returns false. Coalesced rows are handled.
+func (a TileMatrix) EachTileCovering(g orb.Geometry, opts CoverOptions, fn func(TileCover) bool) {
+	if g == nil {
+		return
+	}
+	buffer := math.Max(opts.Buffer, 0)
+	if opts.Mode == CoverWithin {
+		buffer = 0
+	}
+	bound := g.Bound()
+	outer := orb.Bound{
+		Min: orb.Point{bound.Min[0] - buffer, bound.Min[1] - buffer},
+		Max: orb.Point{bound.Max[0] + buffer, bound.Max[1] + buffer},
+	}
+	tr, ok := a.TileRangeForBounds(Bounds{MinX: outer.Min[0], MinY: outer.Min[1], MaxX: outer.Max[0], MaxY: outer.Max[1]})
+	if !ok {
+		return
+	}
+	for r := tr.MinRow; r <= tr.MaxRow; r++ {
+		first, last := a.RowTileRange(tr, r)
+		if first > last {
+			continue
+		}
+		rb, err := a.BoundsForTile(TileIndex{Col: first, Row: r})
+		if err != nil {
+			continue
+		}
+		// Only the part of the geometry within the buffer of the row counts;
+		// clip modifies its input, so work on a copy.
+		band := orb.Bound{
+			Min: orb.Point{outer.Min[0], rb.MinY - buffer},
+			Max: orb.Point{outer.Max[0], rb.MaxY + buffer},
+		}
+		rowGeom := clip.Geometry(band, orb.Clone(g))
+		if rowGeom == nil {
+			continue
+		}
+		for c := first; c <= last; c++ {
+			idx := TileIndex{Col: c, Row: r}
+			tb, _ := a.BoundsForTile(idx)
+			tile := orb.Bound{Min: orb.Point{tb.MinX, tb.MinY}, Max: orb.Point{tb.MaxX, tb.MaxY}}
+			cover := TileCover{TileIndex: idx}
+			switch opts.Mode {
+			case CoverWithin:
+				// Only tiles with their center inside can be within; the
+				// fraction settles it.
+				if BoundDistance(rowGeom, orb.Bound{Min: tile.Center(), Max: tile.Center()}) > 0 {
+					continue
+				}
+				cover.Fraction = CoveredFraction(rowGeom, tile)
+				if cover.Fraction < 1-overlapTolerance {
+					continue
+				}
+			case CoverCenter:
+				if BoundDistance(rowGeom, orb.Bound{Min: tile.Center(), Max: tile.Center()}) > buffer {
+					continue
+				}
+			case CoverContains:
+				if !tile.Contains(outer.Min) || !tile.Contains(outer.Max) {
+					continue
+				}
+			default:
+				if tileDistance(rowGeom, tile) > buffer {
+					continue
+				}
+			}
+			if opts.Fraction && opts.Mode != CoverWithin {
+				cover.Fraction = CoveredFraction(rowGeom, tile)
+			}
+			if !fn(cover) {
+				return
+			}
+		}
+	}
+}
+
+// CoveredFraction returns the share of the bound's area covered by the
+// polygons of the geometry, from 0 to 1. Points and lines cover nothing.
+func CoveredFraction(g orb.Geometry, b orb.Bound) float64 {
+	area := (b.Max[0] - b.Min[0]) * (b.Max[1] - b.Min[1])
+	if !(area > 0) {
+		return 0
+	}
+	return math.Min(1, math.Max(0, coveredArea(g, b)/area))
+}
+
+// coveredArea returns the area of the polygons of the geometry within the
+// bound.
+func coveredArea(g orb.Geometry, b orb.Bound) float64 {
+	switch geom := g.(type) {
+	case orb.Ring:
+		return coveredArea(orb.Polygon{geom}, b)
+	case orb.Bound:
+		return coveredArea(geom.ToPolygon(), b)
+	case orb.Polygon:
+		if !b.Intersects(geom.Bound()) {
+			return 0
+		}
+		if clipped := clip.Polygon(b, geom.Clone()); len(clipped) > 0 {
+			return planar.Area(clipped)
+		}
+	case orb.MultiPolygon:
+		var sum float64
+		for _, poly := range geom {
+			sum += coveredArea(poly, b)
+		}
+		return sum
+	case orb.Collection:
+		var sum float64
+		for _, sub := range geom {
+			sum += coveredArea(sub, b)
+		}
+		return sum
+	}
+	return 0
+}
+
+// tileDistance is BoundDistance with polygons measured against the tile
+// shrunk by a fraction of its size, so that polygons sharing an edge with the
+// tile do not count as intersecting it.
+func tileDistance(g orb.Geometry, tile orb.Bound) float64 {
+	switch geom := g.(type) {
+	case orb.Ring, orb.Polygon, orb.MultiPolygon, orb.Bound:
+		ex := overlapTolerance * (tile.Max[0] - tile.Min[0])
+		ey := overlapTolerance * (tile.Max[1] - tile.Min[1])
+		inner := orb.Bound{
+			Min: orb.Point{tile.Min[0] + ex, tile.Min[1] + ey},
+			Max: orb.Point{tile.Max[0] - ex, tile.Max[1] - ey},
+		}
+		return BoundDistance(g, inner)
+	case orb.Collection:
+		d := math.Inf(1)
+		for _, sub := range geom {
+			d = math.Min(d, tileDistance(sub, tile))
+		}
+		return d
+	}
+	return BoundDistance(g, tile)
+}
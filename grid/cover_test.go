@@ -0,0 +1,102 @@
+package grid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hafenkran/gocantile/tms"
+	"github.com/paulmach/orb"
+)
+
+// unitAdapter has 4x4 tiles of one unit with the origin at the top left.
+func unitAdapter() TileMatrix {
+	return TileMatrix{TM: tms.TileMatrix{
+		CellSize: 1, TileWidth: 1, TileHeight: 1, MatrixWidth: 4, MatrixHeight: 4,
+		PointOfOrigin: []float64{0, 4},
+	}}
+}
+
+func collectCovers(a TileMatrix, g orb.Geometry, opts CoverOptions) map[TileIndex]float64 {
+	got := map[TileIndex]float64{}
+	a.EachTileCovering(g, opts, func(c TileCover) bool {
+		got[c.TileIndex] = c.Fraction
+		return true
+	})
+	return got
+}
+
+func TestEachTileCoveringModes(t *testing.T) {
+	a := unitAdapter()
+	// Covers x 0.5..2.7 and y 0.5..2.4, i.e. tile (1,2) entirely and the
+	// centers of (0,3), (1,3), (0,2), (1,2), (2,2), (2,3) but not (1,1).
+	poly := orb.Polygon{{{0.5, 0.5}, {2.7, 0.5}, {2.7, 2.4}, {0.5, 2.4}, {0.5, 0.5}}}
+
+	if got := collectCovers(a, poly, CoverOptions{}); len(got) != 9 {
+		t.Fatalf("intersects: %v", got)
+	}
+	got := collectCovers(a, poly, CoverOptions{Mode: CoverWithin})
+	if len(got) != 1 || got[TileIndex{Col: 1, Row: 2}] != 1 {
+		t.Fatalf("within: %v", got)
+	}
+	got = collectCovers(a, poly, CoverOptions{Mode: CoverCenter})
+	if len(got) != 6 {
+		t.Fatalf("center: %v", got)
+	}
+	for _, idx := range []TileIndex{{0, 3}, {1, 3}, {2, 3}, {0, 2}, {1, 2}, {2, 2}} {
+		if _, ok := got[idx]; !ok {
+			t.Fatalf("center: missing %v in %v", idx, got)
+		}
+	}
+	// A buffer moves the center test out to the center of (0,1).
+	if got := collectCovers(a, poly, CoverOptions{Mode: CoverCenter, Buffer: 0.2}); len(got) != 9 {
+		t.Fatalf("buffered center: %v", got)
+	}
+	// Only a geometry within a single tile is contained.
+	if got := collectCovers(a, poly, CoverOptions{Mode: CoverContains}); len(got) != 0 {
+		t.Fatalf("contains: %v", got)
+	}
+	small := orb.LineString{{1.2, 1.2}, {1.8, 1.7}}
+	got = collectCovers(a, small, CoverOptions{Mode: CoverContains})
+	if len(got) != 1 || got[TileIndex{Col: 1, Row: 2}] != 0 {
+		t.Fatalf("contains line: %v", got)
+	}
+	if got := collectCovers(a, small, CoverOptions{Mode: CoverContains, Buffer: 0.3}); len(got) != 0 {
+		t.Fatalf("contains buffered line: %v", got)
+	}
+	// Lines have no area, so no tile lies within them.
+	if got := collectCovers(a, small, CoverOptions{Mode: CoverWithin}); len(got) != 0 {
+		t.Fatalf("within line: %v", got)
+	}
+}
+
+func TestEachTileCoveringFraction(t *testing.T) {
+	a := unitAdapter()
+	poly := orb.Polygon{{{0.5, 0.5}, {2.7, 0.5}, {2.7, 2.4}, {0.5, 2.4}, {0.5, 0.5}}}
+	got := collectCovers(a, poly, CoverOptions{Fraction: true})
+	want := map[TileIndex]float64{
+		{0, 3}: 0.25, {1, 3}: 0.5, {2, 3}: 0.35,
+		{0, 2}: 0.5, {1, 2}: 1, {2, 2}: 0.7,
+		{0, 1}: 0.2, {1, 1}: 0.4, {2, 1}: 0.28,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fractions: %v", got)
+	}
+	for idx, f := range want {
+		if math.Abs(got[idx]-f) > 1e-9 {
+			t.Fatalf("fraction of %v = %v, want %v", idx, got[idx], f)
+		}
+	}
+	// Adjacent polygons sharing an edge add up, so a tile split between
+	// them is within the geometry.
+	split := orb.MultiPolygon{
+		{{{0, 0}, {1.5, 0}, {1.5, 1}, {0, 1}, {0, 0}}},
+		{{{1.5, 0}, {2, 0}, {2, 1}, {1.5, 1}, {1.5, 0}}},
+	}
+	within := collectCovers(a, split, CoverOptions{Mode: CoverWithin})
+	if len(within) != 2 || within[TileIndex{Col: 1, Row: 3}] != 1 {
+		t.Fatalf("within split: %v", within)
+	}
+	if f := CoveredFraction(orb.Point{1, 1}, orb.Bound{Max: orb.Point{2, 2}}); f != 0 {
+		t.Fatalf("point fraction %v", f)
+	}
+}
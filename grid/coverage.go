@@ -0,0 +1,334 @@
+package grid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Coverage is a compact set of tiles for large coverages. Per zoom level and
+// row it stores sorted, disjoint runs of columns, so a contiguous area takes
+// memory in proportion to its number of rows rather than its number of
+// tiles. Unlike the set operations of TilesList, tiles of different zoom
+// levels are independent. The zero value is not usable; use NewCoverage.
+type Coverage struct {
+	zooms map[int]map[int][]colRun
+}
+
+// colRun is an inclusive run of columns.
+type colRun struct {
+	start, end int
+}
+
+// coverageMagic starts the binary encoding of a Coverage, followed by the
+// format version.
+const (
+	coverageMagic   = "GCOV"
+	coverageVersion = 1
+)
+
+// NewCoverage returns an empty coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{zooms: map[int]map[int][]colRun{}}
+}
+
+// Add adds the tile.
+func (c *Coverage) Add(t Tile) {
+	c.AddRun(t.Zoom, t.Row, t.Col, t.Col)
+}
+
+// AddRun adds the tiles of the row from minCol to maxCol inclusive.
+func (c *Coverage) AddRun(zoom, row, minCol, maxCol int) {
+	if minCol > maxCol {
+		return
+	}
+	rows := c.zooms[zoom]
+	if rows == nil {
+		rows = map[int][]colRun{}
+		c.zooms[zoom] = rows
+	}
+	rows[row] = insertRun(rows[row], colRun{minCol, maxCol})
+}
+
+// insertRun adds r to the sorted runs, merging overlapping and adjacent runs.
+func insertRun(runs []colRun, r colRun) []colRun {
+	// First run that ends at or after the column before r.
+	i := sort.Search(len(runs), func(i int) bool { return runs[i].end >= r.start-1 })
+	j := i
+	for j < len(runs) && runs[j].start <= r.end+1 {
+		r.start = min(r.start, runs[j].start)
+		r.end = max(r.end, runs[j].end)
+		j++
+	}
+	if i == j {
+		runs = append(runs, colRun{})
+		copy(runs[i+1:], runs[i:])
+		runs[i] = r
+		return runs
+	}
+	runs[i] = r
+	return append(runs[:i+1], runs[j:]...)
+}
+
+// Contains reports whether the tile is in the coverage.
+func (c *Coverage) Contains(t Tile) bool {
+	runs := c.zooms[t.Zoom][t.Row]
+	i := sort.Search(len(runs), func(i int) bool { return runs[i].end >= t.Col })
+	return i < len(runs) && runs[i].start <= t.Col
+}
+
+// Count returns the number of tiles.
+func (c *Coverage) Count() int {
+	n := 0
+	for _, rows := range c.zooms {
+		for _, runs := range rows {
+			for _, r := range runs {
+				n += r.end - r.start + 1
+			}
+		}
+	}
+	return n
+}
+
+// Zooms returns the zoom levels with tiles in ascending order.
+func (c *Coverage) Zooms() []int {
+	zooms := make([]int, 0, len(c.zooms))
+	for z, rows := range c.zooms {
+		if len(rows) > 0 {
+			zooms = append(zooms, z)
+		}
+	}
+	sort.Ints(zooms)
+	return zooms
+}
+
+// sortedRows returns the rows of the zoom level in ascending order.
+func (c *Coverage) sortedRows(zoom int) []int {
+	rows := make([]int, 0, len(c.zooms[zoom]))
+	for r := range c.zooms[zoom] {
+		rows = append(rows, r)
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+// Each calls fn for every tile, sorted by zoom, row and column, until fn
+// returns false.
+func (c *Coverage) Each(fn func(Tile) bool) {
+	c.EachRun(func(zoom, row, minCol, maxCol int) bool {
+		for col := minCol; col <= maxCol; col++ {
+			if !fn(Tile{Zoom: zoom, TileIndex: TileIndex{Col: col, Row: row}}) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// EachRun calls fn for every run of columns, sorted by zoom, row and column,
+// until fn returns false.
+func (c *Coverage) EachRun(fn func(zoom, row, minCol, maxCol int) bool) {
+	for _, z := range c.Zooms() {
+		for _, row := range c.sortedRows(z) {
+			for _, r := range c.zooms[z][row] {
+				if !fn(z, row, r.start, r.end) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// TilesList returns the tiles sorted by zoom, row and column.
+func (c *Coverage) TilesList() TilesList {
+	out := make(TilesList, 0, c.Count())
+	c.Each(func(t Tile) bool {
+		out = append(out, t)
+		return true
+	})
+	return out
+}
+
+// Coverage returns the tiles of the list as a Coverage.
+func (l TilesList) Coverage() *Coverage {
+	c := NewCoverage()
+	for _, t := range l {
+		c.Add(t)
+	}
+	return c
+}
+
+// Union returns the tiles in either coverage.
+func (c *Coverage) Union(other *Coverage) *Coverage {
+	out := NewCoverage()
+	for _, src := range []*Coverage{c, other} {
+		src.EachRun(func(zoom, row, minCol, maxCol int) bool {
+			out.AddRun(zoom, row, minCol, maxCol)
+			return true
+		})
+	}
+	return out
+}
+
+// Intersect returns the tiles in both coverages.
+func (c *Coverage) Intersect(other *Coverage) *Coverage {
+	out := NewCoverage()
+	for z, rows := range c.zooms {
+		otherRows := other.zooms[z]
+		for row, a := range rows {
+			b := otherRows[row]
+			for i, j := 0, 0; i < len(a) && j < len(b); {
+				start, end := max(a[i].start, b[j].start), min(a[i].end, b[j].end)
+				if start <= end {
+					out.AddRun(z, row, start, end)
+				}
+				if a[i].end < b[j].end {
+					i++
+				} else {
+					j++
+				}
+			}
+		}
+	}
+	return out
+}
+
+// MarshalBinary encodes the coverage as varints: per zoom level its rows, and
+// per row its runs as the gap to the previous run and the run length.
+func (c *Coverage) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(coverageMagic)
+	buf.WriteByte(coverageVersion)
+	tmp := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) { buf.Write(tmp[:binary.PutUvarint(tmp, v)]) }
+	putVarint := func(v int64) { buf.Write(tmp[:binary.PutVarint(tmp, v)]) }
+
+	zooms := c.Zooms()
+	putUvarint(uint64(len(zooms)))
+	for _, z := range zooms {
+		putVarint(int64(z))
+		rows := c.sortedRows(z)
+		putUvarint(uint64(len(rows)))
+		prevRow := 0
+		for _, row := range rows {
+			putVarint(int64(row - prevRow))
+			prevRow = row
+			runs := c.zooms[z][row]
+			putUvarint(uint64(len(runs)))
+			// The first run is stored by its start column, which may be
+			// negative; later runs by the gap to the previous run.
+			prevEnd := -1
+			for i, r := range runs {
+				if i == 0 {
+					putVarint(int64(r.start))
+				} else {
+					putUvarint(uint64(r.start - prevEnd - 2))
+				}
+				putUvarint(uint64(r.end - r.start))
+				prevEnd = r.end
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a coverage encoded by MarshalBinary.
+func (c *Coverage) UnmarshalBinary(data []byte) error {
+	if len(data) < len(coverageMagic)+1 || string(data[:len(coverageMagic)]) != coverageMagic {
+		return errors.New("not a coverage encoding")
+	}
+	if v := data[len(coverageMagic)]; v != coverageVersion {
+		return fmt.Errorf("unsupported coverage version %d", v)
+	}
+	r := bytes.NewReader(data[len(coverageMagic)+1:])
+	var err error
+	uvarint := func() int {
+		if err != nil {
+			return 0
+		}
+		var v uint64
+		v, err = binary.ReadUvarint(r)
+		return int(v)
+	}
+	varint := func() int {
+		if err != nil {
+			return 0
+		}
+		var v int64
+		v, err = binary.ReadVarint(r)
+		return int(v)
+	}
+
+	out := NewCoverage()
+	nZooms := uvarint()
+	for zi := 0; zi < nZooms && err == nil; zi++ {
+		z := varint()
+		nRows := uvarint()
+		row := 0
+		for ri := 0; ri < nRows && err == nil; ri++ {
+			row += varint()
+			nRuns := uvarint()
+			prevEnd := -1
+			for i := 0; i < nRuns && err == nil; i++ {
+				var start int
+				if i == 0 {
+					start = varint()
+				} else {
+					start = prevEnd + 2 + uvarint()
+				}
+				end := start + uvarint()
+				if err == nil {
+					out.AddRun(z, row, start, end)
+				}
+				prevEnd = end
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("decode coverage: %w", err)
+	}
+	if r.Len() != 0 {
+		return errors.New("decode coverage: trailing data")
+	}
+	*c = *out
+	return nil
+}
+
+// MarshalJSON encodes the coverage as an object keyed by zoom level whose
+// values list [row, minCol, maxCol] runs, e.g. {"3":[[2,4,5],[3,4,4]]}.
+func (c *Coverage) MarshalJSON() ([]byte, error) {
+	out := make(map[string][][3]int, len(c.zooms))
+	c.EachRun(func(zoom, row, minCol, maxCol int) bool {
+		key := strconv.Itoa(zoom)
+		out[key] = append(out[key], [3]int{row, minCol, maxCol})
+		return true
+	})
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a coverage encoded by MarshalJSON.
+func (c *Coverage) UnmarshalJSON(data []byte) error {
+	var in map[string][][3]int
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	out := NewCoverage()
+	for key, runs := range in {
+		z, err := strconv.Atoi(key)
+		if err != nil {
+			return fmt.Errorf("invalid zoom %q", key)
+		}
+		for _, r := range runs {
+			if r[1] > r[2] {
+				return fmt.Errorf("invalid run %v at zoom %d", r, z)
+			}
+			out.AddRun(z, r[0], r[1], r[2])
+		}
+	}
+	*c = *out
+	return nil
+}
@@ -0,0 +1,109 @@
+package grid
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCoverageRuns(t *testing.T) {
+	tile := func(z, x, y int) Tile { return Tile{Zoom: z, TileIndex: TileIndex{Col: x, Row: y}} }
+	c := NewCoverage()
+	for _, x := range []int{5, 1, 2, 9, 3, 7, 8, 3} {
+		c.Add(tile(4, x, 2))
+	}
+	c.AddRun(4, 0, 10, 12)
+	c.AddRun(2, 1, -1, 0)
+	if got := c.zooms[4][2]; !reflect.DeepEqual(got, []colRun{{1, 3}, {5, 5}, {7, 9}}) {
+		t.Fatalf("runs %v", got)
+	}
+	// Filling the gap merges the runs around it.
+	c.AddRun(4, 2, 4, 6)
+	if got := c.zooms[4][2]; !reflect.DeepEqual(got, []colRun{{1, 9}}) {
+		t.Fatalf("runs %v", got)
+	}
+	if c.Count() != 9+3+2 {
+		t.Fatalf("count %d", c.Count())
+	}
+	if !c.Contains(tile(4, 9, 2)) || c.Contains(tile(4, 10, 2)) || c.Contains(tile(3, 1, 2)) {
+		t.Fatalf("contains")
+	}
+	if got := c.Zooms(); !reflect.DeepEqual(got, []int{2, 4}) {
+		t.Fatalf("zooms %v", got)
+	}
+
+	list := c.TilesList()
+	if len(list) != c.Count() || list[0] != tile(2, -1, 1) || list[2] != tile(4, 10, 0) {
+		t.Fatalf("tiles %v", list)
+	}
+	if got := list.Coverage().TilesList(); !reflect.DeepEqual(got, list) {
+		t.Fatalf("round trip %v", got)
+	}
+	var n int
+	c.Each(func(Tile) bool { n++; return n < 3 })
+	if n != 3 {
+		t.Fatalf("each stopped after %d", n)
+	}
+}
+
+func TestCoverageUnionIntersect(t *testing.T) {
+	a, b := NewCoverage(), NewCoverage()
+	a.AddRun(3, 1, 0, 4)
+	a.AddRun(3, 1, 8, 10)
+	a.AddRun(3, 2, 0, 1)
+	b.AddRun(3, 1, 3, 9)
+	b.AddRun(5, 0, 0, 0)
+
+	u := a.Union(b)
+	if got := u.zooms[3][1]; !reflect.DeepEqual(got, []colRun{{0, 10}}) || u.Count() != 11+2+1 {
+		t.Fatalf("union %v", u.TilesList())
+	}
+	in := a.Intersect(b)
+	if got := in.zooms[3][1]; !reflect.DeepEqual(got, []colRun{{3, 4}, {8, 9}}) || in.Count() != 4 {
+		t.Fatalf("intersection %v", in.TilesList())
+	}
+}
+
+func TestCoverageEncoding(t *testing.T) {
+	c := NewCoverage()
+	c.AddRun(16, 21000, 34000, 35999)
+	c.AddRun(16, 21000, 36500, 36600)
+	c.AddRun(16, 21001, -3, 2)
+	c.AddRun(0, 0, 0, 0)
+
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got Coverage
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got.TilesList(), c.TilesList()) {
+		t.Fatalf("binary round trip differs")
+	}
+	if err := got.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Fatalf("expected error for truncated data")
+	}
+	if err := got.UnmarshalBinary([]byte("nope")); err == nil {
+		t.Fatalf("expected error for foreign data")
+	}
+
+	js, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+	if want := `{"0":[[0,0,0]],"16":[[21000,34000,35999],[21000,36500,36600],[21001,-3,2]]}`; string(js) != want {
+		t.Fatalf("json %s", js)
+	}
+	var fromJSON Coverage
+	if err := json.Unmarshal(js, &fromJSON); err != nil {
+		t.Fatalf("unmarshal json: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON.TilesList(), c.TilesList()) {
+		t.Fatalf("json round trip differs")
+	}
+	if err := json.Unmarshal([]byte(`{"1":[[0,3,2]]}`), &fromJSON); err == nil {
+		t.Fatalf("expected error for an inverted run")
+	}
+}
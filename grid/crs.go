@@ -3,6 +3,8 @@ package grid
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/hafenkran/gocantile/tms"
@@ -62,3 +64,96 @@ func normalizeCRSString(s string) string {
 	}
 	return s
 }
+
+// earthRadius is the WGS84 semi-major axis in meters.
+const earthRadius = 6378137.0
+
+// geographicCRS lists CRS codes whose axes are in degrees.
+var geographicCRS = map[string]struct{}{
+	"EPSG:4326": {},
+	"EPSG:4258": {},
+	"EPSG:4269": {},
+	"EPSG:4283": {},
+	"EPSG:4617": {},
+	"EPSG:4979": {},
+	"OGC:CRS84": {},
+}
+
+// IsGeographicCRS reports whether the CRS string (as returned by ExtractCRS)
+// refers to a known geographic CRS with degree units.
+func IsGeographicCRS(crs string) bool {
+	s := normalizeCRSString(crs)
+	if _, ok := geographicCRS[s]; ok {
+		return true
+	}
+	return strings.HasSuffix(strings.ToUpper(s), "/CRS84")
+}
+
+// usSurveyFoot is the length of the US survey foot in meters.
+const usSurveyFoot = 1200.0 / 3937
+
+// projectedCRSUnits lists the meters per axis unit of projected CRSs other
+// than the UTM zones, which MetersPerUnit recognizes by their code.
+var projectedCRSUnits = map[string]float64{
+	"EPSG:3857":  1,            // WGS 84 / Pseudo-Mercator
+	"EPSG:3395":  1,            // WGS 84 / World Mercator
+	"EPSG:3035":  1,            // ETRS89-extended / LAEA Europe
+	"EPSG:3034":  1,            // ETRS89-extended / LCC Europe
+	"EPSG:3978":  1,            // NAD83 / Canada Atlas Lambert
+	"EPSG:5041":  1,            // WGS 84 / UPS North (E,N)
+	"EPSG:5042":  1,            // WGS 84 / UPS South (E,N)
+	"EPSG:3413":  1,            // WGS 84 / NSIDC Sea Ice Polar Stereographic North
+	"EPSG:3031":  1,            // WGS 84 / Antarctic Polar Stereographic
+	"EPSG:2056":  1,            // CH1903+ / LV95
+	"EPSG:2154":  1,            // RGF93 v1 / Lambert-93
+	"EPSG:27700": 1,            // OSGB36 / British National Grid
+	"EPSG:28992": 1,            // Amersfoort / RD New
+	"EPSG:31467": 1,            // DHDN / 3-degree Gauss-Kruger zone 3
+	"EPSG:5070":  1,            // NAD83 / Conus Albers
+	"EPSG:2263":  usSurveyFoot, // NAD83 / New York Long Island (ftUS)
+	"EPSG:2227":  usSurveyFoot, // NAD83 / California zone 3 (ftUS)
+	"EPSG:2229":  usSurveyFoot, // NAD83 / California zone 5 (ftUS)
+	"EPSG:2277":  usSurveyFoot, // NAD83 / Texas Central (ftUS)
+	"EPSG:2278":  usSurveyFoot, // NAD83 / Texas South Central (ftUS)
+	"EPSG:2236":  usSurveyFoot, // NAD83 / Florida East (ftUS)
+}
+
+// utmZoneRanges lists the EPSG code ranges of metric UTM zones: WGS 84 north
+// and south, ETRS89 and NAD83.
+var utmZoneRanges = [][2]int{{32601, 32660}, {32701, 32760}, {25828, 25838}, {26901, 26923}}
+
+// MetersPerUnit returns the number of meters per CRS unit. Geographic CRSs use
+// the length of one degree on the WGS84 semi-major axis as in OGC TMS 2.0.0.
+// Projected CRSs are looked up in a table of known units, including US survey
+// foot state plane systems; other CRSs return an error rather than a guess.
+func MetersPerUnit(crs string) (float64, error) {
+	if IsGeographicCRS(crs) {
+		return 2 * math.Pi * earthRadius / 360, nil
+	}
+	s := normalizeCRSString(crs)
+	if mpu, ok := projectedCRSUnits[s]; ok {
+		return mpu, nil
+	}
+	if code, err := strconv.Atoi(strings.TrimPrefix(s, "EPSG:")); err == nil && strings.HasPrefix(s, "EPSG:") {
+		for _, r := range utmZoneRanges {
+			if code >= r[0] && code <= r[1] {
+				return 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown unit of crs %q", crs)
+}
+
+// NorthingFirst reports whether orderedAxes lists the northing or latitude
+// axis first, e.g. ["Lat","Lon"] for EPSG:4326 or ["Y","X"] for EPSG:3035.
+// Coordinates such as pointOfOrigin are then given northing first.
+func NorthingFirst(orderedAxes []string) bool {
+	if len(orderedAxes) == 0 {
+		return false
+	}
+	switch strings.ToLower(orderedAxes[0]) {
+	case "lat", "latitude", "y", "n", "northing", "north":
+		return true
+	}
+	return false
+}
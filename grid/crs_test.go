@@ -73,3 +73,48 @@ func TestExtractCRSUnsupportedType(t *testing.T) {
 		t.Fatalf("expected error for unsupported crs type")
 	}
 }
+
+func TestMetersPerUnit(t *testing.T) {
+	for crs, want := range map[string]float64{
+		"EPSG:3857": 1,
+		"http://www.opengis.net/def/crs/EPSG/0/32631": 1,
+		"EPSG:32760": 1,
+		"EPSG:2263":  0.3048006096012192,
+	} {
+		if got, err := MetersPerUnit(crs); err != nil || !approxEqual(got, want, 1e-12) {
+			t.Fatalf("meters per unit for %s = %v, %v; want %v", crs, got, err, want)
+		}
+	}
+	for _, crs := range []string{"EPSG:4326", "http://www.opengis.net/def/crs/OGC/1.3/CRS84", "urn:ogc:def:crs:EPSG::4326"} {
+		if got, err := MetersPerUnit(crs); err != nil || !approxEqual(got, 111319.49079327357, 1e-6) {
+			t.Fatalf("unexpected meters per unit for %s: %f, %v", crs, got, err)
+		}
+	}
+	// Unknown CRSs, such as other geographic CRSs, are not taken as metric.
+	for _, crs := range []string{"EPSG:4674", "EPSG:32661", "EPSG:9999999", `PROJCRS["custom"]`} {
+		if _, err := MetersPerUnit(crs); err == nil {
+			t.Fatalf("expected error for %s", crs)
+		}
+	}
+}
+
+func TestNorthingFirst(t *testing.T) {
+	cases := map[string]bool{
+		"Lat,Lon": true,
+		"Y,X":     true,
+		"N,E":     true,
+		"Lon,Lat": false,
+		"X,Y":     false,
+		"E,N":     false,
+		"":        false,
+	}
+	for axes, want := range cases {
+		var ordered []string
+		if axes != "" {
+			ordered = strings.Split(axes, ",")
+		}
+		if got := NorthingFirst(ordered); got != want {
+			t.Fatalf("NorthingFirst(%q) = %v, want %v", axes, got, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package grid
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Curve is a space-filling curve that orders the tiles of a zoom level.
+type Curve int
+
+const (
+	// HilbertCurve keeps consecutive tiles adjacent; with a shift of 0 its
+	// ids are PMTiles tile ids.
+	HilbertCurve Curve = iota
+	// MortonCurve (Z-order) interleaves the bits of column and row, column
+	// first, and orders tiles like their quadkeys.
+	MortonCurve
+)
+
+// MaxCurveOrder is the highest curve order, zoom plus shift, for which tile
+// ids fit into a uint64.
+const MaxCurveOrder = 31
+
+func (c Curve) String() string {
+	switch c {
+	case HilbertCurve:
+		return "hilbert"
+	case MortonCurve:
+		return "morton"
+	}
+	return fmt.Sprintf("Curve(%d)", int(c))
+}
+
+// Curve tile ids number the tiles of all zoom levels in one sequence: the
+// tiles at zoom z are ordered along a curve of order z+shift, covering a
+// square of 2^(z+shift) tiles per side, and preceded by the squares of all
+// lower zoom levels. A quadtree with 2^z x 2^z tiles at zoom z uses shift 0;
+// sets whose matrices are wider, such as WorldCRS84Quad with 2^(z+1) x 2^z,
+// use the smallest shift that fits every matrix. Ids are unique across zoom
+// levels for a given shift.
+
+// curveZoomStart returns the first id at zoom z: the squares of the lower
+// zoom levels hold (4^(z+shift) - 4^shift) / 3 ids.
+func curveZoomStart(z, shift int) uint64 {
+	// 1<<64 wraps to 0, so the difference stays exact at the maximum order.
+	return ((uint64(1) << (2 * (z + shift))) - (uint64(1) << (2 * shift))) / 3
+}
+
+// CurveTileID returns the id of the tile along curve c for the given shift.
+// Col and Row are used as they are; for PMTiles these are XYZ x and y.
+func CurveTileID(c Curve, t Tile, shift int) (uint64, error) {
+	if shift < 0 || t.Zoom < 0 || t.Zoom+shift > MaxCurveOrder {
+		return 0, fmt.Errorf("zoom %d with shift %d out of range 0..%d", t.Zoom, shift, MaxCurveOrder)
+	}
+	order := t.Zoom + shift
+	n := uint64(1) << order
+	if t.Col < 0 || t.Row < 0 || uint64(t.Col) >= n || uint64(t.Row) >= n {
+		return 0, fmt.Errorf("tile out of range col=%d row=%d at zoom %d", t.Col, t.Row, t.Zoom)
+	}
+	var d uint64
+	switch c {
+	case HilbertCurve:
+		d = hilbertIndex(n, uint64(t.Col), uint64(t.Row))
+	case MortonCurve:
+		d = mortonIndex(uint64(t.Col), uint64(t.Row))
+	default:
+		return 0, fmt.Errorf("unknown curve %v", c)
+	}
+	return curveZoomStart(t.Zoom, shift) + d, nil
+}
+
+// CurveTileFromID returns the tile of an id returned by CurveTileID.
+func CurveTileFromID(c Curve, id uint64, shift int) (Tile, error) {
+	if shift < 0 || shift > MaxCurveOrder {
+		return Tile{}, fmt.Errorf("shift %d out of range 0..%d", shift, MaxCurveOrder)
+	}
+	z := 0
+	for ; z+shift <= MaxCurveOrder; z++ {
+		if id < curveZoomStart(z+1, shift) {
+			break
+		}
+	}
+	if z+shift > MaxCurveOrder {
+		return Tile{}, fmt.Errorf("tile id %d out of range", id)
+	}
+	d := id - curveZoomStart(z, shift)
+	var x, y uint64
+	switch c {
+	case HilbertCurve:
+		x, y = hilbertPoint(uint64(1)<<(z+shift), d)
+	case MortonCurve:
+		x, y = mortonPoint(d)
+	default:
+		return Tile{}, fmt.Errorf("unknown curve %v", c)
+	}
+	return Tile{Zoom: z, TileIndex: TileIndex{Col: int(x), Row: int(y)}}, nil
+}
+
+// SortByCurve sorts the list by the ids of curve c for the given shift, i.e.
+// by zoom and then along the curve. Tiles without an id sort last, by zoom,
+// row and column.
+func (l TilesList) SortByCurve(c Curve, shift int) {
+	type keyed struct {
+		id  uint64
+		ok  bool
+		pos int
+	}
+	keys := make([]keyed, len(l))
+	for i, t := range l {
+		id, err := CurveTileID(c, t, shift)
+		keys[i] = keyed{id: id, ok: err == nil, pos: i}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.ok != b.ok {
+			return a.ok
+		}
+		if a.ok {
+			return a.id < b.id
+		}
+		return l[a.pos].Less(l[b.pos])
+	})
+	sorted := make(TilesList, len(l))
+	for i, k := range keys {
+		sorted[i] = l[k.pos]
+	}
+	copy(l, sorted)
+}
+
+// hilbertIndex returns the position of (x, y) on the Hilbert curve filling
+// an n x n square.
+func hilbertIndex(n, x, y uint64) uint64 {
+	var d uint64
+	for s := n / 2; s > 0; s /= 2 {
+		var rx, ry uint64
+		if x&s > 0 {
+			rx = 1
+		}
+		if y&s > 0 {
+			ry = 1
+		}
+		d += s * s * ((3 * rx) ^ ry)
+		x, y = hilbertRotate(n, x, y, rx, ry)
+	}
+	return d
+}
+
+// hilbertPoint returns the cell at position d on the Hilbert curve filling an
+// n x n square.
+func hilbertPoint(n, d uint64) (uint64, uint64) {
+	var x, y uint64
+	for s := uint64(1); s < n; s *= 2 {
+		rx := 1 & (d / 2)
+		ry := 1 & (d ^ rx)
+		x, y = hilbertRotate(s, x, y, rx, ry)
+		x += s * rx
+		y += s * ry
+		d /= 4
+	}
+	return x, y
+}
+
+// hilbertRotate flips and transposes the quadrant of size n.
+func hilbertRotate(n, x, y, rx, ry uint64) (uint64, uint64) {
+	if ry == 0 {
+		if rx == 1 {
+			x = n - 1 - x
+			y = n - 1 - y
+		}
+		x, y = y, x
+	}
+	return x, y
+}
+
+// mortonIndex interleaves the bits of x and y, x in the even bits.
+func mortonIndex(x, y uint64) uint64 {
+	return spreadBits(x) | spreadBits(y)<<1
+}
+
+// mortonPoint splits a Morton index into x and y.
+func mortonPoint(d uint64) (uint64, uint64) {
+	return compactBits(d), compactBits(d >> 1)
+}
+
+// spreadBits moves the lower 32 bits of v to the even bit positions.
+func spreadBits(v uint64) uint64 {
+	v &= 0xffffffff
+	v = (v | v<<16) & 0x0000ffff0000ffff
+	v = (v | v<<8) & 0x00ff00ff00ff00ff
+	v = (v | v<<4) & 0x0f0f0f0f0f0f0f0f
+	v = (v | v<<2) & 0x3333333333333333
+	v = (v | v<<1) & 0x5555555555555555
+	return v
+}
+
+// compactBits gathers the even bits of v into the lower 32 bits.
+func compactBits(v uint64) uint64 {
+	v &= 0x5555555555555555
+	v = (v | v>>1) & 0x3333333333333333
+	v = (v | v>>2) & 0x0f0f0f0f0f0f0f0f
+	v = (v | v>>4) & 0x00ff00ff00ff00ff
+	v = (v | v>>8) & 0x0000ffff0000ffff
+	v = (v | v>>16) & 0x00000000ffffffff
+	return v
+}
@@ -0,0 +1,115 @@
+package grid
+
+import "testing"
+
+func TestCurveTileIDMorton(t *testing.T) {
+	cases := []struct {
+		zoom, col, row, shift int
+		id                    uint64
+	}{
+		{0, 0, 0, 0, 0},
+		{1, 0, 0, 0, 1},
+		{1, 1, 0, 0, 2},
+		{1, 0, 1, 0, 3},
+		{1, 1, 1, 0, 4},
+		{2, 3, 1, 0, 5 + 7},
+		// With shift 1 zoom 0 holds 2x2 tiles, zoom 1 starts at 4.
+		{0, 1, 0, 1, 1},
+		{1, 0, 0, 1, 4},
+	}
+	for _, tc := range cases {
+		tile := Tile{Zoom: tc.zoom, TileIndex: TileIndex{Col: tc.col, Row: tc.row}}
+		id, err := CurveTileID(MortonCurve, tile, tc.shift)
+		if err != nil || id != tc.id {
+			t.Fatalf("CurveTileID(%+v, %d) = %d, %v; want %d", tile, tc.shift, id, err, tc.id)
+		}
+		back, err := CurveTileFromID(MortonCurve, id, tc.shift)
+		if err != nil || back != tile {
+			t.Fatalf("CurveTileFromID(%d, %d) = %+v, %v; want %+v", id, tc.shift, back, err, tile)
+		}
+	}
+}
+
+func TestCurveTileIDUniqueRoundTrip(t *testing.T) {
+	for _, c := range []Curve{HilbertCurve, MortonCurve} {
+		for shift := 0; shift <= 2; shift++ {
+			seen := map[uint64]bool{}
+			for z := 0; z <= 3; z++ {
+				n := 1 << (z + shift)
+				for col := 0; col < n; col++ {
+					for row := 0; row < n; row++ {
+						tile := Tile{Zoom: z, TileIndex: TileIndex{Col: col, Row: row}}
+						id, err := CurveTileID(c, tile, shift)
+						if err != nil {
+							t.Fatalf("%v shift %d: %v", c, shift, err)
+						}
+						if seen[id] {
+							t.Fatalf("%v shift %d: duplicate id %d for %+v", c, shift, id, tile)
+						}
+						seen[id] = true
+						back, err := CurveTileFromID(c, id, shift)
+						if err != nil || back != tile {
+							t.Fatalf("%v shift %d: round trip %+v -> %d -> %+v, %v", c, shift, tile, id, back, err)
+						}
+					}
+				}
+			}
+			// The ids are dense: every id below the count decodes.
+			if want := curveZoomStart(4, shift); uint64(len(seen)) != want {
+				t.Fatalf("%v shift %d: %d ids, want %d", c, shift, len(seen), want)
+			}
+		}
+	}
+}
+
+func TestCurveTileIDRange(t *testing.T) {
+	bad := []struct {
+		tile  Tile
+		shift int
+	}{
+		{Tile{Zoom: 1, TileIndex: TileIndex{Col: 2, Row: 0}}, 0},
+		{Tile{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: -1}}, 0},
+		{Tile{Zoom: 31, TileIndex: TileIndex{}}, 1},
+		{Tile{Zoom: 0, TileIndex: TileIndex{}}, -1},
+	}
+	for _, tc := range bad {
+		if _, err := CurveTileID(HilbertCurve, tc.tile, tc.shift); err == nil {
+			t.Fatalf("expected error for %+v shift %d", tc.tile, tc.shift)
+		}
+	}
+	if _, err := CurveTileID(Curve(7), Tile{}, 0); err == nil {
+		t.Fatal("expected error for unknown curve")
+	}
+	// The last tile at the maximum order still fits.
+	last := Tile{Zoom: MaxCurveOrder, TileIndex: TileIndex{Col: 1<<MaxCurveOrder - 1}}
+	id, err := CurveTileID(MortonCurve, last, 0)
+	if err != nil {
+		t.Fatalf("max order: %v", err)
+	}
+	if back, err := CurveTileFromID(MortonCurve, id, 0); err != nil || back != last {
+		t.Fatalf("max order round trip: %+v, %v", back, err)
+	}
+}
+
+func TestTilesListSortByCurve(t *testing.T) {
+	l := TilesList{
+		{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 0}},
+		{Zoom: 5, TileIndex: TileIndex{Col: 99, Row: 0}}, // outside the curve
+		{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 1}},
+		{Zoom: 0, TileIndex: TileIndex{}},
+		{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 1}},
+	}
+	l.SortByCurve(HilbertCurve, 0)
+	want := TilesList{
+		{Zoom: 0, TileIndex: TileIndex{}},
+		{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 1}},
+		{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 1}},
+		{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 0}},
+		{Zoom: 5, TileIndex: TileIndex{Col: 99, Row: 0}},
+	}
+	for i := range want {
+		if l[i] != want[i] {
+			t.Fatalf("sorted = %v, want %v", l, want)
+		}
+	}
+}
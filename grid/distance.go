@@ -0,0 +1,131 @@
+package grid
+
+import (
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// BoundDistance returns the planar distance between the geometry and the
+// bound, 0 where they intersect. A bound inside a polygon, away from its
+// rings, intersects it.
+func BoundDistance(g orb.Geometry, b orb.Bound) float64 {
+	switch geom := g.(type) {
+	case nil:
+		return math.Inf(1)
+	case orb.Point:
+		return pointBoundDistance(geom, b)
+	case orb.MultiPoint:
+		d := math.Inf(1)
+		for _, pt := range geom {
+			d = math.Min(d, pointBoundDistance(pt, b))
+		}
+		return d
+	case orb.LineString:
+		return pathBoundDistance(geom, b)
+	case orb.MultiLineString:
+		d := math.Inf(1)
+		for _, ls := range geom {
+			d = math.Min(d, pathBoundDistance(ls, b))
+		}
+		return d
+	case orb.Ring:
+		return BoundDistance(orb.Polygon{geom}, b)
+	case orb.Bound:
+		return BoundDistance(geom.ToPolygon(), b)
+	case orb.Polygon:
+		if len(geom) == 0 {
+			return math.Inf(1)
+		}
+		if planar.PolygonContains(geom, b.Center()) {
+			return 0
+		}
+		d := math.Inf(1)
+		for _, r := range geom {
+			d = math.Min(d, pathBoundDistance(r, b))
+		}
+		return d
+	case orb.MultiPolygon:
+		d := math.Inf(1)
+		for _, poly := range geom {
+			d = math.Min(d, BoundDistance(poly, b))
+		}
+		return d
+	case orb.Collection:
+		d := math.Inf(1)
+		for _, sub := range geom {
+			d = math.Min(d, BoundDistance(sub, b))
+		}
+		return d
+	}
+	return math.Inf(1)
+}
+
+// pointBoundDistance returns the distance from the point to the bound.
+func pointBoundDistance(p orb.Point, b orb.Bound) float64 {
+	dx := math.Max(0, math.Max(b.Min[0]-p[0], p[0]-b.Max[0]))
+	dy := math.Max(0, math.Max(b.Min[1]-p[1], p[1]-b.Max[1]))
+	return math.Hypot(dx, dy)
+}
+
+// pathBoundDistance returns the distance from the segments of the path to
+// the bound.
+func pathBoundDistance(pts []orb.Point, b orb.Bound) float64 {
+	if len(pts) == 1 {
+		return pointBoundDistance(pts[0], b)
+	}
+	d := math.Inf(1)
+	for i := 1; i < len(pts); i++ {
+		d = math.Min(d, segmentBoundDistance(pts[i-1], pts[i], b))
+		if d == 0 {
+			break
+		}
+	}
+	return d
+}
+
+// segmentBoundDistance returns the distance from segment a-b to the bound.
+// A segment that misses the bound is closest at one of its end points or at
+// a corner of the bound.
+func segmentBoundDistance(a, c orb.Point, b orb.Bound) float64 {
+	if segmentIntersectsBound(a, c, b) {
+		return 0
+	}
+	d := math.Min(pointBoundDistance(a, b), pointBoundDistance(c, b))
+	for _, corner := range []orb.Point{b.Min, b.Max, b.LeftTop(), b.RightBottom()} {
+		d = math.Min(d, planar.DistanceFromSegment(a, c, corner))
+	}
+	return d
+}
+
+// segmentIntersectsBound clips segment a-b to the bound (Liang-Barsky) and
+// reports whether anything is left.
+func segmentIntersectsBound(a, b orb.Point, bound orb.Bound) bool {
+	t0, t1 := 0.0, 1.0
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	for _, e := range [4][2]float64{
+		{-dx, a[0] - bound.Min[0]},
+		{dx, bound.Max[0] - a[0]},
+		{-dy, a[1] - bound.Min[1]},
+		{dy, bound.Max[1] - a[1]},
+	} {
+		p, q := e[0], e[1]
+		if p == 0 {
+			if q < 0 {
+				return false
+			}
+			continue
+		}
+		r := q / p
+		if p < 0 {
+			t0 = math.Max(t0, r)
+		} else {
+			t1 = math.Min(t1, r)
+		}
+		if t0 > t1 {
+			return false
+		}
+	}
+	return true
+}
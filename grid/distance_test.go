@@ -0,0 +1,37 @@
+package grid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func TestBoundDistance(t *testing.T) {
+	b := orb.Bound{Min: orb.Point{0, 0}, Max: orb.Point{2, 1}}
+	cases := []struct {
+		name string
+		g    orb.Geometry
+		want float64
+	}{
+		{"point inside", orb.Point{1, 0.5}, 0},
+		{"point on edge", orb.Point{2, 0.5}, 0},
+		{"point beside", orb.Point{5, 0.5}, 3},
+		{"point off corner", orb.Point{5, 5}, 5},
+		{"line crossing", orb.LineString{{-1, -1}, {3, 2}}, 0},
+		{"line above", orb.LineString{{-5, 3}, {5, 3}}, 2},
+		{"line off corner", orb.LineString{{4, 0}, {0, 4}}, math.Sqrt2 / 2},
+		{"polygon around", orb.Polygon{{{-5, -5}, {5, -5}, {5, 5}, {-5, 5}, {-5, -5}}}, 0},
+		{"hole around", orb.Polygon{
+			{{-5, -5}, {5, -5}, {5, 5}, {-5, 5}, {-5, -5}},
+			{{-1, -1}, {3, -1}, {3, 2}, {-1, 2}, {-1, -1}},
+		}, 1},
+		{"multipoint", orb.MultiPoint{{9, 9}, {2, 3}}, 2},
+		{"collection", orb.Collection{orb.Point{9, 9}, orb.LineString{{-1, 0}, {-1, 1}}}, 1},
+	}
+	for _, tc := range cases {
+		if got := BoundDistance(tc.g, b); math.Abs(got-tc.want) > 1e-12 {
+			t.Fatalf("%s: BoundDistance = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/paulmach/orb"
+)
+
+// Geodesic measures on the WGS84 ellipsoid. Areas are computed on the authalic
+// sphere, which has the same area as the ellipsoid and maps latitudes so that
+// areas are preserved; distances use Vincenty's inverse formula.
+
+// wgs84Flattening is the flattening of the WGS84 ellipsoid.
+const wgs84Flattening = 1 / 298.257223563
+
+var (
+	wgs84E2        = wgs84Flattening * (2 - wgs84Flattening)
+	wgs84E         = math.Sqrt(wgs84E2)
+	wgs84SemiMinor = earthRadius * (1 - wgs84Flattening)
+	authalicQPole  = authalicQ(1)
+	// authalicRadius2 is the squared radius of the authalic sphere.
+	authalicRadius2 = earthRadius * earthRadius * authalicQPole / 2
+)
+
+// tileSubdivisions is the number of cells per side a tile is split into to
+// weight its centroid.
+const tileSubdivisions = 8
+
+// tileEdgeDensify is the number of points inserted per tile edge for areas and
+// perimeters. Areas double it up to maxTileEdgeDensify until they change by
+// less than areaTolerance, which holds at once for edges along meridians and
+// parallels.
+const (
+	tileEdgeDensify    = 15
+	maxTileEdgeDensify = 1023
+	areaTolerance      = 1e-7
+)
+
+// poleTolerance is the distance in degrees from a pole under which a vertex is
+// treated as the pole itself.
+const poleTolerance = 1e-9
+
+func authalicQ(sinPhi float64) float64 {
+	es := wgs84E * sinPhi
+	return (1 - wgs84E2) * (sinPhi/(1-es*es) - math.Log((1-es)/(1+es))/(2*wgs84E))
+}
+
+// sinAuthalic returns the sine of the authalic latitude of a geodetic
+// latitude in degrees.
+func sinAuthalic(lat float64) float64 {
+	return authalicQ(math.Sin(lat*math.Pi/180)) / authalicQPole
+}
+
+// geodeticLatitude returns the geodetic latitude in degrees of an authalic
+// latitude in radians.
+func geodeticLatitude(beta float64) float64 {
+	e2 := wgs84E2
+	e4 := e2 * e2
+	e6 := e4 * e2
+	phi := beta +
+		(e2/3+31*e4/180+517*e6/5040)*math.Sin(2*beta) +
+		(23*e4/360+251*e6/3780)*math.Sin(4*beta) +
+		(761*e6/45360)*math.Sin(6*beta)
+	return phi * 180 / math.Pi
+}
+
+// EllipsoidalRingArea returns the area in square meters enclosed by a lon/lat
+// ring (degrees) on the WGS84 ellipsoid. Edges are taken as straight lines in
+// longitude and sine of authalic latitude, which is exact along meridians and
+// parallels; densify other edges. A vertex on a pole stands for the stretch of
+// the pole between the longitudes of its neighbours. A ring that winds around
+// a pole encloses the north pole if pole > 0, the south pole if pole < 0, and
+// otherwise the smaller of the two caps.
+func EllipsoidalRingArea(ring orb.Ring, pole int) float64 {
+	pts := expandPoleVertices(ring)
+	n := len(pts)
+	if n < 3 {
+		return 0
+	}
+	var sum, winding float64
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%n]
+		dLon := math.Remainder((b[0]-a[0])*math.Pi/180, 2*math.Pi)
+		sum += dLon * (sinAuthalic(a[1]) + sinAuthalic(b[1])) / 2
+		winding += dLon
+	}
+	area := math.Abs(sum)
+	if math.Abs(winding) > math.Pi {
+		// Signed by the direction around the pole, sum is the area between
+		// the ring and the equator.
+		s := math.Copysign(sum, winding)
+		north, south := 2*math.Pi-s, 2*math.Pi+s
+		switch {
+		case pole > 0:
+			area = north
+		case pole < 0:
+			area = south
+		default:
+			area = math.Min(north, south)
+		}
+	}
+	return area * authalicRadius2
+}
+
+// expandPoleVertices drops the closing point of the ring and replaces vertices
+// on a pole by two pole vertices at the longitudes of their neighbours.
+func expandPoleVertices(ring orb.Ring) []orb.Point {
+	pts := []orb.Point(ring)
+	if len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	n := len(pts)
+	onPole := func(p orb.Point) bool { return math.Abs(p[1]) >= 90-poleTolerance }
+	out := make([]orb.Point, 0, n+2)
+	for i, p := range pts {
+		if !onPole(p) {
+			out = append(out, p)
+			continue
+		}
+		lat := math.Copysign(90, p[1])
+		prev, next := pts[(i+n-1)%n], pts[(i+1)%n]
+		if onPole(prev) || onPole(next) {
+			out = append(out, orb.Point{p[0], lat})
+			continue
+		}
+		out = append(out, orb.Point{prev[0], lat}, orb.Point{next[0], lat})
+	}
+	return out
+}
+
+// GeodesicDistance returns the distance in meters along the geodesic between
+// two lon/lat positions (degrees) on the WGS84 ellipsoid. Nearly antipodal
+// points, for which Vincenty's formula does not converge, fall back to the
+// great-circle distance.
+func GeodesicDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	const rad = math.Pi / 180
+	a, b, f := earthRadius, wgs84SemiMinor, wgs84Flattening
+	l := (lon2 - lon1) * rad
+	u1 := math.Atan((1 - f) * math.Tan(lat1*rad))
+	u2 := math.Atan((1 - f) * math.Tan(lat2*rad))
+	sinU1, cosU1 := math.Sincos(u1)
+	sinU2, cosU2 := math.Sincos(u2)
+
+	lambda := l
+	for iter := 0; iter < 200; iter++ {
+		sinLambda, cosLambda := math.Sincos(lambda)
+		sinSigma := math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0
+		}
+		cosSigma := sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma := math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cos2Alpha := 1 - sinAlpha*sinAlpha
+		cos2SigmaM := 0.0
+		if cos2Alpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cos2Alpha
+		}
+		c := f / 16 * cos2Alpha * (4 + f*(4-3*cos2Alpha))
+		prev := lambda
+		lambda = l + (1-c)*f*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-prev) > 1e-12 {
+			continue
+		}
+		u := cos2Alpha * (a*a - b*b) / (b * b)
+		bigA := 1 + u/16384*(4096+u*(-768+u*(320-175*u)))
+		bigB := u / 1024 * (256 + u*(-128+u*(74-47*u)))
+		dSigma := bigB * sinSigma * (cos2SigmaM + bigB/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			bigB/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+		return b * bigA * (sigma - dSigma)
+	}
+	return greatCircleDistance(lon1, lat1, lon2, lat2)
+}
+
+// LineLength returns the geodesic length in meters of a lon/lat line
+// (degrees) on the WGS84 ellipsoid.
+func LineLength(ls orb.LineString) float64 {
+	var d float64
+	for i := 1; i < len(ls); i++ {
+		d += GeodesicDistance(ls[i-1][0], ls[i-1][1], ls[i][0], ls[i][1])
+	}
+	return d
+}
+
+// lonLatRing returns the CRS bounds as a densified lon/lat ring.
+func lonLatRing(b Bounds, densify int, p Projector) (orb.Ring, error) {
+	poly, err := InversePolygon(PolygonForBounds(b, densify), p)
+	if err != nil {
+		return nil, err
+	}
+	return poly[0], nil
+}
+
+// GeodesicArea returns the area of the tile in square meters on the WGS84
+// ellipsoid. Tiles containing a pole, as in polar stereographic sets, are
+// supported. Edges are densified until the area converges.
+func (a TileMatrix) GeodesicArea(t TileIndex, p Projector) (float64, error) {
+	b, err := a.BoundsForTile(t)
+	if err != nil {
+		return 0, err
+	}
+	pole := PoleWithin(b, p)
+	area := math.NaN()
+	for densify := tileEdgeDensify; ; densify = 2*densify + 1 {
+		ring, err := lonLatRing(b, densify, p)
+		if err != nil {
+			return 0, err
+		}
+		prev := area
+		area = EllipsoidalRingArea(ring, pole)
+		if math.Abs(area-prev) <= areaTolerance*area || densify >= maxTileEdgeDensify {
+			return area, nil
+		}
+	}
+}
+
+// GeodesicPerimeter returns the length of the tile outline in meters on the
+// WGS84 ellipsoid, following the tile edges as they appear in the CRS.
+func (a TileMatrix) GeodesicPerimeter(t TileIndex, p Projector) (float64, error) {
+	b, err := a.BoundsForTile(t)
+	if err != nil {
+		return 0, err
+	}
+	ring, err := lonLatRing(b, tileEdgeDensify, p)
+	if err != nil {
+		return 0, err
+	}
+	return LineLength(orb.LineString(ring)), nil
+}
+
+// GeodesicCentroid returns the lon/lat (degrees) centroid of the tile on the
+// WGS84 ellipsoid: the area-weighted mean direction of the centers of
+// tileSubdivisions x tileSubdivisions cells of the tile on the authalic
+// sphere. It fails for tiles that wrap the globe, such as the zoom 0 tile of
+// WebMercatorQuad, whose centroid is not defined.
+func (a TileMatrix) GeodesicCentroid(t TileIndex, p Projector) (orb.Point, error) {
+	b, err := a.BoundsForTile(t)
+	if err != nil {
+		return orb.Point{}, err
+	}
+	const n = tileSubdivisions
+	dx, dy := (b.MaxX-b.MinX)/n, (b.MaxY-b.MinY)/n
+	var nodes [n + 1][n + 1]orb.Point
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= n; j++ {
+			lon, lat, err := p.Inverse(b.MinX+float64(i)*dx, b.MinY+float64(j)*dy)
+			if err != nil {
+				return orb.Point{}, err
+			}
+			nodes[i][j] = orb.Point{lon, lat}
+		}
+	}
+	var sx, sy, sz, total float64
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			cell := Bounds{
+				MinX: b.MinX + float64(i)*dx, MinY: b.MinY + float64(j)*dy,
+				MaxX: b.MinX + float64(i+1)*dx, MaxY: b.MinY + float64(j+1)*dy,
+			}
+			ring := orb.Ring{nodes[i][j], nodes[i+1][j], nodes[i+1][j+1], nodes[i][j+1], nodes[i][j]}
+			area := EllipsoidalRingArea(ring, PoleWithin(cell, p))
+			lon, lat, err := p.Inverse((cell.MinX+cell.MaxX)/2, (cell.MinY+cell.MaxY)/2)
+			if err != nil {
+				return orb.Point{}, err
+			}
+			sinLon, cosLon := math.Sincos(lon * math.Pi / 180)
+			sinBeta := sinAuthalic(lat)
+			cosBeta := math.Sqrt(1 - sinBeta*sinBeta)
+			sx += area * cosBeta * cosLon
+			sy += area * cosBeta * sinLon
+			sz += area * sinBeta
+			total += area
+		}
+	}
+	norm := math.Sqrt(sx*sx + sy*sy + sz*sz)
+	if total == 0 || norm < 1e-9*total {
+		return orb.Point{}, fmt.Errorf("centroid of tile col=%d row=%d not defined", t.Col, t.Row)
+	}
+	lon := math.Atan2(sy, sx) * 180 / math.Pi
+	beta := math.Asin(math.Max(-1, math.Min(1, sz/norm)))
+	return orb.Point{lon, geodeticLatitude(beta)}, nil
+}
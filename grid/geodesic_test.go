@@ -0,0 +1,72 @@
+package grid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+// wgs84Area is the surface area of the WGS84 ellipsoid in square meters.
+const wgs84Area = 5.100656217240886e14
+
+func TestGeodesicDistance(t *testing.T) {
+	cases := []struct {
+		lon1, lat1, lon2, lat2 float64
+		want                   float64
+	}{
+		// Vincenty's Flinders Peak to Buninyong example.
+		{144.42486788888889, -37.95103341666667, 143.92649552777778, -37.65282113888889, 54972.271},
+		{0, 0, 1, 0, 111319.491},
+		{0, 0, 0, 1, 110574.389},
+		{10, 20, 10, 20, 0},
+	}
+	for _, c := range cases {
+		got := GeodesicDistance(c.lon1, c.lat1, c.lon2, c.lat2)
+		if math.Abs(got-c.want) > 1e-3 {
+			t.Fatalf("%v: got %v, want %v", c, got, c.want)
+		}
+	}
+	// Antipodal points fall back to the great circle.
+	if got := GeodesicDistance(0, 0, 180, 0); got < 2e7 || got > 2.01e7 {
+		t.Fatalf("antipodal distance %v", got)
+	}
+}
+
+func TestEllipsoidalRingArea(t *testing.T) {
+	hemisphere := orb.Ring{{-180, 0}, {0, 0}, {180, 0}, {180, 90}, {0, 90}, {-180, 90}, {-180, 0}}
+	if got := EllipsoidalRingArea(hemisphere, 0); math.Abs(got-wgs84Area/2)/wgs84Area > 1e-12 {
+		t.Fatalf("hemisphere: got %v, want %v", got, wgs84Area/2)
+	}
+
+	var cap orb.Ring
+	for lon := -180.0; lon < 180; lon += 10 {
+		cap = append(cap, orb.Point{lon, 60})
+	}
+	cap = append(cap, cap[0])
+	want := 2 * math.Pi * authalicRadius2 * (1 - sinAuthalic(60))
+	if got := EllipsoidalRingArea(cap, 1); math.Abs(got-want)/want > 1e-12 {
+		t.Fatalf("north cap: got %v, want %v", got, want)
+	}
+	if got := EllipsoidalRingArea(cap, 0); math.Abs(got-want)/want > 1e-12 {
+		t.Fatalf("smaller cap: got %v, want %v", got, want)
+	}
+	if got := EllipsoidalRingArea(cap, -1); math.Abs(got-(wgs84Area-want))/wgs84Area > 1e-12 {
+		t.Fatalf("south cap: got %v, want %v", got, wgs84Area-want)
+	}
+
+	// A quarter of the cap with a vertex on the pole.
+	quarter := orb.Ring{{0, 60}, {45, 60}, {90, 60}, {0, 90}, {0, 60}}
+	if got := EllipsoidalRingArea(quarter, 0); math.Abs(got-want/4)/want > 1e-12 {
+		t.Fatalf("quarter cap: got %v, want %v", got, want/4)
+	}
+}
+
+func TestGeodeticLatitude(t *testing.T) {
+	for _, lat := range []float64{-89, -45, 0, 30, 60, 89.9} {
+		beta := math.Asin(sinAuthalic(lat))
+		if got := geodeticLatitude(beta); math.Abs(got-lat) > 1e-7 {
+			t.Fatalf("lat %v: round trip %v", lat, got)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package grid
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/clip"
+	"github.com/paulmach/orb/planar"
+)
+
+// overlapTolerance is the fraction of a tile's area below which an overlap is
+// taken as rounding noise of a reprojected edge.
+const overlapTolerance = 1e-9
+
+// TilesIntersectingPolygon returns the tiles whose area overlaps the polygon
+// in the matrix CRS, row by row. Tiles that only touch the polygon along an
+// edge or at a corner are left out. Coalesced rows are handled.
+func (a TileMatrix) TilesIntersectingPolygon(poly orb.Polygon) []TileIndex {
+	if len(poly) == 0 {
+		return nil
+	}
+	bound := poly.Bound()
+	tr, ok := a.TileRangeForBounds(Bounds{MinX: bound.Min[0], MinY: bound.Min[1], MaxX: bound.Max[0], MaxY: bound.Max[1]})
+	if !ok {
+		return nil
+	}
+	var tiles []TileIndex
+	for r := tr.MinRow; r <= tr.MaxRow; r++ {
+		first, last := a.RowTileRange(tr, r)
+		for c := first; c <= last; c++ {
+			idx := TileIndex{Col: c, Row: r}
+			tb, err := a.BoundsForTile(idx)
+			if err != nil {
+				continue
+			}
+			tileBound := orb.Bound{Min: orb.Point{tb.MinX, tb.MinY}, Max: orb.Point{tb.MaxX, tb.MaxY}}
+			clipped := clip.Polygon(tileBound, poly.Clone())
+			if len(clipped) > 0 && planar.Area(clipped) > overlapTolerance*(tb.MaxX-tb.MinX)*(tb.MaxY-tb.MinY) {
+				tiles = append(tiles, idx)
+			}
+		}
+	}
+	return tiles
+}
@@ -0,0 +1,44 @@
+package grid
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hafenkran/gocantile/tms"
+	"github.com/paulmach/orb"
+)
+
+func TestTilesIntersectingPolygon(t *testing.T) {
+	// 4x4 tiles of 10x10 units from (0,40) down to (40,0); row 0 is
+	// coalesced by 2.
+	adapter := TileMatrix{TM: tms.TileMatrix{
+		CellSize:       1,
+		TileWidth:      10,
+		TileHeight:     10,
+		MatrixWidth:    4,
+		MatrixHeight:   4,
+		PointOfOrigin:  []float64{0, 40},
+		CornerOfOrigin: tms.TileMatrixJsonCornerOfOriginTopLeft,
+		VariableMatrixWidths: []tms.VariableMatrixWidthJson{
+			{Coalesce: 2, MinTileRow: 0, MaxTileRow: 0},
+		},
+	}}
+
+	// The triangle lies above the line y = x + 15; in the coalesced row 0
+	// it overlaps both tiles.
+	triangle := orb.Polygon{{{0, 40}, {25, 40}, {0, 15}, {0, 40}}}
+	got := adapter.TilesIntersectingPolygon(triangle)
+	want := []TileIndex{{Col: 0, Row: 0}, {Col: 1, Row: 0}, {Col: 0, Row: 1}, {Col: 1, Row: 1}, {Col: 0, Row: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Neighbouring tiles only share edges with a polygon equal to a tile.
+	square := orb.Polygon{{{10, 10}, {20, 10}, {20, 20}, {10, 20}, {10, 10}}}
+	if got := adapter.TilesIntersectingPolygon(square); !reflect.DeepEqual(got, []TileIndex{{Col: 1, Row: 2}}) {
+		t.Fatalf("got %v for a polygon equal to a tile", got)
+	}
+	if got := adapter.TilesIntersectingPolygon(orb.Polygon{{{50, 50}, {60, 50}, {60, 60}, {50, 50}}}); got != nil {
+		t.Fatalf("got %v outside the matrix", got)
+	}
+}
@@ -0,0 +1,109 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hafenkran/gocantile/tms"
+)
+
+// Global pixel coordinates treat a tile matrix as one large image: pixels of
+// cellSize ground units counted from the top-left corner of the matrix, x to
+// the right and y down, independent of the cornerOfOrigin. Pixel positions
+// within a tile (i, j) follow the same image convention relative to the
+// tile's top-left corner, as the i/j of OGC GetFeatureInfo requests.
+// Coordinates are fractional; the integer parts address a pixel and the
+// fractions are sub-pixel offsets.
+//
+// In coalesced rows a tile still has tileWidth pixels, but each spans
+// coalesce cells horizontally, so i advances by 1/coalesce per global pixel.
+
+// topLeft returns the CRS position of the top-left corner of the matrix.
+func (a TileMatrix) topLeft() (float64, float64, bool) {
+	originX, originY, ok := a.origin()
+	if !ok {
+		return 0, 0, false
+	}
+	if a.TM.CornerOfOrigin == tms.TileMatrixJsonCornerOfOriginBottomLeft {
+		return originX, originY + float64(a.matrixHeight())*a.tileSizeY(), true
+	}
+	return originX, originY, true
+}
+
+// PixelForXY returns the global pixel coordinates of a CRS position. The
+// position may lie outside the matrix.
+func (a TileMatrix) PixelForXY(x, y float64) (float64, float64, error) {
+	left, top, ok := a.topLeft()
+	if !ok {
+		return 0, 0, fmt.Errorf("tile matrix origin not defined")
+	}
+	return (x - left) / a.cellSize(), (top - y) / a.cellSize(), nil
+}
+
+// XYForPixel returns the CRS position of global pixel coordinates.
+func (a TileMatrix) XYForPixel(px, py float64) (float64, float64, error) {
+	left, top, ok := a.topLeft()
+	if !ok {
+		return 0, 0, fmt.Errorf("tile matrix origin not defined")
+	}
+	return left + px*a.cellSize(), top - py*a.cellSize(), nil
+}
+
+// TilePixelForPixel returns the tile containing the global pixel position and
+// the position (i, j) within the tile image. It returns false outside the
+// matrix.
+func (a TileMatrix) TilePixelForPixel(px, py float64) (TileIndex, float64, float64, bool) {
+	tileW, tileH := a.TM.TileWidth, a.TM.TileHeight
+	width, height := a.MatrixSize()
+	if px < 0 || py < 0 || px >= float64(width)*tileW || py >= float64(height)*tileH {
+		return TileIndex{}, 0, 0, false
+	}
+	imageRow := int(math.Floor(py / tileH))
+	j := py - float64(imageRow)*tileH
+	row := imageRow
+	if a.TM.CornerOfOrigin == tms.TileMatrixJsonCornerOfOriginBottomLeft {
+		row = height - 1 - imageRow
+	}
+	k := float64(a.Coalesce(row))
+	col := int(math.Floor(px / (tileW * k)))
+	i := (px - float64(col)*tileW*k) / k
+	idx := TileIndex{Col: col, Row: row}
+	if !a.ContainsTile(idx) {
+		return TileIndex{}, 0, 0, false
+	}
+	return idx, i, j, true
+}
+
+// PixelForTilePixel returns the global pixel coordinates of position (i, j)
+// within the tile image.
+func (a TileMatrix) PixelForTilePixel(t TileIndex, i, j float64) (float64, float64, error) {
+	if !a.ContainsTile(t) {
+		return 0, 0, fmt.Errorf("tile out of range col=%d row=%d", t.Col, t.Row)
+	}
+	imageRow := t.Row
+	if a.TM.CornerOfOrigin == tms.TileMatrixJsonCornerOfOriginBottomLeft {
+		imageRow = a.matrixHeight() - 1 - t.Row
+	}
+	k := float64(a.Coalesce(t.Row))
+	return (float64(t.Col)*a.TM.TileWidth + i) * k, float64(imageRow)*a.TM.TileHeight + j, nil
+}
+
+// TilePixelForXY returns the tile containing the CRS position and the
+// position (i, j) within the tile image. It returns false outside the matrix.
+func (a TileMatrix) TilePixelForXY(x, y float64) (TileIndex, float64, float64, bool) {
+	px, py, err := a.PixelForXY(x, y)
+	if err != nil {
+		return TileIndex{}, 0, 0, false
+	}
+	return a.TilePixelForPixel(px, py)
+}
+
+// XYForTilePixel returns the CRS position of (i, j) within the tile image.
+// Use i+0.5, j+0.5 for the center of a pixel.
+func (a TileMatrix) XYForTilePixel(t TileIndex, i, j float64) (float64, float64, error) {
+	px, py, err := a.PixelForTilePixel(t, i, j)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a.XYForPixel(px, py)
+}
@@ -0,0 +1,101 @@
+package grid
+
+import (
+	"testing"
+
+	"github.com/hafenkran/gocantile/tms"
+)
+
+func TestPixelForXYWebMercator(t *testing.T) {
+	adapter := newMercatorAdapter()
+
+	px, py, err := adapter.PixelForXY(0, 0)
+	if err != nil || !almostEqual(px, 128, 1e-9) || !almostEqual(py, 128, 1e-9) {
+		t.Fatalf("expected map center at pixel 128,128, got %v,%v err=%v", px, py, err)
+	}
+	x, y, err := adapter.XYForPixel(0, 256)
+	if err != nil || !almostEqual(x, -20037508.3427892, 1e-6) || !almostEqual(y, -20037508.3427892, 1e-6) {
+		t.Fatalf("unexpected bottom-left corner %v,%v err=%v", x, y, err)
+	}
+
+	idx, i, j, ok := adapter.TilePixelForXY(1000, -1000)
+	if !ok || idx != (TileIndex{}) {
+		t.Fatalf("unexpected tile %+v ok=%v", idx, ok)
+	}
+	res := adapter.Resolution()
+	if !almostEqual(i, 128+1000/res, 1e-9) || !almostEqual(j, 128+1000/res, 1e-9) {
+		t.Fatalf("unexpected sub-pixel position %v,%v", i, j)
+	}
+	if _, _, _, ok := adapter.TilePixelForXY(0, 3e7); ok {
+		t.Fatalf("expected position above the matrix to be outside")
+	}
+}
+
+func TestTilePixelBottomLeftCoalesced(t *testing.T) {
+	// 4x4 tiles of 2x2 pixels, 10 units per cell, origin at (0,0) in the
+	// bottom-left corner; rows 0-1 (the bottom) are coalesced by 2.
+	adapter := TileMatrix{TM: tms.TileMatrix{
+		CellSize:       10,
+		TileWidth:      2,
+		TileHeight:     2,
+		MatrixWidth:    4,
+		MatrixHeight:   4,
+		PointOfOrigin:  []float64{0, 0},
+		CornerOfOrigin: tms.TileMatrixJsonCornerOfOriginBottomLeft,
+		VariableMatrixWidths: []tms.VariableMatrixWidthJson{
+			{Coalesce: 2, MinTileRow: 0, MaxTileRow: 1},
+		},
+	}}
+
+	// The top-left corner of the matrix is (0, 80).
+	px, py, err := adapter.PixelForXY(5, 75)
+	if err != nil || px != 0.5 || py != 0.5 {
+		t.Fatalf("unexpected pixel %v,%v err=%v", px, py, err)
+	}
+
+	// Top row 3 is regular: global pixel (3.5, 1.25) is tile (1,3), i=1.5.
+	idx, i, j, ok := adapter.TilePixelForPixel(3.5, 1.25)
+	if !ok || idx != (TileIndex{Col: 1, Row: 3}) || i != 1.5 || j != 1.25 {
+		t.Fatalf("unexpected tile pixel %+v %v,%v ok=%v", idx, i, j, ok)
+	}
+
+	// Bottom row 0 is coalesced: tiles are 4 global pixels wide, so global
+	// x 5 is in tile 1 at i 0.5.
+	idx, i, j, ok = adapter.TilePixelForPixel(5, 7)
+	if !ok || idx != (TileIndex{Col: 1, Row: 0}) || i != 0.5 || j != 1 {
+		t.Fatalf("unexpected coalesced tile pixel %+v %v,%v ok=%v", idx, i, j, ok)
+	}
+	x, y, err := adapter.XYForTilePixel(idx, i, j)
+	if err != nil || x != 50 || y != 10 {
+		t.Fatalf("unexpected position %v,%v err=%v", x, y, err)
+	}
+	// The center of the last pixel of the coalesced tile.
+	x, y, err = adapter.XYForTilePixel(TileIndex{Col: 1, Row: 0}, 1.5, 1.5)
+	if err != nil || x != 70 || y != 5 {
+		t.Fatalf("unexpected pixel center %v,%v err=%v", x, y, err)
+	}
+
+	if _, _, err := adapter.PixelForTilePixel(TileIndex{Col: 2, Row: 0}, 0, 0); err == nil {
+		t.Fatalf("expected error for column beyond coalesced row width")
+	}
+	if _, _, _, ok := adapter.TilePixelForPixel(-0.1, 0); ok {
+		t.Fatalf("expected negative pixel to be outside")
+	}
+
+	// Round trip through every tile and a few sub-pixel positions.
+	for row := 0; row < 4; row++ {
+		for col := 0; col < adapter.RowWidth(row); col++ {
+			for _, ij := range [][2]float64{{0, 0}, {0.25, 1.75}, {1.99, 1}} {
+				tile := TileIndex{Col: col, Row: row}
+				x, y, err := adapter.XYForTilePixel(tile, ij[0], ij[1])
+				if err != nil {
+					t.Fatalf("xy for %+v: %v", tile, err)
+				}
+				got, i, j, ok := adapter.TilePixelForXY(x, y)
+				if !ok || got != tile || !almostEqual(i, ij[0], 1e-9) || !almostEqual(j, ij[1], 1e-9) {
+					t.Fatalf("round trip %+v %v -> %+v %v,%v ok=%v", tile, ij, got, i, j, ok)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package grid
+
+import (
+	"math"
+
+	"github.com/paulmach/orb"
+)
+
+// PoleWithin reports which pole lies strictly inside the CRS bounds: 1 for
+// the north pole, -1 for the south pole and 0 for none. Projections that
+// cannot represent a pole report none.
+func PoleWithin(b Bounds, p Projector) int {
+	for _, side := range []int{1, -1} {
+		x, y, err := p.Forward(0, float64(side)*90)
+		if err != nil || math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			continue
+		}
+		if x > b.MinX && x < b.MaxX && y > b.MinY && y < b.MaxY {
+			return side
+		}
+	}
+	return 0
+}
+
+// RingPole reports which pole a lon/lat ring in degrees winds around: 1 for
+// the north pole, -1 for the south pole and 0 for none. A ring winds around a
+// pole when its longitudes, unwrapped as in SplitAntimeridian, end 360° away
+// from where they start; the pole is taken on the side of the hemisphere
+// holding most of the ring.
+func RingPole(r orb.Ring) int {
+	if len(r) < 2 {
+		return 0
+	}
+	pts := unwrapLongitudes(r)
+	if math.Abs(pts[len(pts)-1][0]-pts[0][0]) < 180 {
+		return 0
+	}
+	var lat float64
+	for _, pt := range r {
+		lat += pt[1]
+	}
+	if lat < 0 {
+		return -1
+	}
+	return 1
+}
+
+// closePolarRing closes an unwrapped ring that winds around a pole along the
+// pole, so that in lon/lat it covers the polar cap instead of a thin band.
+func closePolarRing(ring orb.Ring, pole int) orb.Ring {
+	first, last := ring[0], ring[len(ring)-1]
+	lat := 90 * float64(pole)
+	out := make(orb.Ring, 0, len(ring)+3)
+	out = append(out, ring...)
+	return append(out, orb.Point{last[0], lat}, orb.Point{first[0], lat}, first)
+}
+
+// DensifyLonLat returns the lon/lat geometry with points inserted so that no
+// segment spans more than step degrees of longitude or latitude. Straight
+// lines in lon/lat bend once projected, e.g. around a pole, so long segments
+// are densified before projecting.
+func DensifyLonLat(g orb.Geometry, step float64) orb.Geometry {
+	if !(step > 0) {
+		return g
+	}
+	switch geom := g.(type) {
+	case orb.LineString:
+		return orb.LineString(densifyPoints(geom, step))
+	case orb.Ring:
+		return orb.Ring(densifyPoints(geom, step))
+	case orb.MultiLineString:
+		out := make(orb.MultiLineString, len(geom))
+		for i, ls := range geom {
+			out[i] = orb.LineString(densifyPoints(ls, step))
+		}
+		return out
+	case orb.Bound:
+		return DensifyLonLat(geom.ToPolygon(), step)
+	case orb.Polygon:
+		out := make(orb.Polygon, len(geom))
+		for i, r := range geom {
+			out[i] = orb.Ring(densifyPoints(r, step))
+		}
+		return out
+	case orb.MultiPolygon:
+		out := make(orb.MultiPolygon, len(geom))
+		for i, poly := range geom {
+			out[i] = DensifyLonLat(poly, step).(orb.Polygon)
+		}
+		return out
+	case orb.Collection:
+		out := make(orb.Collection, len(geom))
+		for i, sub := range geom {
+			out[i] = DensifyLonLat(sub, step)
+		}
+		return out
+	}
+	return g
+}
+
+func densifyPoints(pts []orb.Point, step float64) []orb.Point {
+	if len(pts) == 0 {
+		return nil
+	}
+	out := make([]orb.Point, 0, len(pts))
+	out = append(out, pts[0])
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		n := int(math.Ceil(math.Max(math.Abs(b[0]-a[0]), math.Abs(b[1]-a[1])) / step))
+		for j := 1; j < n; j++ {
+			f := float64(j) / float64(n)
+			out = append(out, orb.Point{a[0] + f*(b[0]-a[0]), a[1] + f*(b[1]-a[1])})
+		}
+		out = append(out, b)
+	}
+	return out
+}
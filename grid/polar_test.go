@@ -0,0 +1,53 @@
+package grid
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+// identityLonLat projects lon/lat onto itself.
+type identityLonLat struct{}
+
+func (identityLonLat) Forward(lon, lat float64) (float64, float64, error) { return lon, lat, nil }
+func (identityLonLat) Inverse(x, y float64) (float64, float64, error)     { return x, y, nil }
+
+func TestPoleWithin(t *testing.T) {
+	p := identityLonLat{}
+	cases := []struct {
+		b    Bounds
+		want int
+	}{
+		{Bounds{MinX: -10, MinY: 80, MaxX: 10, MaxY: 91}, 1},
+		{Bounds{MinX: -10, MinY: -91, MaxX: 10, MaxY: -80}, -1},
+		// A pole on the edge is not inside.
+		{Bounds{MinX: -10, MinY: 80, MaxX: 10, MaxY: 90}, 0},
+		{Bounds{MinX: 10, MinY: -91, MaxX: 20, MaxY: -80}, 0},
+	}
+	for _, tc := range cases {
+		if got := PoleWithin(tc.b, p); got != tc.want {
+			t.Fatalf("PoleWithin(%+v) = %d, want %d", tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestDensifyLonLat(t *testing.T) {
+	poly := orb.Polygon{{{0, 0}, {10, 0}, {10, 2.5}, {0, 0}}}
+	got := DensifyLonLat(poly, 1).(orb.Polygon)
+	// 10 segments along the bottom, 3 up the side and 10 back.
+	if len(got[0]) != 24 {
+		t.Fatalf("densified ring has %d points: %v", len(got[0]), got[0])
+	}
+	for i := 1; i < len(got[0]); i++ {
+		a, b := got[0][i-1], got[0][i]
+		if b[0]-a[0] > 1+1e-12 || a[0]-b[0] > 1+1e-12 || b[1]-a[1] > 1+1e-12 || a[1]-b[1] > 1+1e-12 {
+			t.Fatalf("segment %v-%v longer than the step", a, b)
+		}
+	}
+	if got[0][0] != poly[0][0] || got[0][23] != poly[0][3] {
+		t.Fatalf("end points changed: %v", got[0])
+	}
+	if pt := DensifyLonLat(orb.Point{1, 2}, 1); pt != (orb.Point{1, 2}) {
+		t.Fatalf("point changed: %v", pt)
+	}
+}
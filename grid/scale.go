@@ -0,0 +1,73 @@
+package grid
+
+import (
+	"fmt"
+	"math"
+)
+
+// GroundMetersPerUnit returns the ground distance in meters covered by one
+// CRS unit along the x-axis at lon/lat, e.g. cos(lat) for EPSG:3857 or
+// 111319.49*cos(lat) for EPSG:4326. The distortion is measured by
+// unprojecting a short step with p, which converts between EPSG:4326 and the
+// CRS; distances are great-circle distances on a sphere with the WGS84
+// semi-major axis, matching the OGC meters-per-unit convention.
+func GroundMetersPerUnit(p Projector, crs string, lon, lat float64) (float64, error) {
+	return groundMetersPerUnit(p, crs, lon, lat, 1, 0)
+}
+
+// GroundMetersPerUnitXY is GroundMetersPerUnit along the x-axis and the
+// y-axis. The two differ in projections that are not conformal, e.g. along
+// the y-axis EPSG:4326 covers 111319.49 meters per degree at any latitude.
+func GroundMetersPerUnitXY(p Projector, crs string, lon, lat float64) (float64, float64, error) {
+	x, err := groundMetersPerUnit(p, crs, lon, lat, 1, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := groundMetersPerUnit(p, crs, lon, lat, 0, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
+
+// groundMetersPerUnit measures a step along the CRS direction dx, dy.
+func groundMetersPerUnit(p Projector, crs string, lon, lat, dx, dy float64) (float64, error) {
+	if math.Abs(lat) >= 90 {
+		return 0, fmt.Errorf("latitude %v out of range", lat)
+	}
+	x, y, err := p.Forward(lon, lat)
+	if err != nil {
+		return 0, err
+	}
+	mpu, err := MetersPerUnit(crs)
+	if err != nil {
+		return 0, err
+	}
+	// A step of about one meter keeps the difference well above the
+	// floating point noise of projected coordinates.
+	step := 1 / mpu
+	lon1, lat1, err := p.Inverse(x-dx*step/2, y-dy*step/2)
+	if err != nil {
+		return 0, err
+	}
+	lon2, lat2, err := p.Inverse(x+dx*step/2, y+dy*step/2)
+	if err != nil {
+		return 0, err
+	}
+	d := greatCircleDistance(lon1, lat1, lon2, lat2)
+	if d == 0 || math.IsNaN(d) {
+		return 0, fmt.Errorf("no ground distance at %v,%v in %s", lon, lat, crs)
+	}
+	return d / step, nil
+}
+
+// greatCircleDistance returns the haversine distance in meters between two
+// lon/lat positions in degrees.
+func greatCircleDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
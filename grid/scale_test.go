@@ -0,0 +1,41 @@
+package grid
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGroundMetersPerUnit(t *testing.T) {
+	mercator := NewWGS84Projector("EPSG:3857")
+	for _, lat := range []float64{0, 45, 60, -75} {
+		got, err := GroundMetersPerUnit(mercator, "EPSG:3857", 10, lat)
+		if err != nil {
+			t.Fatalf("lat %v: %v", lat, err)
+		}
+		if want := math.Cos(lat * math.Pi / 180); !almostEqual(got, want, 1e-6) {
+			t.Fatalf("lat %v: got %v, want %v", lat, got, want)
+		}
+	}
+
+	geographic := NewWGS84Projector("EPSG:4326")
+	got, err := GroundMetersPerUnit(geographic, "EPSG:4326", 0, 60)
+	if err != nil {
+		t.Fatalf("geographic: %v", err)
+	}
+	if want := 111319.49079327357 / 2; !almostEqual(got, want, 1e-3) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// Along the y-axis a degree of latitude keeps its length.
+	x, y, err := GroundMetersPerUnitXY(geographic, "EPSG:4326", 0, 60)
+	if err != nil {
+		t.Fatalf("geographic xy: %v", err)
+	}
+	if !almostEqual(x, got, 1e-9) || !almostEqual(y, 111319.49079327357, 1e-3) {
+		t.Fatalf("got %v x %v", x, y)
+	}
+
+	if _, err := GroundMetersPerUnit(mercator, "EPSG:3857", 0, 90); err == nil {
+		t.Fatalf("expected error at the pole")
+	}
+}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/hafenkran/gocantile/tms"
 	"github.com/paulmach/orb"
-	"github.com/paulmach/orb/clip"
 )
 
 // TileMatrix wraps a TileMatrix from the OGC-generated structs and
@@ -64,6 +63,44 @@ func (a TileMatrix) matrixHeight() int {
 	return int(math.Round(a.TM.MatrixHeight))
 }
 
+// MatrixSize returns the number of tile columns and rows of the matrix.
+func (a TileMatrix) MatrixSize() (int, int) {
+	return a.matrixWidth(), a.matrixHeight()
+}
+
+// Coalesce returns the coalescence factor of the row: the number of matrix
+// columns merged into one tile. It is 1 outside variableMatrixWidths.
+func (a TileMatrix) Coalesce(row int) int {
+	if ri := a.rowInfo(); ri != nil {
+		if info, ok := ri[row]; ok && info.coalesce > 0 {
+			return info.coalesce
+		}
+	}
+	return 1
+}
+
+// RowWidth returns the number of tiles in the row after coalescing.
+func (a TileMatrix) RowWidth(row int) int {
+	return a.matrixWidth() / a.Coalesce(row)
+}
+
+// RowTileRange returns the first and last tile columns of the row that cover
+// the matrix columns of tr. In coalesced rows each tile spans several matrix
+// columns. The row holds no tiles of tr if first > last.
+func (a TileMatrix) RowTileRange(tr TileRange, row int) (first, last int) {
+	k := a.Coalesce(row)
+	return tr.MinCol / k, min(tr.MaxCol/k, a.RowWidth(row)-1)
+}
+
+// ContainsTile reports whether the tile exists in the matrix, taking the
+// reduced width of coalesced rows into account.
+func (a TileMatrix) ContainsTile(t TileIndex) bool {
+	if t.Row < 0 || t.Row >= a.matrixHeight() || t.Col < 0 {
+		return false
+	}
+	return t.Col < a.RowWidth(t.Row)
+}
+
 func (a TileMatrix) origin() (float64, float64, bool) {
 	if len(a.TM.PointOfOrigin) < 2 {
 		return 0, 0, false
@@ -222,7 +259,8 @@ func (a TileMatrix) TileRangeForBounds(b Bounds) (TileRange, bool) {
 }
 
 // TilesForBounds returns all tiles covering the given bounds (in CRS units),
-// clipped to the matrix extent.
+// clipped to the matrix extent. In coalesced rows the tile columns are
+// returned, each covering several matrix columns.
 func (a TileMatrix) TilesForBounds(b Bounds) []TileIndex {
 	tr, ok := a.TileRangeForBounds(b)
 	if !ok {
@@ -230,51 +268,188 @@ func (a TileMatrix) TilesForBounds(b Bounds) []TileIndex {
 	}
 	var tiles []TileIndex
 	for r := tr.MinRow; r <= tr.MaxRow; r++ {
-		for c := tr.MinCol; c <= tr.MaxCol; c++ {
+		first, last := a.RowTileRange(tr, r)
+		for c := first; c <= last; c++ {
 			tiles = append(tiles, TileIndex{Col: c, Row: r})
 		}
 	}
 	return tiles
 }
 
-// TilesForGeometry returns all tiles that intersect the provided geometry
-// in the matrix CRS. An optional buffer (in CRS units) can be provided to
-// expand the geometry bounds before calculating tiles.
+// TilesForGeometry returns all tiles that intersect the bounds of the
+// provided geometry in the matrix CRS, as TilesForBounds. An optional buffer
+// (in CRS units) can be provided to expand the geometry bounds before
+// calculating tiles.
 func (a TileMatrix) TilesForGeometry(g orb.Geometry, buffer float64) []TileIndex {
-	bound := g.Bound()
-	b := Bounds{
-		MinX: bound.Min[0],
-		MinY: bound.Min[1],
-		MaxX: bound.Max[0],
-		MaxY: bound.Max[1],
-	}
-	if buffer != 0 {
-		b.MinX -= buffer
-		b.MinY -= buffer
-		b.MaxX += buffer
-		b.MaxY += buffer
-	}
-	tr, ok := a.TileRangeForBounds(b)
+	var tiles []TileIndex
+	a.EachTileForGeometry(g, buffer, func(idx TileIndex) bool {
+		tiles = append(tiles, idx)
+		return true
+	})
+	return tiles
+}
+
+// EachTileForGeometry calls fn for the tiles of TilesForGeometry row by row
+// until fn returns false, without collecting them.
+func (a TileMatrix) EachTileForGeometry(g orb.Geometry, buffer float64, fn func(TileIndex) bool) {
+	tr, ok := a.TileRangeForGeometry(g, buffer)
 	if !ok {
-		return nil
+		return
 	}
-	geomBound := orb.Bound{
-		Min: orb.Point{b.MinX, b.MinY},
-		Max: orb.Point{b.MaxX, b.MaxY},
+	a.EachTileForGeometryInRows(g, buffer, tr.MinRow, tr.MaxRow, fn)
+}
+
+// TileRangeForGeometry returns the matrix rows and columns EachTileForGeometry
+// walks: the range covering the geometry bounds grown by buffer.
+func (a TileMatrix) TileRangeForGeometry(g orb.Geometry, buffer float64) (TileRange, bool) {
+	return a.TileRangeForBounds(bufferedBounds(g, buffer))
+}
+
+// EachTileForGeometryInRows is EachTileForGeometry limited to the rows minRow
+// to maxRow, so that bands of rows can be walked independently. Tiles are
+// selected by the buffered bounds of the geometry alone, so the geometry is
+// not clipped per tile; see EachTileCovering for tiles that meet the
+// geometry itself.
+func (a TileMatrix) EachTileForGeometryInRows(g orb.Geometry, buffer float64, minRow, maxRow int, fn func(TileIndex) bool) {
+	tr, ok := a.TileRangeForGeometry(g, buffer)
+	if !ok {
+		return
 	}
-	var tiles []TileIndex
-	for r := tr.MinRow; r <= tr.MaxRow; r++ {
-		for c := tr.MinCol; c <= tr.MaxCol; c++ {
-			tb, _ := a.BoundsForTile(TileIndex{Col: c, Row: r})
-			tileBound := orb.Bound{
-				Min: orb.Point{tb.MinX, tb.MinY},
-				Max: orb.Point{tb.MaxX, tb.MaxY},
+	for r := max(tr.MinRow, minRow); r <= min(tr.MaxRow, maxRow); r++ {
+		first, last := a.RowTileRange(tr, r)
+		for c := first; c <= last; c++ {
+			if !fn(TileIndex{Col: c, Row: r}) {
+				return
 			}
-			clipped := clip.Geometry(tileBound, g)
-			if clipped != nil || tileBound.Intersects(geomBound) {
-				tiles = append(tiles, TileIndex{Col: c, Row: r})
+		}
+	}
+}
+
+// bufferedBounds returns the bounds of g grown by buffer on every side.
+func bufferedBounds(g orb.Geometry, buffer float64) Bounds {
+	bound := g.Bound()
+	return Bounds{
+		MinX: bound.Min[0] - buffer,
+		MinY: bound.Min[1] - buffer,
+		MaxX: bound.Max[0] + buffer,
+		MaxY: bound.Max[1] + buffer,
+	}
+}
+
+// EachTileNearGeometry calls fn for the tiles within distance buffer (in CRS
+// units) of the geometry, i.e. the tiles intersecting the geometry buffered
+// by that distance, row by row until fn returns false. Unlike
+// EachTileForGeometry the buffer applies to the geometry rather than to its
+// bounds, so a diagonal line only selects the tiles along it. Tiles that only
+// touch a polygon along an edge are left out; points and lines on a tile edge
+// select the tiles on both sides.
+func (a TileMatrix) EachTileNearGeometry(g orb.Geometry, buffer float64, fn func(TileIndex) bool) {
+	a.EachTileCovering(g, CoverOptions{Buffer: buffer}, func(c TileCover) bool {
+		return fn(c.TileIndex)
+	})
+}
+
+// PolygonForBounds returns the bounds as a counter-clockwise polygon with
+// densify extra points inserted evenly along each edge.
+func PolygonForBounds(b Bounds, densify int) orb.Polygon {
+	if densify < 0 {
+		densify = 0
+	}
+	corners := [4]orb.Point{
+		{b.MinX, b.MinY},
+		{b.MaxX, b.MinY},
+		{b.MaxX, b.MaxY},
+		{b.MinX, b.MaxY},
+	}
+	steps := densify + 1
+	ring := make(orb.Ring, 0, 4*steps+1)
+	for i := 0; i < 4; i++ {
+		from, to := corners[i], corners[(i+1)%4]
+		for s := 0; s < steps; s++ {
+			f := float64(s) / float64(steps)
+			ring = append(ring, orb.Point{
+				from[0] + (to[0]-from[0])*f,
+				from[1] + (to[1]-from[1])*f,
+			})
+		}
+	}
+	ring = append(ring, ring[0])
+	return orb.Polygon{ring}
+}
+
+// PolygonForTile returns the tile footprint in the matrix CRS as a polygon
+// with densify extra points per edge.
+func (a TileMatrix) PolygonForTile(t TileIndex, densify int) (orb.Polygon, error) {
+	b, err := a.BoundsForTile(t)
+	if err != nil {
+		return nil, err
+	}
+	return PolygonForBounds(b, densify), nil
+}
+
+// PolygonForTileLonLat returns the tile footprint in lon/lat (degrees). Edges
+// are densified before reprojection so that curved edges are preserved.
+func (a TileMatrix) PolygonForTileLonLat(t TileIndex, densify int, p Projector) (orb.Polygon, error) {
+	poly, err := a.PolygonForTile(t, densify)
+	if err != nil {
+		return nil, err
+	}
+	return InversePolygon(poly, p)
+}
+
+// InversePolygon converts a polygon from projected CRS coordinates to lon/lat
+// (degrees) using the projector.
+func InversePolygon(poly orb.Polygon, p Projector) (orb.Polygon, error) {
+	out := make(orb.Polygon, 0, len(poly))
+	for _, ring := range poly {
+		r := make(orb.Ring, 0, len(ring))
+		for _, pt := range ring {
+			lon, lat, err := p.Inverse(pt[0], pt[1])
+			if err != nil {
+				return nil, err
 			}
+			r = append(r, orb.Point{lon, lat})
 		}
+		out = append(out, r)
 	}
-	return tiles
+	return out, nil
+}
+
+// flipRow mirrors a row index vertically within the matrix.
+func (a TileMatrix) flipRow(row int) int {
+	return a.matrixHeight() - 1 - row
+}
+
+// XYZ returns the slippy-map x/y of the tile: columns counted from the left
+// and rows from the top, regardless of the matrix cornerOfOrigin.
+func (a TileMatrix) XYZ(t TileIndex) (int, int) {
+	if a.TM.CornerOfOrigin == tms.TileMatrixJsonCornerOfOriginBottomLeft {
+		return t.Col, a.flipRow(t.Row)
+	}
+	return t.Col, t.Row
+}
+
+// FromXYZ returns the tile index for slippy-map x/y; see XYZ.
+func (a TileMatrix) FromXYZ(x, y int) TileIndex {
+	if a.TM.CornerOfOrigin == tms.TileMatrixJsonCornerOfOriginBottomLeft {
+		return TileIndex{Col: x, Row: a.flipRow(y)}
+	}
+	return TileIndex{Col: x, Row: y}
+}
+
+// TMS returns the OSGeo TMS x/y of the tile: columns counted from the left
+// and rows from the bottom, as used by MBTiles.
+func (a TileMatrix) TMS(t TileIndex) (int, int) {
+	if a.TM.CornerOfOrigin == tms.TileMatrixJsonCornerOfOriginBottomLeft {
+		return t.Col, t.Row
+	}
+	return t.Col, a.flipRow(t.Row)
+}
+
+// FromTMS returns the tile index for OSGeo TMS x/y; see TMS.
+func (a TileMatrix) FromTMS(x, y int) TileIndex {
+	if a.TM.CornerOfOrigin == tms.TileMatrixJsonCornerOfOriginBottomLeft {
+		return TileIndex{Col: x, Row: y}
+	}
+	return TileIndex{Col: x, Row: a.flipRow(y)}
 }
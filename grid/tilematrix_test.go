@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/hafenkran/gocantile/tms"
@@ -204,6 +205,10 @@ func TestTilesForGeometry(t *testing.T) {
 	if len(tiles) != 2 {
 		t.Fatalf("expected 2 tiles, got %d", len(tiles))
 	}
+	// The geometry is not clipped in place.
+	if poly2[0][1] != (orb.Point{26000, 47000}) || len(poly2[0]) != 5 {
+		t.Fatalf("geometry modified: %v", poly2)
+	}
 }
 
 func TestTilesForGeometryWebMercator(t *testing.T) {
@@ -332,3 +337,244 @@ func TestVariableMatrixWidths(t *testing.T) {
 		t.Fatalf("expected out-of-range for coalesced width")
 	}
 }
+
+func TestTilesForBoundsCoalescedRows(t *testing.T) {
+	adapter := TileMatrix{
+		TM: tms.TileMatrix{
+			CellSize:      1,
+			TileWidth:     1,
+			TileHeight:    1,
+			MatrixWidth:   4,
+			MatrixHeight:  2,
+			PointOfOrigin: []float64{0, 2},
+			VariableMatrixWidths: []tms.VariableMatrixWidthJson{
+				{Coalesce: 2, MinTileRow: 0, MaxTileRow: 0},
+			},
+		},
+	}
+	// The eastern half holds matrix columns 2 and 3, which are tile 1 of
+	// the coalesced top row.
+	east := Bounds{MinX: 2.5, MinY: 0.5, MaxX: 3.5, MaxY: 1.5}
+	want := []TileIndex{{Col: 1, Row: 0}, {Col: 2, Row: 1}, {Col: 3, Row: 1}}
+	if got := adapter.TilesForBounds(east); !reflect.DeepEqual(got, want) {
+		t.Fatalf("bounds tiles = %v, want %v", got, want)
+	}
+	line := orb.LineString{{2.5, 0.5}, {3.5, 1.5}}
+	if got := adapter.TilesForGeometry(line, 0); !reflect.DeepEqual(got, want) {
+		t.Fatalf("geometry tiles = %v, want %v", got, want)
+	}
+	// The whole matrix has 2 tiles in the top row and 4 below.
+	if got := adapter.TilesForBounds(Bounds{MinX: 0, MinY: 0, MaxX: 4, MaxY: 2}); len(got) != 6 {
+		t.Fatalf("all tiles = %v", got)
+	}
+	// Matrix columns 1 to 2 straddle both tiles of the top row.
+	if first, last := adapter.RowTileRange(TileRange{MinCol: 1, MaxCol: 2}, 0); first != 0 || last != 1 {
+		t.Fatalf("row 0 tile range = %d..%d, want 0..1", first, last)
+	}
+	if first, last := adapter.RowTileRange(TileRange{MinCol: 1, MaxCol: 2}, 1); first != 1 || last != 2 {
+		t.Fatalf("row 1 tile range = %d..%d, want 1..2", first, last)
+	}
+}
+
+func TestPolygonForBoundsDensify(t *testing.T) {
+	b := Bounds{MinX: 0, MinY: 0, MaxX: 4, MaxY: 2}
+	poly := PolygonForBounds(b, 3)
+	ring := poly[0]
+	if len(ring) != 17 {
+		t.Fatalf("expected 17 ring points, got %d", len(ring))
+	}
+	if !ring.Closed() {
+		t.Fatalf("expected closed ring")
+	}
+	if ring.Orientation() != orb.CCW {
+		t.Fatalf("expected counter-clockwise ring")
+	}
+	if ring[1] != (orb.Point{1, 0}) {
+		t.Fatalf("unexpected densified point %v", ring[1])
+	}
+	if got := PolygonForBounds(b, 0)[0]; len(got) != 5 {
+		t.Fatalf("expected 5 ring points without densify, got %d", len(got))
+	}
+}
+
+func TestPolygonForTileLonLat(t *testing.T) {
+	adapter := newMercatorAdapter()
+	proj := NewWGS84Projector("EPSG:3857")
+
+	poly, err := adapter.PolygonForTileLonLat(TileIndex{Col: 0, Row: 0}, 4, proj)
+	if err != nil {
+		t.Fatalf("polygon err: %v", err)
+	}
+	bound := poly.Bound()
+	if !almostEqual(bound.Min[0], -180, 1e-6) || !almostEqual(bound.Max[0], 180, 1e-6) {
+		t.Fatalf("unexpected lon range %+v", bound)
+	}
+	if !almostEqual(bound.Max[1], 85.0511287798, 1e-6) {
+		t.Fatalf("unexpected max lat %f", bound.Max[1])
+	}
+	if _, err := adapter.PolygonForTile(TileIndex{Col: 1, Row: 0}, 0); err == nil {
+		t.Fatalf("expected error for out-of-range tile")
+	}
+}
+
+func TestContainsTileCoalescedRows(t *testing.T) {
+	adapter := TileMatrix{TM: tms.TileMatrix{
+		CellSize:      1,
+		TileWidth:     1,
+		TileHeight:    1,
+		MatrixWidth:   8,
+		MatrixHeight:  4,
+		PointOfOrigin: []float64{0, 4},
+		VariableMatrixWidths: []tms.VariableMatrixWidthJson{
+			{Coalesce: 4, MinTileRow: 0, MaxTileRow: 0},
+		},
+	}}
+	if w, h := adapter.MatrixSize(); w != 8 || h != 4 {
+		t.Fatalf("unexpected matrix size %dx%d", w, h)
+	}
+	if adapter.Coalesce(0) != 4 || adapter.Coalesce(1) != 1 {
+		t.Fatalf("unexpected coalesce factors %d %d", adapter.Coalesce(0), adapter.Coalesce(1))
+	}
+	if adapter.RowWidth(0) != 2 || adapter.RowWidth(3) != 8 {
+		t.Fatalf("unexpected row widths %d %d", adapter.RowWidth(0), adapter.RowWidth(3))
+	}
+	cases := []struct {
+		idx  TileIndex
+		want bool
+	}{
+		{TileIndex{Col: 1, Row: 0}, true},
+		{TileIndex{Col: 2, Row: 0}, false},
+		{TileIndex{Col: 7, Row: 1}, true},
+		{TileIndex{Col: 8, Row: 1}, false},
+		{TileIndex{Col: 0, Row: 4}, false},
+		{TileIndex{Col: -1, Row: 2}, false},
+	}
+	for _, tc := range cases {
+		if got := adapter.ContainsTile(tc.idx); got != tc.want {
+			t.Fatalf("ContainsTile(%+v) = %v, want %v", tc.idx, got, tc.want)
+		}
+	}
+}
+
+func TestXYZAndTMSNumbering(t *testing.T) {
+	topLeft := TileMatrix{TM: tms.TileMatrix{MatrixWidth: 4, MatrixHeight: 2}}
+	bottomLeft := TileMatrix{TM: tms.TileMatrix{
+		MatrixWidth:    4,
+		MatrixHeight:   2,
+		CornerOfOrigin: tms.TileMatrixJsonCornerOfOriginBottomLeft,
+	}}
+	idx := TileIndex{Col: 3, Row: 0}
+
+	if x, y := topLeft.XYZ(idx); x != 3 || y != 0 {
+		t.Fatalf("topLeft XYZ: got %d/%d", x, y)
+	}
+	if x, y := topLeft.TMS(idx); x != 3 || y != 1 {
+		t.Fatalf("topLeft TMS: got %d/%d", x, y)
+	}
+	if x, y := bottomLeft.XYZ(idx); x != 3 || y != 1 {
+		t.Fatalf("bottomLeft XYZ: got %d/%d", x, y)
+	}
+	if x, y := bottomLeft.TMS(idx); x != 3 || y != 0 {
+		t.Fatalf("bottomLeft TMS: got %d/%d", x, y)
+	}
+	for _, a := range []TileMatrix{topLeft, bottomLeft} {
+		if got := a.FromXYZ(a.XYZ(idx)); got != idx {
+			t.Fatalf("XYZ round trip: got %+v", got)
+		}
+		if got := a.FromTMS(a.TMS(idx)); got != idx {
+			t.Fatalf("TMS round trip: got %+v", got)
+		}
+	}
+}
+
+func TestEachTileNearGeometry(t *testing.T) {
+	// 4x4 tiles of one unit with the origin at the top left.
+	adapter := TileMatrix{TM: tms.TileMatrix{
+		CellSize: 1, TileWidth: 1, TileHeight: 1, MatrixWidth: 4, MatrixHeight: 4,
+		PointOfOrigin: []float64{0, 4},
+	}}
+	collect := func(g orb.Geometry, buffer float64) map[TileIndex]bool {
+		got := map[TileIndex]bool{}
+		adapter.EachTileNearGeometry(g, buffer, func(idx TileIndex) bool {
+			got[idx] = true
+			return true
+		})
+		return got
+	}
+
+	// A diagonal only selects the tiles along it, not its whole bounds.
+	diag := orb.LineString{{0.5, 0.5}, {3.5, 3.2}}
+	got := collect(diag, 0)
+	if len(got) != 7 || !got[TileIndex{Col: 0, Row: 3}] || !got[TileIndex{Col: 3, Row: 0}] || got[TileIndex{Col: 3, Row: 3}] {
+		t.Fatalf("diagonal tiles = %v", got)
+	}
+	// A buffer of one unit reaches the next tiles off the diagonal.
+	if got := collect(diag, 1); len(got) != 14 || got[TileIndex{Col: 3, Row: 3}] {
+		t.Fatalf("buffered diagonal tiles = %v", got)
+	}
+
+	// A polygon covering a tile exactly leaves its neighbours out, until the
+	// buffer reaches into them.
+	tile := orb.Polygon{{{1, 1}, {2, 1}, {2, 2}, {1, 2}, {1, 1}}}
+	if got := collect(tile, 0); len(got) != 1 || !got[TileIndex{Col: 1, Row: 2}] {
+		t.Fatalf("tile polygon tiles = %v", got)
+	}
+	if got := collect(tile, 0.1); len(got) != 9 {
+		t.Fatalf("buffered tile polygon tiles = %v", got)
+	}
+
+	// Points keep their tiles with a buffer short of the next tile.
+	if got := collect(orb.Point{1.5, 1.5}, 0.4); len(got) != 1 {
+		t.Fatalf("point tiles = %v", got)
+	}
+	// A hole keeps the tiles inside it out.
+	donut := orb.Polygon{
+		{{0, 0}, {4, 0}, {4, 4}, {0, 4}, {0, 0}},
+		{{0.9, 0.9}, {3.1, 0.9}, {3.1, 3.1}, {0.9, 3.1}, {0.9, 0.9}},
+	}
+	if got := collect(donut, 0); len(got) != 12 || got[TileIndex{Col: 1, Row: 1}] {
+		t.Fatalf("donut tiles = %v", got)
+	}
+	// Stopping early.
+	n := 0
+	adapter.EachTileNearGeometry(diag, 0, func(TileIndex) bool { n++; return n < 2 })
+	if n != 2 {
+		t.Fatalf("expected to stop after 2 tiles, got %d", n)
+	}
+}
+
+func TestEachTileForGeometryInRows(t *testing.T) {
+	adapter := TileMatrix{TM: tms.TileMatrix{
+		CellSize: 1, TileWidth: 1, TileHeight: 1, MatrixWidth: 4, MatrixHeight: 4,
+		PointOfOrigin: []float64{0, 4},
+	}}
+	g := orb.LineString{{0.5, 0.5}, {2.5, 3.5}}
+	tr, ok := adapter.TileRangeForGeometry(g, 0)
+	if !ok || tr.MinRow != 0 || tr.MaxRow != 3 || tr.MinCol != 0 || tr.MaxCol != 2 {
+		t.Fatalf("range = %+v ok=%v", tr, ok)
+	}
+
+	// Walking the rows in bands visits the same tiles in the same order.
+	want := adapter.TilesForGeometry(g, 0)
+	var got []TileIndex
+	for r := tr.MinRow; r <= tr.MaxRow; r += 3 {
+		adapter.EachTileForGeometryInRows(g, 0, r, r+2, func(idx TileIndex) bool {
+			got = append(got, idx)
+			return true
+		})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("banded tiles = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("banded tiles = %v, want %v", got, want)
+		}
+	}
+
+	// Rows outside the geometry range yield nothing.
+	adapter.EachTileForGeometryInRows(g, 0, 5, 8, func(idx TileIndex) bool {
+		t.Fatalf("unexpected tile %v", idx)
+		return false
+	})
+}
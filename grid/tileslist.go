@@ -0,0 +1,256 @@
+package grid
+
+import "sort"
+
+// TileHierarchy relates the tiles of consecutive zoom levels of a tile matrix
+// set.
+type TileHierarchy interface {
+	// Parent returns the tile one zoom level up that contains the tile.
+	Parent(t Tile) (Tile, error)
+	// Children returns the tiles one zoom level down that exactly make up
+	// the tile.
+	Children(t Tile) (TilesList, error)
+}
+
+// Simplify returns the smallest list of tiles covering the same area as the
+// list: duplicates and tiles within another tile of the list are dropped, and
+// complete groups of siblings are replaced by their parent, level by level up
+// to zoom 0. Tiles whose parent or children h cannot determine, as in sets
+// whose levels do not nest, are kept as they are. The result is sorted by
+// zoom, row and column.
+func (l TilesList) Simplify(h TileHierarchy) TilesList {
+	return l.SimplifyToZoom(h, 0)
+}
+
+// SimplifyToZoom is Simplify without merging tiles into parents above
+// minZoom.
+func (l TilesList) SimplifyToZoom(h TileHierarchy, minZoom int) TilesList {
+	set := make(map[Tile]struct{}, len(l))
+	maxZoom := minZoom
+	for _, t := range l {
+		set[t] = struct{}{}
+		maxZoom = max(maxZoom, t.Zoom)
+	}
+
+	// Drop tiles within an ancestor of the list.
+	for t := range set {
+		for a := t; ; {
+			p, err := h.Parent(a)
+			if err != nil {
+				break
+			}
+			if _, ok := set[p]; ok {
+				delete(set, t)
+				break
+			}
+			a = p
+		}
+	}
+
+	for z := maxZoom; z > minZoom; z-- {
+		parents := map[Tile]struct{}{}
+		for t := range set {
+			if t.Zoom != z {
+				continue
+			}
+			if p, err := h.Parent(t); err == nil {
+				parents[p] = struct{}{}
+			}
+		}
+		for p := range parents {
+			children, err := h.Children(p)
+			if err != nil || len(children) == 0 {
+				continue
+			}
+			complete := true
+			for _, c := range children {
+				if _, ok := set[c]; !ok {
+					complete = false
+					break
+				}
+			}
+			if !complete {
+				continue
+			}
+			for _, c := range children {
+				delete(set, c)
+			}
+			set[p] = struct{}{}
+		}
+	}
+
+	return sortedSet(set)
+}
+
+// Set operations treat a list as the area covered by its tiles: with a
+// TileHierarchy, a tile at zoom z covers its descendants at higher zoom
+// levels. A nil hierarchy compares tiles as plain values.
+
+// Less reports whether a sorts before b: by zoom, then row, then column.
+func (a Tile) Less(b Tile) bool {
+	if a.Zoom != b.Zoom {
+		return a.Zoom < b.Zoom
+	}
+	if a.Row != b.Row {
+		return a.Row < b.Row
+	}
+	return a.Col < b.Col
+}
+
+// Sort sorts the list in place by zoom, row and column.
+func (l TilesList) Sort() {
+	sort.Slice(l, func(i, j int) bool { return l[i].Less(l[j]) })
+}
+
+// Dedup returns the list without repeated tiles, keeping the first occurrence
+// of each.
+func (l TilesList) Dedup() TilesList {
+	seen := make(map[Tile]struct{}, len(l))
+	out := make(TilesList, 0, len(l))
+	for _, t := range l {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		out = append(out, t)
+	}
+	return out
+}
+
+// Contains reports whether the tile is in the list.
+func (l TilesList) Contains(t Tile) bool {
+	for _, c := range l {
+		if c == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether the tile or one of its ancestors is in the list.
+func (l TilesList) Covers(t Tile, h TileHierarchy) bool {
+	return newTileIndex(l, h).covers(t)
+}
+
+// Union returns the tiles of both lists, without duplicates and without tiles
+// within another tile of the result, sorted. Sibling groups are not merged;
+// see Simplify.
+func (l TilesList) Union(other TilesList, h TileHierarchy) TilesList {
+	all := make(TilesList, 0, len(l)+len(other))
+	all = append(all, l...)
+	all = append(all, other...)
+	return newTileIndex(all, h).outermost()
+}
+
+// Intersection returns the tiles covering the area covered by both lists,
+// sorted: tiles of either list that lie within the other.
+func (l TilesList) Intersection(other TilesList, h TileHierarchy) TilesList {
+	a, b := newTileIndex(l, h), newTileIndex(other, h)
+	set := map[Tile]struct{}{}
+	for t := range a.tiles {
+		if b.covers(t) {
+			set[t] = struct{}{}
+		}
+	}
+	for t := range b.tiles {
+		if a.covers(t) {
+			set[t] = struct{}{}
+		}
+	}
+	return newTileIndex(sortedSet(set), h).outermost()
+}
+
+// Difference returns the tiles covering the area of the list that other does
+// not cover, sorted. A tile that other covers in part is replaced by those of
+// its descendants that other leaves out. Tiles whose children h cannot
+// determine are kept whole.
+func (l TilesList) Difference(other TilesList, h TileHierarchy) TilesList {
+	a, b := newTileIndex(l, h), newTileIndex(other, h)
+	// partial holds the strict ancestors of the tiles of other.
+	partial := map[Tile]struct{}{}
+	if h != nil {
+		for t := range b.tiles {
+			for p, err := h.Parent(t); err == nil; p, err = h.Parent(p) {
+				if _, ok := partial[p]; ok {
+					break
+				}
+				partial[p] = struct{}{}
+			}
+		}
+	}
+	set := map[Tile]struct{}{}
+	var subtract func(t Tile)
+	subtract = func(t Tile) {
+		if b.covers(t) {
+			return
+		}
+		if _, ok := partial[t]; ok {
+			if children, err := h.Children(t); err == nil {
+				for _, c := range children {
+					subtract(c)
+				}
+				return
+			}
+		}
+		set[t] = struct{}{}
+	}
+	for t := range a.tiles {
+		subtract(t)
+	}
+	return newTileIndex(sortedSet(set), h).outermost()
+}
+
+// tileIndex is a set of tiles with ancestor lookups.
+type tileIndex struct {
+	tiles map[Tile]struct{}
+	h     TileHierarchy
+}
+
+func newTileIndex(l TilesList, h TileHierarchy) tileIndex {
+	tiles := make(map[Tile]struct{}, len(l))
+	for _, t := range l {
+		tiles[t] = struct{}{}
+	}
+	return tileIndex{tiles: tiles, h: h}
+}
+
+// covers reports whether the tile or one of its ancestors is in the set.
+func (x tileIndex) covers(t Tile) bool {
+	if _, ok := x.tiles[t]; ok {
+		return true
+	}
+	return x.coveredByAncestor(t)
+}
+
+func (x tileIndex) coveredByAncestor(t Tile) bool {
+	if x.h == nil {
+		return false
+	}
+	for p, err := x.h.Parent(t); err == nil; p, err = x.h.Parent(p) {
+		if _, ok := x.tiles[p]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// outermost returns the sorted tiles of the set that have no ancestor in it.
+func (x tileIndex) outermost() TilesList {
+	out := make(TilesList, 0, len(x.tiles))
+	for t := range x.tiles {
+		if !x.coveredByAncestor(t) {
+			out = append(out, t)
+		}
+	}
+	out.Sort()
+	return out
+}
+
+func sortedSet(set map[Tile]struct{}) TilesList {
+	out := make(TilesList, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	out.Sort()
+	return out
+}
@@ -0,0 +1,127 @@
+package grid
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// quadHierarchy relates tiles of a plain quad tree down to maxZoom.
+type quadHierarchy struct{ maxZoom int }
+
+func (quadHierarchy) Parent(t Tile) (Tile, error) {
+	if t.Zoom == 0 {
+		return Tile{}, fmt.Errorf("no parent")
+	}
+	return Tile{Zoom: t.Zoom - 1, TileIndex: TileIndex{Col: t.Col / 2, Row: t.Row / 2}}, nil
+}
+
+func (h quadHierarchy) Children(t Tile) (TilesList, error) {
+	if t.Zoom >= h.maxZoom {
+		return nil, fmt.Errorf("no children")
+	}
+	var out TilesList
+	for _, d := range []TileIndex{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		out = append(out, Tile{Zoom: t.Zoom + 1, TileIndex: TileIndex{Col: 2*t.Col + d.Col, Row: 2*t.Row + d.Row}})
+	}
+	return out, nil
+}
+
+func TestTilesListSimplify(t *testing.T) {
+	h := quadHierarchy{maxZoom: 4}
+	tile := func(z, x, y int) Tile { return Tile{Zoom: z, TileIndex: TileIndex{Col: x, Row: y}} }
+
+	var tiles TilesList
+	// All of 1/0/0 at zoom 2, three quarters of 1/1/0 and a duplicate.
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 2; y++ {
+			tiles = append(tiles, tile(2, x, y))
+		}
+	}
+	tiles = append(tiles, tile(2, 2, 0), tile(2, 3, 0), tile(2, 2, 1), tile(2, 0, 0))
+	// A tile within 1/1/1, which is listed itself.
+	tiles = append(tiles, tile(3, 6, 6), tile(1, 1, 1))
+
+	got := tiles.Simplify(h)
+	want := TilesList{tile(1, 0, 0), tile(1, 1, 1), tile(2, 2, 0), tile(2, 3, 0), tile(2, 2, 1)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	tiles = append(tiles, tile(2, 3, 1))
+	if got := tiles.Simplify(h); !reflect.DeepEqual(got, TilesList{tile(1, 0, 0), tile(1, 1, 0), tile(1, 1, 1)}) {
+		t.Fatalf("got %v", got)
+	}
+	tiles = append(tiles, tile(1, 0, 1))
+	if got := tiles.Simplify(h); !reflect.DeepEqual(got, TilesList{tile(0, 0, 0)}) {
+		t.Fatalf("got %v", got)
+	}
+	if got := tiles.SimplifyToZoom(h, 1); len(got) != 4 {
+		t.Fatalf("got %v", got)
+	}
+	if got := TilesList(nil).Simplify(h); len(got) != 0 {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestTilesListSetOperations(t *testing.T) {
+	h := quadHierarchy{maxZoom: 4}
+	tile := func(z, x, y int) Tile { return Tile{Zoom: z, TileIndex: TileIndex{Col: x, Row: y}} }
+
+	a := TilesList{tile(1, 0, 0), tile(2, 2, 0), tile(2, 2, 0)}
+	b := TilesList{tile(2, 1, 1), tile(3, 4, 0), tile(2, 3, 3)}
+
+	if got := a.Dedup(); !reflect.DeepEqual(got, TilesList{tile(1, 0, 0), tile(2, 2, 0)}) {
+		t.Fatalf("dedup %v", got)
+	}
+	if !a.Contains(tile(2, 2, 0)) || a.Contains(tile(2, 1, 1)) {
+		t.Fatalf("contains")
+	}
+	if !a.Covers(tile(2, 1, 1), h) || a.Covers(tile(2, 1, 1), nil) || a.Covers(tile(2, 3, 0), h) {
+		t.Fatalf("covers")
+	}
+
+	// 2/1/1 lies within 1/0/0 and 3/4/0 within 2/2/0.
+	union := a.Union(b, h)
+	if want := (TilesList{tile(1, 0, 0), tile(2, 2, 0), tile(2, 3, 3)}); !reflect.DeepEqual(union, want) {
+		t.Fatalf("union %v, want %v", union, want)
+	}
+	if got := a.Union(b, nil); len(got) != 5 {
+		t.Fatalf("plain union %v", got)
+	}
+
+	inter := a.Intersection(b, h)
+	if want := (TilesList{tile(2, 1, 1), tile(3, 4, 0)}); !reflect.DeepEqual(inter, want) {
+		t.Fatalf("intersection %v, want %v", inter, want)
+	}
+	if got := a.Intersection(TilesList{tile(2, 2, 0)}, nil); !reflect.DeepEqual(got, TilesList{tile(2, 2, 0)}) {
+		t.Fatalf("plain intersection %v", got)
+	}
+
+	// 1/0/0 minus 2/1/1 leaves its three other children; 2/2/0 minus 3/4/0
+	// leaves three grandchildren.
+	diff := a.Difference(b, h)
+	want := TilesList{
+		tile(2, 0, 0), tile(2, 1, 0), tile(2, 0, 1),
+		tile(3, 5, 0), tile(3, 4, 1), tile(3, 5, 1),
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Fatalf("difference %v, want %v", diff, want)
+	}
+	if got := b.Difference(a, h); !reflect.DeepEqual(got, TilesList{tile(2, 3, 3)}) {
+		t.Fatalf("difference %v", got)
+	}
+	if got := a.Difference(a, h); len(got) != 0 {
+		t.Fatalf("self difference %v", got)
+	}
+	// Union of the difference and the intersection restores the list.
+	if got := diff.Union(inter, h).Simplify(h); !reflect.DeepEqual(got, TilesList{tile(1, 0, 0), tile(2, 2, 0)}) {
+		t.Fatalf("restored %v", got)
+	}
+
+	list := TilesList{tile(2, 1, 0), tile(1, 1, 1), tile(2, 0, 1), tile(2, 1, 1)}
+	list.Sort()
+	if want := (TilesList{tile(1, 1, 1), tile(2, 1, 0), tile(2, 0, 1), tile(2, 1, 1)}); !reflect.DeepEqual(list, want) {
+		t.Fatalf("sort %v", list)
+	}
+}
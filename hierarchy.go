@@ -0,0 +1,115 @@
+package gocantile
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+)
+
+// nestTolerance is the fraction of a tile's size by which tile edges of
+// consecutive zoom levels may differ and still be taken as aligned.
+const nestTolerance = 1e-9
+
+// Parent returns the tile one zoom level up that contains the tile. Tiles are
+// related by their footprints, so any ratio between levels, coalesced rows
+// and levels with different origins are supported as long as the tile lies
+// within a single tile of the parent level.
+func (t *TileMatrixSet) Parent(tile grid.Tile) (grid.Tile, error) {
+	child, err := t.validTileMatrix(tile)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	if tile.Zoom == 0 {
+		return grid.Tile{}, fmt.Errorf("tile %d/%d/%d has no parent", tile.Zoom, tile.Col, tile.Row)
+	}
+	parent, err := t.tileMatrix(tile.Zoom - 1)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	b, err := child.BoundsForTile(tile.TileIndex)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	idx, ok := parent.TileForXY((b.MinX+b.MaxX)/2, (b.MinY+b.MaxY)/2)
+	if !ok {
+		return grid.Tile{}, fmt.Errorf("tile %d/%d/%d outside zoom %d", tile.Zoom, tile.Col, tile.Row, tile.Zoom-1)
+	}
+	// TileForXY returns the matrix column; coalesced tiles span several.
+	idx.Col /= parent.Coalesce(idx.Row)
+	pb, err := parent.BoundsForTile(idx)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	if !boundsWithin(b, pb) {
+		return grid.Tile{}, fmt.Errorf("tile %d/%d/%d does not nest in zoom %d", tile.Zoom, tile.Col, tile.Row, tile.Zoom-1)
+	}
+	return grid.Tile{Zoom: tile.Zoom - 1, TileIndex: idx}, nil
+}
+
+// Children returns the tiles one zoom level down that make up the tile, row
+// by row. It fails if those tiles do not exactly cover the tile, e.g. where
+// a coalesced row is split across the edge of the tile.
+func (t *TileMatrixSet) Children(tile grid.Tile) (grid.TilesList, error) {
+	parent, err := t.validTileMatrix(tile)
+	if err != nil {
+		return nil, err
+	}
+	child, err := t.tileMatrix(tile.Zoom + 1)
+	if err != nil {
+		return nil, err
+	}
+	pb, err := parent.BoundsForTile(tile.TileIndex)
+	if err != nil {
+		return nil, err
+	}
+	// Shrink the bounds so that neighbouring tiles sharing an edge are not
+	// included.
+	eps := nestTolerance * math.Max(pb.MaxX-pb.MinX, pb.MaxY-pb.MinY)
+	inner := grid.Bounds{MinX: pb.MinX + eps, MinY: pb.MinY + eps, MaxX: pb.MaxX - eps, MaxY: pb.MaxY - eps}
+	tiles := child.TilesForBounds(inner)
+
+	var children grid.TilesList
+	var area float64
+	for _, idx := range tiles {
+		cb, err := child.BoundsForTile(idx)
+		if err != nil {
+			return nil, err
+		}
+		if !boundsWithin(cb, pb) {
+			return nil, fmt.Errorf("tile %d/%d/%d is not made up of tiles of zoom %d", tile.Zoom, tile.Col, tile.Row, tile.Zoom+1)
+		}
+		area += (cb.MaxX - cb.MinX) * (cb.MaxY - cb.MinY)
+		children = append(children, grid.Tile{Zoom: tile.Zoom + 1, TileIndex: idx})
+	}
+	parentArea := (pb.MaxX - pb.MinX) * (pb.MaxY - pb.MinY)
+	if len(children) == 0 || math.Abs(area-parentArea) > nestTolerance*parentArea {
+		return nil, fmt.Errorf("tile %d/%d/%d is not covered by tiles of zoom %d", tile.Zoom, tile.Col, tile.Row, tile.Zoom+1)
+	}
+	return children, nil
+}
+
+// boundsWithin reports whether inner lies within outer, allowing for
+// rounding of the tile edges.
+func boundsWithin(inner, outer grid.Bounds) bool {
+	eps := nestTolerance * math.Max(outer.MaxX-outer.MinX, outer.MaxY-outer.MinY)
+	return inner.MinX >= outer.MinX-eps && inner.MinY >= outer.MinY-eps &&
+		inner.MaxX <= outer.MaxX+eps && inner.MaxY <= outer.MaxY+eps
+}
+
+// MinimalCover returns the smallest set of tiles between minZoom and maxZoom
+// that covers the geometry exactly once: the tiles at maxZoom that intersect
+// the geometry itself, not just its bounds, with complete groups of siblings
+// replaced by their parent down to minZoom. The geometry is in the matrix
+// CRS, in the axis order of the set as in TilesForGeometry.
+func (t *TileMatrixSet) MinimalCover(g orb.Geometry, minZoom, maxZoom int) (grid.TilesList, error) {
+	if minZoom < 0 || minZoom > maxZoom {
+		return nil, fmt.Errorf("invalid zoom range %d-%d", minZoom, maxZoom)
+	}
+	tiles, err := t.TilesForGeometryWithOptions(g, maxZoom, maxZoom, CoverageOptions{Mode: grid.CoverIntersects})
+	if err != nil {
+		return nil, err
+	}
+	return tiles.SimplifyToZoom(t, minZoom), nil
+}
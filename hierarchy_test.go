@@ -0,0 +1,177 @@
+package gocantile
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hafenkran/gocantile/tms"
+	"github.com/paulmach/orb"
+)
+
+func TestParentChildren(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	p, err := set.Parent(Tile{Zoom: 3, TileIndex: TileIndex{Col: 5, Row: 2}})
+	if err != nil || p != (Tile{Zoom: 2, TileIndex: TileIndex{Col: 2, Row: 1}}) {
+		t.Fatalf("parent %v (%v)", p, err)
+	}
+	children, err := set.Children(p)
+	if err != nil {
+		t.Fatalf("children: %v", err)
+	}
+	want := [][3]int{{3, 4, 2}, {3, 5, 2}, {3, 4, 3}, {3, 5, 3}}
+	if keys := tileKeys(children); !reflect.DeepEqual(keys, want) {
+		t.Fatalf("children %v, want %v", keys, want)
+	}
+	if _, err := set.Parent(Tile{}); err == nil {
+		t.Fatalf("expected error for the parent of zoom 0")
+	}
+	if _, err := set.Children(Tile{Zoom: set.MaxZoom()}); err == nil {
+		t.Fatalf("expected error for the children of the last zoom level")
+	}
+
+	// Row 0 of GNOSISGlobalGrid zoom 1 is coalesced by 2 and row 0 of zoom
+	// 2 by 4, so the 90° tile 1/0/0 has one child in row 0 and two in row 1.
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	children, err = gnosis.Children(Tile{Zoom: 1})
+	if err != nil {
+		t.Fatalf("children: %v", err)
+	}
+	want = [][3]int{{2, 0, 0}, {2, 0, 1}, {2, 1, 1}}
+	if keys := tileKeys(children); !reflect.DeepEqual(keys, want) {
+		t.Fatalf("children %v, want %v", keys, want)
+	}
+	for _, c := range children {
+		if p, err := gnosis.Parent(c); err != nil || p != (Tile{Zoom: 1}) {
+			t.Fatalf("parent of %v: %v (%v)", c, p, err)
+		}
+	}
+}
+
+// thirdsSet has a 2x2 level followed by a 3x3 level over the same extent, so
+// the levels do not nest.
+func thirdsSet() *TileMatrixSet {
+	level := func(id string, n int) tms.TileMatrix {
+		return tms.TileMatrix{
+			Id:             id,
+			CellSize:       60 / float64(n) / 10,
+			CornerOfOrigin: tms.TileMatrixJsonCornerOfOriginTopLeft,
+			PointOfOrigin:  []float64{0, 60},
+			TileWidth:      10,
+			TileHeight:     10,
+			MatrixWidth:    float64(n),
+			MatrixHeight:   float64(n),
+		}
+	}
+	return WrapTileMatrixSet(tms.TileMatrixSet{
+		Crs:          "EPSG:3857",
+		TileMatrices: []tms.TileMatrix{level("0", 2), level("1", 3), level("2", 9)},
+	})
+}
+
+func TestParentChildrenNotNested(t *testing.T) {
+	set := thirdsSet()
+	if _, err := set.Parent(Tile{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 1}}); err == nil {
+		t.Fatalf("expected error for a tile across parents")
+	}
+	if _, err := set.Children(Tile{}); err == nil {
+		t.Fatalf("expected error for children across the tile edge")
+	}
+	// The ratio 3 between zoom 1 and 2 nests.
+	children, err := set.Children(Tile{Zoom: 1, TileIndex: TileIndex{Col: 2, Row: 0}})
+	if err != nil || len(children) != 9 {
+		t.Fatalf("children %v (%v)", children, err)
+	}
+}
+
+func TestMinimalCover(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	// The eastern half of the world down to zoom 3 is the two tiles of
+	// zoom 1; the western edge at x=0 is not included.
+	half := orb.Polygon{{{1, -2e7}, {2e7, -2e7}, {2e7, 2e7}, {1, 2e7}, {1, -2e7}}}
+	got, err := set.MinimalCover(half, 0, 3)
+	if err != nil {
+		t.Fatalf("minimal cover: %v", err)
+	}
+	want := [][3]int{{1, 1, 0}, {1, 1, 1}}
+	if keys := tileKeys(got); !reflect.DeepEqual(keys, want) {
+		t.Fatalf("cover %v, want %v", keys, want)
+	}
+	// With minZoom 2 the cover stops at zoom 2.
+	if got, _ = set.MinimalCover(half, 2, 3); len(got) != 8 || got[0].Zoom != 2 {
+		t.Fatalf("cover %v", tileKeys(got))
+	}
+	if _, err := set.MinimalCover(half, 3, 2); err == nil {
+		t.Fatalf("expected error for an invalid zoom range")
+	}
+
+	// A diagonal line keeps the zoom 4 tiles along it rather than the four
+	// zoom 2 tiles of its bounds.
+	diagonal := orb.LineString{{-1e7, -1e7}, {1e7, 1e7}}
+	got, err = set.MinimalCover(diagonal, 0, 4)
+	if err != nil {
+		t.Fatalf("minimal cover: %v", err)
+	}
+	if len(got) <= 4 {
+		t.Fatalf("diagonal cover %v", tileKeys(got))
+	}
+	for _, tile := range got {
+		if tile.Zoom != 4 || tile.Col+tile.Row < 14 || tile.Col+tile.Row > 16 {
+			t.Fatalf("diagonal cover has tile %v off the line", tileKeys(TilesList{tile}))
+		}
+	}
+
+	// In the non-nested set, zoom 2 merges into zoom 1 but not further.
+	thirds := thirdsSet()
+	all := orb.Polygon{{{1, 1}, {59, 1}, {59, 59}, {1, 59}, {1, 1}}}
+	got, err = thirds.MinimalCover(all, 0, 2)
+	if err != nil {
+		t.Fatalf("minimal cover: %v", err)
+	}
+	if len(got) != 9 || got[0].Zoom != 1 {
+		t.Fatalf("cover %v", tileKeys(got))
+	}
+}
+
+func TestSimplifyCoalesced(t *testing.T) {
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// All of zoom 2 plus a duplicate and a tile already inside another one
+	// simplify to zoom 0.
+	tiles := zoomTiles(t, gnosis, 2)
+	tiles = append(tiles, tiles[0], Tile{Zoom: 3, TileIndex: TileIndex{Col: 0, Row: 0}})
+	got := tiles.Simplify(gnosis)
+	if want := zoomTiles(t, gnosis, 0); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", tileKeys(got), tileKeys(want))
+	}
+	// One missing tile leaves its siblings in place.
+	partial := zoomTiles(t, gnosis, 2)[1:]
+	got = partial.Simplify(gnosis)
+	var count int
+	for _, tile := range got {
+		if tile.Zoom == 2 {
+			count++
+		}
+	}
+	if count != 2 {
+		t.Fatalf("got %v", tileKeys(got))
+	}
+}
+
+func TestTilesListDifferenceCoalesced(t *testing.T) {
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// Removing the coalesced 2/0/0 from 1/0/0 leaves the two tiles of row 1.
+	have := TilesList{{Zoom: 1}}
+	got := have.Difference(TilesList{{Zoom: 2}}, gnosis)
+	want := [][3]int{{2, 0, 1}, {2, 1, 1}}
+	if keys := tileKeys(got); !reflect.DeepEqual(keys, want) {
+		t.Fatalf("difference %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,145 @@
+package gocantile
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+)
+
+// footprintDensify is the number of points inserted per tile edge when a tile
+// footprint is reprojected into another set.
+const footprintDensify = 32
+
+// TilesIntersecting returns the tiles of dst at dstZoom whose area overlaps
+// the tile of src, e.g. to invalidate the tiles of one cache when a tile of
+// another is updated. The footprint of the tile is densified, reprojected
+// through lon/lat into the CRS of dst and covered there; tiles that only
+// touch it are left out. Footprint points beyond the valid area of the dst
+// CRS, such as the poles in WebMercatorQuad, are clamped to its extent.
+func TilesIntersecting(src *TileMatrixSet, tile grid.Tile, dst *TileMatrixSet, dstZoom int) (grid.TilesList, error) {
+	srcAdapter, err := src.eastingNorthingMatrix(tile)
+	if err != nil {
+		return nil, err
+	}
+	dstAdapter, err := dst.tileMatrix(dstZoom)
+	if err != nil {
+		return nil, err
+	}
+	dstAdapter = dst.eastingNorthing(dstAdapter)
+
+	footprint, err := srcAdapter.PolygonForTile(tile.TileIndex, footprintDensify)
+	if err != nil {
+		return nil, err
+	}
+	srcCRS, err := grid.ExtractCRS(src.TileMatrixSet)
+	if err != nil {
+		return nil, err
+	}
+	dstCRS, err := grid.ExtractCRS(dst.TileMatrixSet)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(srcCRS, dstCRS) {
+		if footprint, err = reprojectFootprint(footprint, src, dst); err != nil {
+			return nil, err
+		}
+	}
+
+	var tiles grid.TilesList
+	for _, idx := range dstAdapter.TilesIntersectingPolygon(footprint) {
+		tiles = append(tiles, grid.Tile{Zoom: dstZoom, TileIndex: idx})
+	}
+	return tiles, nil
+}
+
+// reprojectFootprint converts a polygon in the CRS of src into the CRS of dst.
+func reprojectFootprint(poly orb.Polygon, src, dst *TileMatrixSet) (orb.Polygon, error) {
+	srcP, err := grid.ProjectorFromTMS(src.TileMatrixSet)
+	if err != nil {
+		return nil, err
+	}
+	dstP, err := grid.ProjectorFromTMS(dst.TileMatrixSet)
+	if err != nil {
+		return nil, err
+	}
+	lonLat, err := grid.InversePolygon(poly, srcP)
+	if err != nil {
+		return nil, err
+	}
+	var extent *grid.Bounds
+	out := make(orb.Polygon, 0, len(lonLat))
+	for _, ring := range lonLat {
+		r := make(orb.Ring, 0, len(ring))
+		for _, pt := range ring {
+			x, y, err := dstP.Forward(pt[0], pt[1])
+			if err != nil || !finite(x, y) {
+				if extent == nil {
+					b, err := dst.lonLatExtent(dstP)
+					if err != nil {
+						return nil, err
+					}
+					extent = &b
+				}
+				lon := math.Max(extent.MinX, math.Min(extent.MaxX, pt[0]))
+				lat := math.Max(extent.MinY, math.Min(extent.MaxY, pt[1]))
+				if x, y, err = dstP.Forward(lon, lat); err != nil {
+					return nil, err
+				}
+			}
+			r = append(r, orb.Point{x, y})
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func finite(vals ...float64) bool {
+	for _, v := range vals {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// ClosestZoom returns the zoom level of dst whose resolution matches that of
+// the tile's zoom level in src most closely by ratio. Resolutions are
+// compared on the ground at the center of the tile, and the result is clamped
+// to the zoom levels of dst.
+func ClosestZoom(src *TileMatrixSet, tile grid.Tile, dst *TileMatrixSet) (int, error) {
+	srcAdapter, err := src.eastingNorthingMatrix(tile)
+	if err != nil {
+		return 0, err
+	}
+	b, err := srcAdapter.BoundsForTile(tile.TileIndex)
+	if err != nil {
+		return 0, err
+	}
+	srcP, err := grid.ProjectorFromTMS(src.TileMatrixSet)
+	if err != nil {
+		return 0, err
+	}
+	lon, lat, err := srcP.Inverse((b.MinX+b.MaxX)/2, (b.MinY+b.MaxY)/2)
+	if err != nil {
+		return 0, err
+	}
+	srcCRS, err := grid.ExtractCRS(src.TileMatrixSet)
+	if err != nil {
+		return 0, err
+	}
+	res, err := dst.convertResolution(srcAdapter.Resolution(), ResolutionOptions{CRS: srcCRS, Lon: lon, Lat: lat})
+	if err != nil {
+		return 0, err
+	}
+	z, err := dst.ZoomForNativeResolution(res, ResolutionOptions{Strategy: ZoomAuto})
+	if errors.Is(err, ErrResolutionOutOfRange) {
+		if coarsest, _ := dst.ResolutionForZoom(0); res > coarsest {
+			return 0, nil
+		}
+		return dst.MaxZoom(), nil
+	}
+	return z, err
+}
@@ -0,0 +1,95 @@
+package gocantile
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func tileKeys(tiles grid.TilesList) [][3]int {
+	out := make([][3]int, len(tiles))
+	for i, tile := range tiles {
+		out[i] = [3]int{tile.Zoom, tile.Col, tile.Row}
+	}
+	return out
+}
+
+func TestTilesIntersecting(t *testing.T) {
+	mercator := loadWebMercatorQuad(t)
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		src      *TileMatrixSet
+		tile     Tile
+		dst      *TileMatrixSet
+		dstZoom  int
+		expected [][3]int
+	}{
+		// North-west quadrant; the tiles south of the equator only touch it.
+		{"mercator to crs84", mercator, Tile{Zoom: 1}, crs84, 1, [][3]int{{1, 0, 0}, {1, 1, 0}}},
+		// The polar part beyond 85.05°N is clamped to the Mercator extent.
+		{"crs84 to mercator", crs84, Tile{Zoom: 1}, mercator, 2, [][3]int{{2, 0, 0}, {2, 0, 1}}},
+		{"same set", mercator, Tile{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 1}}, mercator, 2,
+			[][3]int{{2, 2, 2}, {2, 3, 2}, {2, 2, 3}, {2, 3, 3}}},
+		// GNOSISGlobalGrid is Lat,Lon and coalesces row 0 of zoom 1 by 2.
+		{"crs84 to gnosis", crs84, Tile{Zoom: 1}, gnosis, 1, [][3]int{{1, 0, 0}, {1, 0, 1}, {1, 1, 1}}},
+	}
+	for _, c := range cases {
+		got, err := TilesIntersecting(c.src, c.tile, c.dst, c.dstZoom)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if keys := tileKeys(got); !reflect.DeepEqual(keys, c.expected) {
+			t.Fatalf("%s: got %v, want %v", c.name, keys, c.expected)
+		}
+	}
+
+	if _, err := TilesIntersecting(mercator, Tile{Zoom: 1, TileIndex: TileIndex{Col: 2}}, crs84, 1); err == nil {
+		t.Fatalf("expected error for tile outside matrix")
+	}
+	if _, err := TilesIntersecting(mercator, Tile{Zoom: 1}, crs84, 99); err == nil {
+		t.Fatalf("expected error for zoom out of range")
+	}
+}
+
+func TestClosestZoom(t *testing.T) {
+	mercator := loadWebMercatorQuad(t)
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// Both scale by cos(lat) along x, so zoom z of WebMercatorQuad matches
+	// zoom z-1 of WorldCRS84Quad at any latitude.
+	for _, tile := range []Tile{
+		{Zoom: 5, TileIndex: TileIndex{Col: 16, Row: 15}},
+		{Zoom: 5, TileIndex: TileIndex{Col: 3, Row: 2}},
+	} {
+		z, err := ClosestZoom(mercator, tile, crs84)
+		if err != nil {
+			t.Fatalf("closest zoom: %v", err)
+		}
+		if z != 4 {
+			t.Fatalf("tile %v: got zoom %d, want 4", tile, z)
+		}
+	}
+	z, err := ClosestZoom(crs84, Tile{Zoom: 4, TileIndex: TileIndex{Col: 10, Row: 3}}, mercator)
+	if err != nil || z != 5 {
+		t.Fatalf("got zoom %d (%v), want 5", z, err)
+	}
+
+	// Beyond the finest level of the destination the zoom is clamped.
+	finest := mercator.MaxZoom()
+	z, err = ClosestZoom(mercator, Tile{Zoom: finest}, crs84)
+	if err != nil || z != crs84.MaxZoom() {
+		t.Fatalf("got zoom %d (%v), want %d", z, err, crs84.MaxZoom())
+	}
+}
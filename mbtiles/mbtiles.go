@@ -0,0 +1,315 @@
+// Package mbtiles reads and writes MBTiles 1.3 files, addressing tiles by
+// TileMatrixSet tile. MBTiles stores rows TMS-style, counted from the bottom;
+// the conversion to the set's row numbering is done with TileToTMS and
+// TileFromTMS.
+//
+// MBTiles is defined for WebMercatorQuad only. Writers refuse other sets
+// unless Options.AllowNonStandard is set, in which case the set id is stored
+// in the "tilematrixset" metadata row.
+//
+// The SQLite driver is modernc.org/sqlite, which does not need cgo.
+package mbtiles
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+	_ "modernc.org/sqlite"
+)
+
+var (
+	// ErrNonStandard is returned by Create for TileMatrixSets other than
+	// WebMercatorQuad when Options.AllowNonStandard is not set.
+	ErrNonStandard = errors.New("mbtiles: only WebMercatorQuad is standard")
+	// ErrTileNotFound is returned by Reader.Tile for missing tiles.
+	ErrTileNotFound = errors.New("mbtiles: tile not found")
+)
+
+const schema = `
+CREATE TABLE metadata (name TEXT NOT NULL, value TEXT);
+CREATE UNIQUE INDEX metadata_name ON metadata (name);
+CREATE TABLE tiles (zoom_level INTEGER NOT NULL, tile_column INTEGER NOT NULL, tile_row INTEGER NOT NULL, tile_data BLOB);
+CREATE UNIQUE INDEX tile_index ON tiles (zoom_level, tile_column, tile_row);
+`
+
+func setID(set *gocantile.TileMatrixSet) string {
+	if set.Id == nil {
+		return ""
+	}
+	return *set.Id
+}
+
+// Options configures a Writer.
+type Options struct {
+	// Metadata is written on Close. MinZoom and MaxZoom are taken from the
+	// written tiles, and so are Bounds and Center unless they are set; none
+	// of them is derived from the extent of the set. Fields left at their
+	// zero value, as when no tiles were written, are not written.
+	Metadata Metadata
+	// AllowNonStandard allows TileMatrixSets other than WebMercatorQuad. The
+	// set id is recorded in the "tilematrixset" metadata row.
+	AllowNonStandard bool
+}
+
+// Writer writes tiles to a new MBTiles file. All writes happen in a single
+// transaction that is committed on Close.
+type Writer struct {
+	db     *sql.DB
+	tx     *sql.Tx
+	insert *sql.Stmt
+	set    *gocantile.TileMatrixSet
+	opts   Options
+	ranges map[int]grid.TileRange
+}
+
+// Create creates the MBTiles file at path. The file must not exist.
+func Create(path string, set *gocantile.TileMatrixSet, opts Options) (*Writer, error) {
+	standard := set.IsWebMercatorQuad()
+	if !standard && !opts.AllowNonStandard {
+		return nil, fmt.Errorf("%w: %s", ErrNonStandard, setID(set))
+	}
+	if !standard {
+		if setID(set) == "" {
+			return nil, fmt.Errorf("mbtiles: non-standard tile matrix set needs an id")
+		}
+		opts.Metadata.TileMatrixSet = setID(set)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("mbtiles: %s already exists", path)
+	}
+	db, err := sql.Open("sqlite", fileDSN(path, ""))
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{db: db, set: set, opts: opts, ranges: map[int]grid.TileRange{}}
+	if err := w.init(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) init() error {
+	if _, err := w.db.Exec(schema); err != nil {
+		return fmt.Errorf("mbtiles: create schema: %w", err)
+	}
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	insert, err := tx.Prepare("INSERT OR REPLACE INTO tiles (zoom_level, tile_column, tile_row, tile_data) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	w.tx, w.insert = tx, insert
+	return nil
+}
+
+// WriteTile stores the data of a tile, replacing any previous data.
+func (w *Writer) WriteTile(tile grid.Tile, data []byte) error {
+	x, y, z, err := w.set.TileToTMS(tile)
+	if err != nil {
+		return err
+	}
+	if _, err := w.insert.Exec(z, x, y, data); err != nil {
+		return fmt.Errorf("mbtiles: write tile %d/%d/%d: %w", z, tile.Col, tile.Row, err)
+	}
+	r, ok := w.ranges[z]
+	if !ok {
+		r = grid.TileRange{MinCol: tile.Col, MaxCol: tile.Col, MinRow: tile.Row, MaxRow: tile.Row}
+	}
+	r.MinCol, r.MaxCol = min(r.MinCol, tile.Col), max(r.MaxCol, tile.Col)
+	r.MinRow, r.MaxRow = min(r.MinRow, tile.Row), max(r.MaxRow, tile.Row)
+	w.ranges[z] = r
+	return nil
+}
+
+// Close writes the metadata, commits the tiles and closes the file.
+func (w *Writer) Close() error {
+	err := w.finish()
+	if err != nil {
+		w.tx.Rollback()
+	}
+	if cerr := w.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (w *Writer) finish() error {
+	md, err := w.metadata()
+	if err != nil {
+		return err
+	}
+	stmt, err := w.tx.Prepare("INSERT OR REPLACE INTO metadata (name, value) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for name, value := range md.rows() {
+		if _, err := stmt.Exec(name, value); err != nil {
+			return fmt.Errorf("mbtiles: write metadata %s: %w", name, err)
+		}
+	}
+	if err := w.insert.Close(); err != nil {
+		return err
+	}
+	return w.tx.Commit()
+}
+
+// metadata fills the zoom range, and the bounds and center unless set, from
+// the written tiles.
+func (w *Writer) metadata() (Metadata, error) {
+	md := w.opts.Metadata
+	if len(w.ranges) == 0 {
+		return md, nil
+	}
+	md.MinZoom, md.MaxZoom = math.MaxInt, math.MinInt
+	for z := range w.ranges {
+		md.MinZoom, md.MaxZoom = min(md.MinZoom, z), max(md.MaxZoom, z)
+	}
+	if md.Bounds == (grid.Bounds{}) {
+		b, err := w.set.TileRangesBounds(w.ranges, nil)
+		if err != nil {
+			return Metadata{}, err
+		}
+		md.Bounds = b
+	}
+	if md.Center == ([3]float64{}) {
+		md.Center = [3]float64{
+			(md.Bounds.MinX + md.Bounds.MaxX) / 2,
+			(md.Bounds.MinY + md.Bounds.MaxY) / 2,
+			float64(md.MinZoom),
+		}
+	}
+	return md, nil
+}
+
+// fileDSN returns the SQLite URI of the file at path with the query, so that
+// characters such as '?' and '#' in the path are not taken as its delimiters.
+func fileDSN(path, query string) string {
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath()
+	if query != "" {
+		dsn += "?" + query
+	}
+	return dsn
+}
+
+// Reader reads tiles from an MBTiles file.
+type Reader struct {
+	db  *sql.DB
+	set *gocantile.TileMatrixSet
+}
+
+// Open opens the MBTiles file at path for reading. If set is nil, the set
+// named by the "tilematrixset" metadata row is loaded, or WebMercatorQuad for
+// standard files. A set that does not match the metadata is an error.
+func Open(path string, set *gocantile.TileMatrixSet) (*Reader, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", fileDSN(path, "mode=ro"))
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{db: db}
+	if err := r.resolveSet(set); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reader) resolveSet(set *gocantile.TileMatrixSet) error {
+	var id string
+	err := r.db.QueryRow("SELECT value FROM metadata WHERE name = ?", metadataTileMatrixSet).Scan(&id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("mbtiles: read metadata: %w", err)
+	}
+	switch {
+	case set == nil && id == "":
+		set, err = gocantile.LoadTileMatrixSet("WebMercatorQuad")
+	case set == nil:
+		set, err = gocantile.LoadTileMatrixSet(id)
+	case id == "" && !set.IsWebMercatorQuad():
+		err = fmt.Errorf("%w: file is WebMercatorQuad, got %s", ErrNonStandard, setID(set))
+	case id != "" && id != setID(set):
+		err = fmt.Errorf("mbtiles: file uses tile matrix set %s, got %s", id, setID(set))
+	}
+	if err != nil {
+		return err
+	}
+	r.set = set
+	return nil
+}
+
+// TileMatrixSet returns the set used to address the tiles.
+func (r *Reader) TileMatrixSet() *gocantile.TileMatrixSet { return r.set }
+
+// Tile returns the data of a tile, or ErrTileNotFound.
+func (r *Reader) Tile(tile grid.Tile) ([]byte, error) {
+	x, y, z, err := r.set.TileToTMS(tile)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	err = r.db.QueryRow("SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?", z, x, y).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d/%d/%d", ErrTileNotFound, tile.Zoom, tile.Col, tile.Row)
+	}
+	return data, err
+}
+
+// Tiles lists all stored tiles ordered by zoom, TMS column and TMS row.
+func (r *Reader) Tiles() (grid.TilesList, error) {
+	rows, err := r.db.Query("SELECT zoom_level, tile_column, tile_row FROM tiles ORDER BY zoom_level, tile_column, tile_row")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tiles grid.TilesList
+	for rows.Next() {
+		var z, x, y int
+		if err := rows.Scan(&z, &x, &y); err != nil {
+			return nil, err
+		}
+		tile, err := r.set.TileFromTMS(x, y, z)
+		if err != nil {
+			return nil, err
+		}
+		tiles = append(tiles, tile)
+	}
+	return tiles, rows.Err()
+}
+
+// Metadata returns the parsed metadata table.
+func (r *Reader) Metadata() (Metadata, error) {
+	rows, err := r.db.Query("SELECT name, value FROM metadata")
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer rows.Close()
+	values := map[string]string{}
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			return Metadata{}, err
+		}
+		values[name] = value.String
+	}
+	if err := rows.Err(); err != nil {
+		return Metadata{}, err
+	}
+	return parseMetadata(values)
+}
+
+// Close closes the file.
+func (r *Reader) Close() error { return r.db.Close() }
@@ -0,0 +1,211 @@
+package mbtiles
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func TestWriteReadWebMercatorQuad(t *testing.T) {
+	set, err := gocantile.LoadTileMatrixSet("WebMercatorQuad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "test.mbtiles")
+	w, err := Create(path, set, Options{Metadata: Metadata{Name: "test", Format: "png"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// Zoom 1: the top-left tile and the bottom-right tile.
+	tiles := grid.TilesList{
+		{Zoom: 1, TileIndex: grid.TileIndex{Col: 0, Row: 0}},
+		{Zoom: 2, TileIndex: grid.TileIndex{Col: 3, Row: 3}},
+	}
+	for i, tile := range tiles {
+		if err := w.WriteTile(tile, []byte{byte(i)}); err != nil {
+			t.Fatalf("write %+v: %v", tile, err)
+		}
+	}
+	if err := w.WriteTile(grid.Tile{Zoom: 1, TileIndex: grid.TileIndex{Col: 2}}, nil); err == nil {
+		t.Fatalf("expected error for tile outside matrix")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// The top-left tile at zoom 1 is stored with TMS row 1.
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	var row int
+	if err := db.QueryRow("SELECT tile_row FROM tiles WHERE zoom_level = 1 AND tile_column = 0").Scan(&row); err != nil || row != 1 {
+		t.Fatalf("expected tile_row 1, got %d err=%v", row, err)
+	}
+	db.Close()
+
+	r, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	for i, tile := range tiles {
+		data, err := r.Tile(tile)
+		if err != nil || !bytes.Equal(data, []byte{byte(i)}) {
+			t.Fatalf("tile %+v: data=%v err=%v", tile, data, err)
+		}
+	}
+	if _, err := r.Tile(grid.Tile{Zoom: 1, TileIndex: grid.TileIndex{Col: 1, Row: 1}}); !errors.Is(err, ErrTileNotFound) {
+		t.Fatalf("expected ErrTileNotFound, got %v", err)
+	}
+	got, err := r.Tiles()
+	if err != nil || len(got) != 2 || got[0] != tiles[0] || got[1] != tiles[1] {
+		t.Fatalf("unexpected tiles %+v err=%v", got, err)
+	}
+
+	md, err := r.Metadata()
+	if err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if md.Name != "test" || md.Format != "png" || md.MinZoom != 1 || md.MaxZoom != 2 || md.TileMatrixSet != "" {
+		t.Fatalf("unexpected metadata %+v", md)
+	}
+	// The two tiles span the whole world: west/north from zoom 1 and
+	// east/south from zoom 2.
+	want := grid.Bounds{MinX: -180, MinY: -85.0511287798, MaxX: 180, MaxY: 85.0511287798}
+	if !boundsClose(md.Bounds, want, 1e-6) {
+		t.Fatalf("unexpected bounds %+v", md.Bounds)
+	}
+	if math.Abs(md.Center[0]) > 1e-6 || math.Abs(md.Center[1]) > 1e-6 || md.Center[2] != 1 {
+		t.Fatalf("unexpected center %v", md.Center)
+	}
+}
+
+func TestCreateNonStandard(t *testing.T) {
+	set, err := gocantile.LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	dir := t.TempDir()
+	if _, err := Create(filepath.Join(dir, "refused.mbtiles"), set, Options{}); !errors.Is(err, ErrNonStandard) {
+		t.Fatalf("expected ErrNonStandard, got %v", err)
+	}
+
+	path := filepath.Join(dir, "crs84.mbtiles")
+	w, err := Create(path, set, Options{AllowNonStandard: true, Metadata: Metadata{Name: "crs84", Format: "png"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// Zoom 0 is 2x1: the eastern tile.
+	tile := grid.Tile{Zoom: 0, TileIndex: grid.TileIndex{Col: 1, Row: 0}}
+	if err := w.WriteTile(tile, []byte("east")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, err := Create(path, set, Options{AllowNonStandard: true}); err == nil {
+		t.Fatalf("expected error for existing file")
+	}
+
+	webMercator, err := gocantile.LoadTileMatrixSet("WebMercatorQuad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	if _, err := Open(path, webMercator); err == nil {
+		t.Fatalf("expected error for mismatching tile matrix set")
+	}
+	r, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	if setID(r.TileMatrixSet()) != "WorldCRS84Quad" {
+		t.Fatalf("expected set from metadata, got %s", setID(r.TileMatrixSet()))
+	}
+	data, err := r.Tile(tile)
+	if err != nil || string(data) != "east" {
+		t.Fatalf("unexpected tile data %q err=%v", data, err)
+	}
+	md, err := r.Metadata()
+	if err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if md.TileMatrixSet != "WorldCRS84Quad" || !boundsClose(md.Bounds, grid.Bounds{MinX: 0, MinY: -90, MaxX: 180, MaxY: 90}, 1e-9) {
+		t.Fatalf("unexpected metadata %+v", md)
+	}
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	md := Metadata{
+		Name:    "roads",
+		Format:  "pbf",
+		Bounds:  grid.Bounds{MinX: 5.5, MinY: 47.2, MaxX: 15.1, MaxY: 55.1},
+		Center:  [3]float64{10.3, 51.15, 6},
+		MinZoom: 0,
+		MaxZoom: 14,
+		JSON:    `{"vector_layers":[]}`,
+		Extra:   map[string]string{"generator": "test"},
+	}
+	got, err := parseMetadata(md.rows())
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got.Name != md.Name || got.Format != md.Format || got.Bounds != md.Bounds || got.Center != md.Center ||
+		got.MaxZoom != md.MaxZoom || got.JSON != md.JSON || got.Extra["generator"] != "test" {
+		t.Fatalf("unexpected metadata %+v", got)
+	}
+	if _, err := parseMetadata(map[string]string{"bounds": "1,2,3"}); err == nil {
+		t.Fatalf("expected error for malformed bounds")
+	}
+}
+
+func boundsClose(a, b grid.Bounds, tol float64) bool {
+	return math.Abs(a.MinX-b.MinX) <= tol && math.Abs(a.MinY-b.MinY) <= tol &&
+		math.Abs(a.MaxX-b.MaxX) <= tol && math.Abs(a.MaxY-b.MaxY) <= tol
+}
+
+func TestEmptyFileWithURICharacters(t *testing.T) {
+	set, err := gocantile.LoadTileMatrixSet("WebMercatorQuad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// '?' and '#' would end the path of an unescaped SQLite URI.
+	path := filepath.Join(t.TempDir(), "a?b#c d%.mbtiles")
+	w, err := Create(path, set, Options{Metadata: Metadata{Name: "empty"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	r, err := Open(path, nil)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+
+	// Without tiles there are no bounds, center or zoom range to write.
+	rows, err := r.db.Query("SELECT name FROM metadata ORDER BY name")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		names = append(names, name)
+	}
+	if len(names) != 1 || names[0] != "name" {
+		t.Fatalf("metadata rows = %v, want [name]", names)
+	}
+}
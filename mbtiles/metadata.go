@@ -0,0 +1,146 @@
+package mbtiles
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// Metadata holds the rows of the MBTiles metadata table.
+type Metadata struct {
+	Name   string
+	Format string
+	// Bounds is the extent of the tiles in lon/lat (degrees).
+	Bounds grid.Bounds
+	// Center is lon, lat and zoom of the default view.
+	Center  [3]float64
+	MinZoom int
+	MaxZoom int
+
+	Attribution string
+	Description string
+	Type        string
+	Version     string
+	// JSON holds the vector_layers document for vector tiles.
+	JSON string
+
+	// TileMatrixSet is the identifier of a non-WebMercatorQuad tiling scheme.
+	// It is empty for standard MBTiles files.
+	TileMatrixSet string
+	// Extra holds any other metadata rows.
+	Extra map[string]string
+}
+
+const metadataTileMatrixSet = "tilematrixset"
+
+// rows returns the metadata table rows. Empty strings and zero bounds,
+// center and zoom range are unset and left out.
+func (m Metadata) rows() map[string]string {
+	rows := make(map[string]string, len(m.Extra)+12)
+	for k, v := range m.Extra {
+		rows[k] = v
+	}
+	set := func(k, v string) {
+		if v != "" {
+			rows[k] = v
+		}
+	}
+	set("name", m.Name)
+	set("format", m.Format)
+	if m.Bounds != (grid.Bounds{}) {
+		set("bounds", formatFloats(m.Bounds.MinX, m.Bounds.MinY, m.Bounds.MaxX, m.Bounds.MaxY))
+	}
+	if m.Center != ([3]float64{}) {
+		set("center", formatFloats(m.Center[0], m.Center[1])+","+strconv.Itoa(int(m.Center[2])))
+	}
+	// A zoom range of 0-0 is only meaningful for tiles that have bounds.
+	if m.MaxZoom != 0 || m.Bounds != (grid.Bounds{}) {
+		set("minzoom", strconv.Itoa(m.MinZoom))
+		set("maxzoom", strconv.Itoa(m.MaxZoom))
+	}
+	set("attribution", m.Attribution)
+	set("description", m.Description)
+	set("type", m.Type)
+	set("version", m.Version)
+	set("json", m.JSON)
+	set(metadataTileMatrixSet, m.TileMatrixSet)
+	return rows
+}
+
+func parseMetadata(rows map[string]string) (Metadata, error) {
+	m := Metadata{Extra: map[string]string{}}
+	keys := make([]string, 0, len(rows))
+	for k := range rows {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := rows[k]
+		var err error
+		switch k {
+		case "name":
+			m.Name = v
+		case "format":
+			m.Format = v
+		case "bounds":
+			var f []float64
+			if f, err = parseFloats(v, 4); err == nil {
+				m.Bounds = grid.Bounds{MinX: f[0], MinY: f[1], MaxX: f[2], MaxY: f[3]}
+			}
+		case "center":
+			var f []float64
+			if f, err = parseFloats(v, 3); err == nil {
+				m.Center = [3]float64{f[0], f[1], f[2]}
+			}
+		case "minzoom":
+			m.MinZoom, err = strconv.Atoi(strings.TrimSpace(v))
+		case "maxzoom":
+			m.MaxZoom, err = strconv.Atoi(strings.TrimSpace(v))
+		case "attribution":
+			m.Attribution = v
+		case "description":
+			m.Description = v
+		case "type":
+			m.Type = v
+		case "version":
+			m.Version = v
+		case "json":
+			m.JSON = v
+		case metadataTileMatrixSet:
+			m.TileMatrixSet = v
+		default:
+			m.Extra[k] = v
+		}
+		if err != nil {
+			return Metadata{}, fmt.Errorf("metadata %s: %w", k, err)
+		}
+	}
+	return m, nil
+}
+
+func formatFloats(vals ...float64) string {
+	parts := make([]string, len(vals))
+	for i, v := range vals {
+		parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d values, got %q", n, s)
+	}
+	out := make([]float64, n)
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = f
+	}
+	return out, nil
+}
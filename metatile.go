@@ -0,0 +1,167 @@
+package gocantile
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// Metatile is a block of Width x Height matrix cells at one zoom level that is
+// rendered at once and cut into tiles. Col and Row index the block: it covers
+// matrix columns [Col*Width, (Col+1)*Width) and rows [Row*Height,
+// (Row+1)*Height), counted like the set's tile rows.
+//
+// Columns are matrix columns before coalescing. A coalesced tile belongs to the
+// metatile of its first matrix column, so with widths that are not a multiple
+// of the coalescence factor a metatile may extend past its nominal columns.
+type Metatile struct {
+	Zoom   int
+	Col    int
+	Row    int
+	Width  int
+	Height int
+}
+
+// MetatileGroup is a metatile with the requested tiles that fall into it.
+type MetatileGroup struct {
+	Metatile Metatile
+	Tiles    grid.TilesList
+}
+
+// MetatileForTile returns the width x height metatile containing the tile.
+func (t *TileMatrixSet) MetatileForTile(tile grid.Tile, width, height int) (Metatile, error) {
+	if width < 1 || height < 1 {
+		return Metatile{}, fmt.Errorf("invalid metatile size %dx%d", width, height)
+	}
+	adapter, err := t.validTileMatrix(tile)
+	if err != nil {
+		return Metatile{}, err
+	}
+	matrixCol := tile.Col * adapter.Coalesce(tile.Row)
+	return Metatile{
+		Zoom:   tile.Zoom,
+		Col:    matrixCol / width,
+		Row:    tile.Row / height,
+		Width:  width,
+		Height: height,
+	}, nil
+}
+
+// MetatileTiles lists the tiles of the metatile row by row. The metatile is
+// clipped at the matrix edges and at the reduced width of coalesced rows.
+func (t *TileMatrixSet) MetatileTiles(m Metatile) (grid.TilesList, error) {
+	if m.Width < 1 || m.Height < 1 || m.Col < 0 || m.Row < 0 {
+		return nil, fmt.Errorf("invalid metatile %+v", m)
+	}
+	adapter, err := t.tileMatrix(m.Zoom)
+	if err != nil {
+		return nil, err
+	}
+	_, matrixHeight := adapter.MatrixSize()
+	var tiles grid.TilesList
+	for row := m.Row * m.Height; row < min((m.Row+1)*m.Height, matrixHeight); row++ {
+		k := adapter.Coalesce(row)
+		// Tiles whose first matrix column lies in the metatile.
+		first := ceilDiv(m.Col*m.Width, k)
+		last := min(ceilDiv((m.Col+1)*m.Width, k), adapter.RowWidth(row))
+		for col := first; col < last; col++ {
+			tiles = append(tiles, grid.Tile{Zoom: m.Zoom, TileIndex: grid.TileIndex{Col: col, Row: row}})
+		}
+	}
+	if len(tiles) == 0 {
+		return nil, fmt.Errorf("metatile %d/%d/%d outside matrix", m.Zoom, m.Col, m.Row)
+	}
+	return tiles, nil
+}
+
+// MetatileXYBounds returns the bounds in the matrix CRS of the tiles of the
+// metatile, grown by bufferPixels cells on each side. The buffer is not
+// clipped to the matrix extent.
+func (t *TileMatrixSet) MetatileXYBounds(m Metatile, bufferPixels int) (grid.Bounds, error) {
+	tiles, err := t.MetatileTiles(m)
+	if err != nil {
+		return grid.Bounds{}, err
+	}
+	adapter, err := t.tileMatrix(m.Zoom)
+	if err != nil {
+		return grid.Bounds{}, err
+	}
+	b := grid.Bounds{MinX: math.Inf(1), MinY: math.Inf(1), MaxX: math.Inf(-1), MaxY: math.Inf(-1)}
+	for _, tile := range tiles {
+		tb, err := adapter.BoundsForTile(tile.TileIndex)
+		if err != nil {
+			return grid.Bounds{}, err
+		}
+		b.MinX, b.MinY = math.Min(b.MinX, tb.MinX), math.Min(b.MinY, tb.MinY)
+		b.MaxX, b.MaxY = math.Max(b.MaxX, tb.MaxX), math.Max(b.MaxY, tb.MaxY)
+	}
+	buffer := float64(bufferPixels) * adapter.Resolution()
+	b.MinX -= buffer
+	b.MinY -= buffer
+	b.MaxX += buffer
+	b.MaxY += buffer
+	return b, nil
+}
+
+// MetatilesForBounds returns the width x height metatiles at zoom that
+// intersect the bounds in the matrix CRS, row by row.
+func (t *TileMatrixSet) MetatilesForBounds(zoom int, b grid.Bounds, width, height int) ([]Metatile, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("invalid metatile size %dx%d", width, height)
+	}
+	adapter, err := t.tileMatrix(zoom)
+	if err != nil {
+		return nil, err
+	}
+	tr, ok := adapter.TileRangeForBounds(b)
+	if !ok {
+		return nil, nil
+	}
+	var metatiles []Metatile
+	for row := tr.MinRow / height; row <= tr.MaxRow/height; row++ {
+		// A coalesced tile belongs to the metatile of its first matrix
+		// column, which may lie outside the range, and metatiles holding no
+		// tile start are left out.
+		cols := map[int]struct{}{}
+		for r := max(tr.MinRow, row*height); r <= min(tr.MaxRow, (row+1)*height-1); r++ {
+			k := adapter.Coalesce(r)
+			first, last := adapter.RowTileRange(tr, r)
+			for c := first; c <= last; c++ {
+				cols[c*k/width] = struct{}{}
+			}
+		}
+		for _, col := range slices.Sorted(maps.Keys(cols)) {
+			metatiles = append(metatiles, Metatile{Zoom: zoom, Col: col, Row: row, Width: width, Height: height})
+		}
+	}
+	return metatiles, nil
+}
+
+// GroupMetatiles groups tiles into width x height metatiles. Groups are
+// ordered by the first tile that falls into them; tiles keep their order
+// within a group.
+func (t *TileMatrixSet) GroupMetatiles(tiles grid.TilesList, width, height int) ([]MetatileGroup, error) {
+	var groups []MetatileGroup
+	index := map[Metatile]int{}
+	for _, tile := range tiles {
+		m, err := t.MetatileForTile(tile, width, height)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index[m]
+		if !ok {
+			i = len(groups)
+			index[m] = i
+			groups = append(groups, MetatileGroup{Metatile: m})
+		}
+		groups[i].Tiles = append(groups[i].Tiles, tile)
+	}
+	return groups, nil
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
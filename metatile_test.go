@@ -0,0 +1,141 @@
+package gocantile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func TestMetatileWebMercatorQuad(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	m, err := set.MetatileForTile(Tile{Zoom: 3, TileIndex: TileIndex{Col: 5, Row: 2}}, 4, 4)
+	if err != nil {
+		t.Fatalf("metatile: %v", err)
+	}
+	if m != (Metatile{Zoom: 3, Col: 1, Row: 0, Width: 4, Height: 4}) {
+		t.Fatalf("unexpected metatile %+v", m)
+	}
+	tiles, err := set.MetatileTiles(m)
+	if err != nil || len(tiles) != 16 || tiles[0] != (Tile{Zoom: 3, TileIndex: TileIndex{Col: 4, Row: 0}}) {
+		t.Fatalf("unexpected tiles %+v err=%v", tiles, err)
+	}
+
+	// The north-east quarter of the world with a 64 pixel buffer.
+	b, err := set.MetatileXYBounds(m, 64)
+	if err != nil {
+		t.Fatalf("bounds: %v", err)
+	}
+	res, _ := set.ResolutionForZoom(3)
+	want := grid.Bounds{MinX: -64 * res, MinY: -64 * res, MaxX: webMercatorHalfExtent + 64*res, MaxY: webMercatorHalfExtent + 64*res}
+	if math.Abs(b.MinX-want.MinX) > 1e-3 || math.Abs(b.MinY-want.MinY) > 1e-3 || math.Abs(b.MaxX-want.MaxX) > 1e-3 || math.Abs(b.MaxY-want.MaxY) > 1e-3 {
+		t.Fatalf("unexpected bounds %+v, want %+v", b, want)
+	}
+
+	// 3x3 metatiles on the 4x4 matrix of zoom 2 are clipped at the edge.
+	tiles, err = set.MetatileTiles(Metatile{Zoom: 2, Col: 1, Row: 1, Width: 3, Height: 3})
+	if err != nil || len(tiles) != 1 || tiles[0] != (Tile{Zoom: 2, TileIndex: TileIndex{Col: 3, Row: 3}}) {
+		t.Fatalf("unexpected clipped tiles %+v err=%v", tiles, err)
+	}
+	if _, err := set.MetatileTiles(Metatile{Zoom: 2, Col: 2, Row: 0, Width: 3, Height: 3}); err == nil {
+		t.Fatalf("expected error for metatile outside matrix")
+	}
+	if _, err := set.MetatileForTile(Tile{Zoom: 1, TileIndex: TileIndex{Col: 2}}, 2, 2); err == nil {
+		t.Fatalf("expected error for tile outside matrix")
+	}
+	if _, err := set.MetatileForTile(Tile{Zoom: 1}, 0, 2); err == nil {
+		t.Fatalf("expected error for invalid size")
+	}
+
+	metatiles, err := set.MetatilesForBounds(3, grid.Bounds{MinX: -1, MinY: -1, MaxX: 1, MaxY: 1}, 4, 4)
+	if err != nil || len(metatiles) != 4 {
+		t.Fatalf("expected 4 metatiles around the origin, got %+v err=%v", metatiles, err)
+	}
+}
+
+func TestMetatileCoalescedRows(t *testing.T) {
+	set, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// Zoom 1 is 8x4 with rows 0 and 3 coalesced by 2.
+	tiles, err := set.MetatileTiles(Metatile{Zoom: 1, Col: 0, Row: 0, Width: 4, Height: 2})
+	if err != nil || len(tiles) != 6 {
+		t.Fatalf("expected 2 coalesced and 4 regular tiles, got %+v err=%v", tiles, err)
+	}
+	// The bounds span the coalesced tile 1 of row 0 and tile 3 of row 1.
+	b, err := set.MetatileXYBounds(Metatile{Zoom: 1, Col: 0, Row: 0, Width: 4, Height: 2}, 0)
+	if err != nil {
+		t.Fatalf("bounds: %v", err)
+	}
+	first, _ := set.XYBounds(Tile{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 0}})
+	coalesced, _ := set.XYBounds(Tile{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 0}})
+	last, _ := set.XYBounds(Tile{Zoom: 1, TileIndex: TileIndex{Col: 3, Row: 1}})
+	if b.MinX != first.MinX || b.MaxX != coalesced.MaxX || b.MaxX != last.MaxX ||
+		b.MinY != math.Min(first.MinY, last.MinY) || b.MaxY != math.Max(first.MaxY, last.MaxY) {
+		t.Fatalf("unexpected bounds %+v", b)
+	}
+
+	// Every tile is listed by exactly the metatile it maps to.
+	for z := 0; z <= 2; z++ {
+		tm, err := set.TileMatrixForZoom(z)
+		if err != nil {
+			t.Fatalf("matrix: %v", err)
+		}
+		adapter := grid.TileMatrix{TM: tm}
+		_, height := adapter.MatrixSize()
+		for _, size := range [][2]int{{1, 1}, {3, 1}, {2, 2}, {3, 3}} {
+			var all TilesList
+			for row := 0; row < height; row++ {
+				for col := 0; col < adapter.RowWidth(row); col++ {
+					all = append(all, Tile{Zoom: z, TileIndex: TileIndex{Col: col, Row: row}})
+				}
+			}
+			groups, err := set.GroupMetatiles(all, size[0], size[1])
+			if err != nil {
+				t.Fatalf("group: %v", err)
+			}
+			count := 0
+			for _, g := range groups {
+				listed, err := set.MetatileTiles(g.Metatile)
+				if err != nil {
+					t.Fatalf("metatile tiles %+v: %v", g.Metatile, err)
+				}
+				if len(listed) != len(g.Tiles) {
+					t.Fatalf("zoom %d size %v: metatile %+v lists %v, grouped %v", z, size, g.Metatile, listed, g.Tiles)
+				}
+				count += len(g.Tiles)
+			}
+			if count != len(all) {
+				t.Fatalf("grouped %d of %d tiles", count, len(all))
+			}
+		}
+	}
+	// Metatiles over the whole extent each hold tiles, and together every
+	// tile once, even where a coalesced row has fewer tiles than metatiles.
+	metatiles, err := set.MetatilesForBounds(2, grid.Bounds{MinX: -1000, MinY: -1000, MaxX: 1000, MaxY: 1000}, 2, 1)
+	if err != nil {
+		t.Fatalf("metatiles: %v", err)
+	}
+	var listed TilesList
+	for _, m := range metatiles {
+		tiles, err := set.MetatileTiles(m)
+		if err != nil {
+			t.Fatalf("metatile tiles %+v: %v", m, err)
+		}
+		listed = append(listed, tiles...)
+	}
+	tm, err := set.TileMatrixForZoom(2)
+	if err != nil {
+		t.Fatalf("matrix: %v", err)
+	}
+	adapter := grid.TileMatrix{TM: tm}
+	_, height := adapter.MatrixSize()
+	want := 0
+	for row := 0; row < height; row++ {
+		want += adapter.RowWidth(row)
+	}
+	if len(listed) != want || len(listed.Dedup()) != want {
+		t.Fatalf("metatiles list %d tiles, want %d", len(listed), want)
+	}
+}
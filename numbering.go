@@ -0,0 +1,70 @@
+package gocantile
+
+import (
+	"fmt"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// The TileMatrixSet numbers tile rows from its cornerOfOrigin (OGC row/col).
+// XYZ (slippy map) counts y from the top and TMS (OSGeo, MBTiles) counts y
+// from the bottom; both count x from the left.
+
+// TileToXYZ returns the XYZ x, y and z of the tile.
+func (t *TileMatrixSet) TileToXYZ(tile grid.Tile) (int, int, int, error) {
+	adapter, err := t.validTileMatrix(tile)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	x, y := adapter.XYZ(tile.TileIndex)
+	return x, y, tile.Zoom, nil
+}
+
+// TileFromXYZ returns the tile for XYZ x, y and z.
+func (t *TileMatrixSet) TileFromXYZ(x, y, z int) (grid.Tile, error) {
+	adapter, err := t.tileMatrix(z)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	tile := grid.Tile{Zoom: z, TileIndex: adapter.FromXYZ(x, y)}
+	if _, err := t.validTileMatrix(tile); err != nil {
+		return grid.Tile{}, fmt.Errorf("xyz %d/%d/%d: %w", z, x, y, err)
+	}
+	return tile, nil
+}
+
+// TileToTMS returns the TMS x, y and z of the tile.
+func (t *TileMatrixSet) TileToTMS(tile grid.Tile) (int, int, int, error) {
+	adapter, err := t.validTileMatrix(tile)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	x, y := adapter.TMS(tile.TileIndex)
+	return x, y, tile.Zoom, nil
+}
+
+// TileFromTMS returns the tile for TMS x, y and z.
+func (t *TileMatrixSet) TileFromTMS(x, y, z int) (grid.Tile, error) {
+	adapter, err := t.tileMatrix(z)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	tile := grid.Tile{Zoom: z, TileIndex: adapter.FromTMS(x, y)}
+	if _, err := t.validTileMatrix(tile); err != nil {
+		return grid.Tile{}, fmt.Errorf("tms %d/%d/%d: %w", z, x, y, err)
+	}
+	return tile, nil
+}
+
+// validTileMatrix returns the matrix of the tile's zoom and checks that the
+// tile exists in it.
+func (t *TileMatrixSet) validTileMatrix(tile grid.Tile) (grid.TileMatrix, error) {
+	adapter, err := t.tileMatrix(tile.Zoom)
+	if err != nil {
+		return grid.TileMatrix{}, err
+	}
+	if !adapter.ContainsTile(tile.TileIndex) {
+		return grid.TileMatrix{}, fmt.Errorf("tile out of range col=%d row=%d", tile.Col, tile.Row)
+	}
+	return adapter, nil
+}
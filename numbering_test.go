@@ -0,0 +1,76 @@
+package gocantile
+
+import (
+	"testing"
+
+	"github.com/hafenkran/gocantile/tms"
+)
+
+func TestTileXYZAndTMSTopLeft(t *testing.T) {
+	set, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	// Zoom 1 is 4x2 tiles.
+	tile := Tile{Zoom: 1, TileIndex: TileIndex{Col: 3, Row: 0}}
+
+	x, y, z, err := set.TileToXYZ(tile)
+	if err != nil || x != 3 || y != 0 || z != 1 {
+		t.Fatalf("unexpected xyz %d/%d/%d err=%v", z, x, y, err)
+	}
+	x, y, z, err = set.TileToTMS(tile)
+	if err != nil || x != 3 || y != 1 || z != 1 {
+		t.Fatalf("unexpected tms %d/%d/%d err=%v", z, x, y, err)
+	}
+	back, err := set.TileFromTMS(3, 1, 1)
+	if err != nil || back != tile {
+		t.Fatalf("unexpected tile from tms %+v err=%v", back, err)
+	}
+	if _, err := set.TileFromTMS(4, 0, 1); err == nil {
+		t.Fatalf("expected error for column outside matrix")
+	}
+	if _, err := set.TileFromXYZ(0, 2, 1); err == nil {
+		t.Fatalf("expected error for row outside matrix")
+	}
+}
+
+func TestTileXYZAndTMSBottomLeft(t *testing.T) {
+	// Same layout as examples/custom: bottom-left origin, coalesced rows 0-1.
+	set := WrapTileMatrixSet(tms.TileMatrixSet{
+		Crs: "EPSG:3857",
+		TileMatrices: []tms.TileMatrix{{
+			Id:             "0",
+			CellSize:       1000,
+			TileWidth:      1,
+			TileHeight:     1,
+			MatrixWidth:    4,
+			MatrixHeight:   4,
+			PointOfOrigin:  []float64{0, 0},
+			CornerOfOrigin: tms.TileMatrixJsonCornerOfOriginBottomLeft,
+			VariableMatrixWidths: []tms.VariableMatrixWidthJson{
+				{Coalesce: 2, MinTileRow: 0, MaxTileRow: 1},
+			},
+		}},
+	})
+	tile := Tile{Zoom: 0, TileIndex: TileIndex{Col: 1, Row: 0}}
+
+	x, y, _, err := set.TileToTMS(tile)
+	if err != nil || x != 1 || y != 0 {
+		t.Fatalf("unexpected tms %d/%d err=%v", x, y, err)
+	}
+	x, y, _, err = set.TileToXYZ(tile)
+	if err != nil || x != 1 || y != 3 {
+		t.Fatalf("unexpected xyz %d/%d err=%v", x, y, err)
+	}
+	back, err := set.TileFromXYZ(1, 3, 0)
+	if err != nil || back != tile {
+		t.Fatalf("unexpected tile from xyz %+v err=%v", back, err)
+	}
+	// XYZ row 3 is the coalesced bottom row with only two tiles.
+	if _, err := set.TileFromXYZ(2, 3, 0); err == nil {
+		t.Fatalf("expected error for column beyond coalesced row width")
+	}
+	if _, _, _, err := set.TileToXYZ(Tile{Zoom: 0, TileIndex: TileIndex{Col: 3, Row: 1}}); err == nil {
+		t.Fatalf("expected error for tile beyond coalesced row width")
+	}
+}
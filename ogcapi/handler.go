@@ -0,0 +1,292 @@
+// Package ogcapi serves TileMatrixSet resources as defined by OGC API - Tiles:
+// the list of tiling schemes, individual TileMatrixSet definitions and the
+// matching conformance declaration.
+package ogcapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// Conformance lists the conformance classes declared at /conformance.
+var Conformance = []string{
+	"http://www.opengis.net/spec/ogcapi-common-1/1.0/conf/core",
+	"http://www.opengis.net/spec/ogcapi-common-1/1.0/conf/json",
+	"http://www.opengis.net/spec/tms/2.0/conf/tilematrixset",
+	"http://www.opengis.net/spec/tms/2.0/conf/json-tilematrixset",
+}
+
+// RelTilingScheme is the link relation pointing to a TileMatrixSet definition.
+const RelTilingScheme = "http://www.opengis.net/def/rel/ogc/1.0/tiling-scheme"
+
+const mediaTypeJSON = "application/json"
+
+// ErrNotFound is returned by a Registry for unknown TileMatrixSet identifiers.
+var ErrNotFound = errors.New("tilematrixset not found")
+
+// Registry provides the TileMatrixSets served by a Handler.
+type Registry interface {
+	// TileMatrixSetIDs returns the identifiers of all TileMatrixSets.
+	TileMatrixSetIDs() []string
+	// TileMatrixSet returns the TileMatrixSet for id or an error wrapping
+	// ErrNotFound.
+	TileMatrixSet(id string) (*gocantile.TileMatrixSet, error)
+}
+
+// EmbeddedRegistry serves the TileMatrixSets embedded in gocantile.
+type EmbeddedRegistry struct{}
+
+// TileMatrixSetIDs returns gocantile.AvailableTileMatrixSets.
+func (EmbeddedRegistry) TileMatrixSetIDs() []string {
+	return gocantile.AvailableTileMatrixSets()
+}
+
+// TileMatrixSet loads the embedded TileMatrixSet id.
+func (EmbeddedRegistry) TileMatrixSet(id string) (*gocantile.TileMatrixSet, error) {
+	for _, name := range gocantile.AvailableTileMatrixSets() {
+		if name == id {
+			return gocantile.LoadTileMatrixSet(id)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Handler serves /tileMatrixSets, /tileMatrixSets/{tileMatrixSetId} and
+// /conformance. Mount it with http.StripPrefix to serve below a base path.
+type Handler struct {
+	// TrustForwardedProto takes the scheme of links from the
+	// X-Forwarded-Proto header when no base URL is set. Enable it only
+	// behind a proxy that sets the header.
+	TrustForwardedProto bool
+	// ErrorLog logs registry errors, which are not sent to clients. If nil,
+	// the standard logger of the log package is used.
+	ErrorLog *log.Logger
+
+	registry Registry
+	baseURL  string
+	mux      *http.ServeMux
+}
+
+// NewHandler returns a Handler serving the TileMatrixSets of reg; a nil
+// registry serves the embedded sets. Links are built from baseURL, or from the
+// request host, scheme and mount path if baseURL is empty.
+func NewHandler(reg Registry, baseURL string) *Handler {
+	if reg == nil {
+		reg = EmbeddedRegistry{}
+	}
+	h := &Handler{registry: reg, baseURL: strings.TrimSuffix(baseURL, "/")}
+	h.mux = http.NewServeMux()
+	h.mux.HandleFunc("GET /conformance", h.serveConformance)
+	h.mux.HandleFunc("GET /tileMatrixSets", h.serveTileMatrixSets)
+	h.mux.HandleFunc("GET /tileMatrixSets/{tileMatrixSetId}", h.serveTileMatrixSet)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Link is an OGC API link object.
+type Link struct {
+	Href  string `json:"href"`
+	Rel   string `json:"rel"`
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// TileMatrixSetRef summarises a TileMatrixSet in the /tileMatrixSets list.
+type TileMatrixSetRef struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	URI   string `json:"uri,omitempty"`
+	CRS   string `json:"crs,omitempty"`
+	Links []Link `json:"links"`
+}
+
+// TileMatrixSets is the body of the /tileMatrixSets response.
+type TileMatrixSets struct {
+	TileMatrixSets []TileMatrixSetRef `json:"tileMatrixSets"`
+	Links          []Link             `json:"links"`
+}
+
+// ConformanceDeclaration is the body of the /conformance response.
+type ConformanceDeclaration struct {
+	ConformsTo []string `json:"conformsTo"`
+}
+
+func (h *Handler) serveConformance(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, ConformanceDeclaration{ConformsTo: Conformance})
+}
+
+func (h *Handler) serveTileMatrixSets(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+	base := h.linkBase(r)
+	body := TileMatrixSets{
+		TileMatrixSets: []TileMatrixSetRef{},
+		Links: []Link{{
+			Href:  base + "/tileMatrixSets?f=json",
+			Rel:   "self",
+			Type:  mediaTypeJSON,
+			Title: "The list of supported tiling schemes",
+		}},
+	}
+	for _, id := range h.registry.TileMatrixSetIDs() {
+		set, err := h.registry.TileMatrixSet(id)
+		if err != nil {
+			h.serverError(w, err)
+			return
+		}
+		ref := TileMatrixSetRef{
+			ID: id,
+			Links: []Link{{
+				Href:  base + "/tileMatrixSets/" + url.PathEscape(id) + "?f=json",
+				Rel:   RelTilingScheme,
+				Type:  mediaTypeJSON,
+				Title: "The TileMatrixSet " + id,
+			}},
+		}
+		if set.Title != nil {
+			ref.Title = *set.Title
+		}
+		if set.Uri != nil {
+			ref.URI = *set.Uri
+		}
+		if crs, err := grid.ExtractCRS(set.TileMatrixSet); err == nil {
+			ref.CRS = crs
+		}
+		body.TileMatrixSets = append(body.TileMatrixSets, ref)
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *Handler) serveTileMatrixSet(w http.ResponseWriter, r *http.Request) {
+	if !negotiate(w, r) {
+		return
+	}
+	id := r.PathValue("tileMatrixSetId")
+	set, err := h.registry.TileMatrixSet(id)
+	if errors.Is(err, ErrNotFound) {
+		writeException(w, http.StatusNotFound, "NotFound", "tilematrixset "+id+" not found")
+		return
+	}
+	if err != nil {
+		h.serverError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, set.TileMatrixSet)
+}
+
+// serverError logs err and writes a 500 exception without its details.
+func (h *Handler) serverError(w http.ResponseWriter, err error) {
+	logf := log.Printf
+	if h.ErrorLog != nil {
+		logf = h.ErrorLog.Printf
+	}
+	logf("ogcapi: %v", err)
+	writeException(w, http.StatusInternalServerError, "ServerError", "internal server error")
+}
+
+// linkBase returns the URL the handler is served at: baseURL if set, or the
+// request scheme and host followed by the path prefix stripped before the
+// request reached the handler.
+func (h *Handler) linkBase(r *http.Request) string {
+	if h.baseURL != "" {
+		return h.baseURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if fwd := r.Header.Get("X-Forwarded-Proto"); h.TrustForwardedProto && (fwd == "http" || fwd == "https") {
+		scheme = fwd
+	}
+	return scheme + "://" + r.Host + mountPrefix(r)
+}
+
+// mountPrefix returns the escaped path prefix removed from the request, e.g.
+// by http.StripPrefix, by comparing the path with the original request URI.
+func mountPrefix(r *http.Request) string {
+	orig, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		return ""
+	}
+	full, path := orig.EscapedPath(), r.URL.EscapedPath()
+	if !strings.HasSuffix(full, path) {
+		return ""
+	}
+	return strings.TrimSuffix(full[:len(full)-len(path)], "/")
+}
+
+// negotiate checks the f query parameter and the Accept header. It writes a
+// 406 exception and returns false if JSON is not acceptable.
+func negotiate(w http.ResponseWriter, r *http.Request) bool {
+	if f := r.URL.Query().Get("f"); f != "" {
+		if f == "json" {
+			return true
+		}
+		writeException(w, http.StatusNotAcceptable, "NotAcceptable", "unsupported format "+f+", supported: json")
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" || acceptsJSON(accept) {
+		return true
+	}
+	writeException(w, http.StatusNotAcceptable, "NotAcceptable", "cannot produce "+accept+", supported: "+mediaTypeJSON)
+	return false
+}
+
+func acceptsJSON(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mt := strings.ToLower(strings.TrimSpace(params[0]))
+		if refused(params[1:]) {
+			continue
+		}
+		switch {
+		case mt == "*/*", mt == "application/*", mt == mediaTypeJSON, strings.HasSuffix(mt, "+json"):
+			return true
+		}
+	}
+	return false
+}
+
+// refused reports whether the media range parameters carry q=0.
+func refused(params []string) bool {
+	for _, p := range params {
+		k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if ok && strings.TrimSpace(k) == "q" && strings.Trim(strings.TrimSpace(v), "0.") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", mediaTypeJSON)
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// exception is the OGC API exception body.
+type exception struct {
+	Code        string `json:"code"`
+	Description string `json:"description,omitempty"`
+}
+
+func writeException(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, exception{Code: code, Description: description})
+}
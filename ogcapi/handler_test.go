@@ -0,0 +1,207 @@
+package ogcapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/tms"
+)
+
+func serve(t *testing.T, h http.Handler, target, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTileMatrixSetsList(t *testing.T) {
+	h := NewHandler(nil, "https://example.com/api/")
+	rec := serve(t, h, "/tileMatrixSets", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	var body TileMatrixSets
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(body.TileMatrixSets) != len(gocantile.AvailableTileMatrixSets()) {
+		t.Fatalf("expected %d sets, got %d", len(gocantile.AvailableTileMatrixSets()), len(body.TileMatrixSets))
+	}
+	var found bool
+	for _, ref := range body.TileMatrixSets {
+		if ref.ID != "WebMercatorQuad" {
+			continue
+		}
+		found = true
+		if ref.CRS != "EPSG:3857" {
+			t.Fatalf("unexpected crs %q", ref.CRS)
+		}
+		if len(ref.Links) != 1 || ref.Links[0].Rel != RelTilingScheme || ref.Links[0].Href != "https://example.com/api/tileMatrixSets/WebMercatorQuad?f=json" {
+			t.Fatalf("unexpected links %+v", ref.Links)
+		}
+	}
+	if !found {
+		t.Fatalf("expected WebMercatorQuad in list")
+	}
+	if len(body.Links) != 1 || body.Links[0].Rel != "self" {
+		t.Fatalf("unexpected self links %+v", body.Links)
+	}
+}
+
+func TestTileMatrixSetByID(t *testing.T) {
+	h := NewHandler(nil, "")
+	rec := serve(t, h, "/tileMatrixSets/WorldCRS84Quad?f=json", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var set tms.TileMatrixSet
+	if err := json.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if set.Id == nil || *set.Id != "WorldCRS84Quad" || len(set.TileMatrices) == 0 {
+		t.Fatalf("unexpected set %+v", set)
+	}
+
+	rec = serve(t, h, "/tileMatrixSets/Nope", "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	var exc exception
+	if err := json.Unmarshal(rec.Body.Bytes(), &exc); err != nil || exc.Code != "NotFound" {
+		t.Fatalf("unexpected exception %s", rec.Body)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	rec := serve(t, NewHandler(nil, ""), "/conformance", "application/json")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var decl ConformanceDeclaration
+	if err := json.Unmarshal(rec.Body.Bytes(), &decl); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(decl.ConformsTo) != len(Conformance) {
+		t.Fatalf("unexpected conformance %v", decl.ConformsTo)
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	h := NewHandler(nil, "")
+	cases := []struct {
+		target string
+		accept string
+		status int
+	}{
+		{"/conformance", "text/html,application/json;q=0.9", http.StatusOK},
+		{"/conformance", "*/*", http.StatusOK},
+		{"/conformance", "application/geo+json", http.StatusOK},
+		{"/conformance", "text/html", http.StatusNotAcceptable},
+		{"/conformance", "application/json;q=0", http.StatusNotAcceptable},
+		{"/conformance?f=json", "text/html", http.StatusOK},
+		{"/conformance?f=html", "", http.StatusNotAcceptable},
+	}
+	for _, tc := range cases {
+		rec := serve(t, h, tc.target, tc.accept)
+		if rec.Code != tc.status {
+			t.Fatalf("%s Accept=%q: expected %d, got %d", tc.target, tc.accept, tc.status, rec.Code)
+		}
+	}
+}
+
+type failingRegistry struct{}
+
+func (failingRegistry) TileMatrixSetIDs() []string { return []string{"Broken"} }
+
+func (failingRegistry) TileMatrixSet(string) (*gocantile.TileMatrixSet, error) {
+	return nil, errors.New("boom")
+}
+
+func TestRegistryErrors(t *testing.T) {
+	var logged bytes.Buffer
+	h := NewHandler(failingRegistry{}, "")
+	h.ErrorLog = log.New(&logged, "", 0)
+	for _, target := range []string{"/tileMatrixSets", "/tileMatrixSets/Broken"} {
+		logged.Reset()
+		rec := serve(t, h, target, "")
+		if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "boom") {
+			t.Fatalf("%s: expected 500 without details, got %d: %s", target, rec.Code, rec.Body)
+		}
+		if !strings.Contains(logged.String(), "boom") {
+			t.Fatalf("%s: error not logged: %q", target, logged.String())
+		}
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", NewHandler(nil, "")))
+	rec := serve(t, mux, "/api/tileMatrixSets/WebMercatorQuad", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 below prefix, got %d", rec.Code)
+	}
+
+	// Links keep the prefix the handler is mounted at.
+	rec = serve(t, mux, "http://example.com/api/tileMatrixSets", "")
+	var body TileMatrixSets
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if want := "http://example.com/api/tileMatrixSets?f=json"; body.Links[0].Href != want {
+		t.Fatalf("self link %q, want %q", body.Links[0].Href, want)
+	}
+}
+
+// namedRegistry serves WebMercatorQuad under another identifier.
+type namedRegistry string
+
+func (n namedRegistry) TileMatrixSetIDs() []string { return []string{string(n)} }
+
+func (n namedRegistry) TileMatrixSet(string) (*gocantile.TileMatrixSet, error) {
+	return gocantile.LoadTileMatrixSet("WebMercatorQuad")
+}
+
+func TestLinks(t *testing.T) {
+	list := func(h *Handler, req *http.Request) TileMatrixSets {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var body TileMatrixSets
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return body
+	}
+
+	// Identifiers are escaped in hrefs.
+	body := list(NewHandler(namedRegistry("my set/1"), ""), httptest.NewRequest(http.MethodGet, "http://example.com/tileMatrixSets", nil))
+	if want := "http://example.com/tileMatrixSets/my%20set%2F1?f=json"; body.TileMatrixSets[0].Links[0].Href != want {
+		t.Fatalf("href %q, want %q", body.TileMatrixSets[0].Links[0].Href, want)
+	}
+
+	// X-Forwarded-Proto is only used when trusted.
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/tileMatrixSets", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	h := NewHandler(nil, "")
+	if href := list(h, req).Links[0].Href; !strings.HasPrefix(href, "http://example.com/") {
+		t.Fatalf("untrusted forwarded proto used: %q", href)
+	}
+	h.TrustForwardedProto = true
+	if href := list(h, req).Links[0].Href; !strings.HasPrefix(href, "https://example.com/") {
+		t.Fatalf("trusted forwarded proto ignored: %q", href)
+	}
+}
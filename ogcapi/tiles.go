@@ -0,0 +1,296 @@
+package ogcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/hafenkran/gocantile/tms"
+)
+
+// InvalidParameterError reports a malformed tile path parameter. It maps to
+// HTTP 400.
+type InvalidParameterError struct {
+	Param string
+	Value string
+	Err   error
+}
+
+func (e *InvalidParameterError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %v", e.Param, e.Value, e.Err)
+}
+
+func (e *InvalidParameterError) Unwrap() error { return e.Err }
+
+// NotFoundError reports a well-formed tile address that does not exist: an
+// unknown TileMatrixSet or TileMatrix, or a tile outside the matrix or its
+// limits. It maps to HTTP 404.
+type NotFoundError struct {
+	Param string
+	Value string
+	Err   error
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %q not found: %v", e.Param, e.Value, e.Err)
+}
+
+func (e *NotFoundError) Unwrap() error { return e.Err }
+
+// StatusCode maps tile parsing errors to an HTTP status: 400 for
+// InvalidParameterError, 404 for NotFoundError and 500 otherwise.
+func StatusCode(err error) int {
+	var invalid *InvalidParameterError
+	var notFound *NotFoundError
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.As(err, &notFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// TileRequest is a tile address resolved against a TileMatrixSet.
+type TileRequest struct {
+	TileMatrixSetID string
+	TileMatrixSet   *gocantile.TileMatrixSet
+	Tile            grid.Tile
+}
+
+// TileParser resolves tile addresses against the TileMatrixSets of a Registry.
+type TileParser struct {
+	// Registry provides the TileMatrixSets. If nil, the embedded sets are used.
+	Registry Registry
+	// Limits optionally restricts the valid tiles per TileMatrixSet id, e.g.
+	// to the tileMatrixSetLimits of a TileSet. Tile matrices without limits
+	// are not available when a set has limits.
+	Limits map[string][]tms.TileMatrixLimits
+	// DefaultTileMatrixSetID is used by Middleware for routes without a
+	// {tileMatrixSetId} wildcard.
+	DefaultTileMatrixSetID string
+	// ErrorLog logs the errors Middleware answers with status 500, which are
+	// not sent to clients. If nil, the standard logger of the log package is
+	// used.
+	ErrorLog *log.Logger
+}
+
+// Parse resolves OGC API - Tiles path parameters. tileMatrix is a TileMatrix
+// id, resolved with ZoomForID.
+func (p TileParser) Parse(tileMatrixSetID, tileMatrix, tileRow, tileCol string) (TileRequest, error) {
+	set, err := p.tileMatrixSet(tileMatrixSetID)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	zoom, err := set.ZoomForID(tileMatrix)
+	if err != nil {
+		return TileRequest{}, &NotFoundError{Param: "tileMatrix", Value: tileMatrix, Err: err}
+	}
+	row, err := parseIndex("tileRow", tileRow)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	col, err := parseIndex("tileCol", tileCol)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	return p.resolve(tileMatrixSetID, set, grid.Tile{Zoom: zoom, TileIndex: grid.TileIndex{Col: col, Row: row}})
+}
+
+// ParseXYZ resolves XYZ-style parameters: z is the zero-based zoom level, x
+// the column from the left and y the row from the top. For sets with a
+// bottomLeft cornerOfOrigin, y is converted to the set's row numbering.
+func (p TileParser) ParseXYZ(tileMatrixSetID, z, x, y string) (TileRequest, error) {
+	set, err := p.tileMatrixSet(tileMatrixSetID)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	zoom, err := parseIndex("z", z)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	tm, err := set.TileMatrixForZoom(zoom)
+	if err != nil {
+		return TileRequest{}, &NotFoundError{Param: "z", Value: z, Err: err}
+	}
+	col, err := parseIndex("x", x)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	xyzRow, err := parseIndex("y", y)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	adapter := grid.TileMatrix{TM: tm}
+	if _, height := adapter.MatrixSize(); xyzRow >= height {
+		return TileRequest{}, &NotFoundError{Param: "y", Value: y, Err: fmt.Errorf("matrix %s has %d rows", tm.Id, height)}
+	}
+	return p.resolve(tileMatrixSetID, set, grid.Tile{Zoom: zoom, TileIndex: adapter.FromXYZ(col, xyzRow)})
+}
+
+// ParsePath parses "{tileMatrixSetId}/{tileMatrix}/{tileRow}/{tileCol}". A
+// leading slash and a file extension on the last segment are ignored.
+func (p TileParser) ParsePath(tilePath string) (TileRequest, error) {
+	parts, err := splitTilePath(tilePath, 4)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	return p.Parse(parts[0], parts[1], parts[2], parts[3])
+}
+
+// ParseXYZPath parses "{z}/{x}/{y}" for the given TileMatrixSet. A leading
+// slash and a file extension on the last segment are ignored.
+func (p TileParser) ParseXYZPath(tileMatrixSetID, tilePath string) (TileRequest, error) {
+	parts, err := splitTilePath(tilePath, 3)
+	if err != nil {
+		return TileRequest{}, err
+	}
+	return p.ParseXYZ(tileMatrixSetID, parts[0], parts[1], parts[2])
+}
+
+type tileRequestKey struct{}
+
+// Middleware parses the tile address from the path wildcards of the matched
+// http.ServeMux pattern and stores it in the request context; see
+// TileRequestFromContext. Patterns use either {tileMatrix}/{tileRow}/{tileCol}
+// or {z}/{x}/{y}, with an optional {tileMatrixSetId}. Parse errors are written
+// as OGC API exceptions with status 400 or 404; other errors are logged and
+// answered with status 500.
+func (p TileParser) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setID := r.PathValue("tileMatrixSetId")
+		if setID == "" {
+			setID = p.DefaultTileMatrixSetID
+		}
+		var req TileRequest
+		var err error
+		if r.PathValue("tileMatrix") != "" {
+			req, err = p.Parse(setID, r.PathValue("tileMatrix"), r.PathValue("tileRow"), trimExt(r.PathValue("tileCol")))
+		} else {
+			req, err = p.ParseXYZ(setID, r.PathValue("z"), r.PathValue("x"), trimExt(r.PathValue("y")))
+		}
+		if err != nil {
+			status := StatusCode(err)
+			switch status {
+			case http.StatusBadRequest:
+				writeException(w, status, "InvalidParameterValue", err.Error())
+			case http.StatusNotFound:
+				writeException(w, status, "NotFound", err.Error())
+			default:
+				logf := log.Printf
+				if p.ErrorLog != nil {
+					logf = p.ErrorLog.Printf
+				}
+				logf("ogcapi: %v", err)
+				writeException(w, status, "ServerError", "internal server error")
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tileRequestKey{}, req)))
+	})
+}
+
+// TileRequestFromContext returns the tile stored by Middleware.
+func TileRequestFromContext(ctx context.Context) (TileRequest, bool) {
+	req, ok := ctx.Value(tileRequestKey{}).(TileRequest)
+	return req, ok
+}
+
+func (p TileParser) tileMatrixSet(id string) (*gocantile.TileMatrixSet, error) {
+	reg := p.Registry
+	if reg == nil {
+		reg = EmbeddedRegistry{}
+	}
+	set, err := reg.TileMatrixSet(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, &NotFoundError{Param: "tileMatrixSetId", Value: id, Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// resolve checks the tile against the matrix size, coalesced row widths and
+// the configured limits.
+func (p TileParser) resolve(setID string, set *gocantile.TileMatrixSet, tile grid.Tile) (TileRequest, error) {
+	tm, err := set.TileMatrixForZoom(tile.Zoom)
+	if err != nil {
+		return TileRequest{}, &NotFoundError{Param: "tileMatrix", Value: strconv.Itoa(tile.Zoom), Err: err}
+	}
+	adapter := grid.TileMatrix{TM: tm}
+	width, height := adapter.MatrixSize()
+	if tile.Row >= height {
+		return TileRequest{}, &NotFoundError{Param: "tileRow", Value: strconv.Itoa(tile.Row), Err: fmt.Errorf("matrix %s has %d rows", tm.Id, height)}
+	}
+	if rowWidth := adapter.RowWidth(tile.Row); tile.Col >= rowWidth {
+		err := fmt.Errorf("matrix %s has %d columns", tm.Id, width)
+		if rowWidth != width {
+			err = fmt.Errorf("row %d of matrix %s has %d coalesced columns", tile.Row, tm.Id, rowWidth)
+		}
+		return TileRequest{}, &NotFoundError{Param: "tileCol", Value: strconv.Itoa(tile.Col), Err: err}
+	}
+	if limits, ok := p.Limits[setID]; ok {
+		if err := checkLimits(limits, tm.Id, tile.TileIndex); err != nil {
+			return TileRequest{}, err
+		}
+	}
+	return TileRequest{TileMatrixSetID: setID, TileMatrixSet: set, Tile: tile}, nil
+}
+
+func checkLimits(limits []tms.TileMatrixLimits, tileMatrix string, idx grid.TileIndex) error {
+	for _, l := range limits {
+		if l.TileMatrix != tileMatrix {
+			continue
+		}
+		if idx.Row < l.MinTileRow || idx.Row > l.MaxTileRow {
+			return &NotFoundError{Param: "tileRow", Value: strconv.Itoa(idx.Row), Err: fmt.Errorf("outside limits %d..%d", l.MinTileRow, l.MaxTileRow)}
+		}
+		if idx.Col < l.MinTileCol || idx.Col > l.MaxTileCol {
+			return &NotFoundError{Param: "tileCol", Value: strconv.Itoa(idx.Col), Err: fmt.Errorf("outside limits %d..%d", l.MinTileCol, l.MaxTileCol)}
+		}
+		return nil
+	}
+	return &NotFoundError{Param: "tileMatrix", Value: tileMatrix, Err: errors.New("not available in tile matrix set limits")}
+}
+
+func parseIndex(param, value string) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &InvalidParameterError{Param: param, Value: value, Err: errors.New("not an integer")}
+	}
+	if v < 0 {
+		return 0, &InvalidParameterError{Param: param, Value: value, Err: errors.New("must not be negative")}
+	}
+	return v, nil
+}
+
+func splitTilePath(tilePath string, n int) ([]string, error) {
+	parts := strings.Split(strings.Trim(tilePath, "/"), "/")
+	if len(parts) != n {
+		return nil, &InvalidParameterError{Param: "path", Value: tilePath, Err: fmt.Errorf("expected %d segments", n)}
+	}
+	parts[n-1] = trimExt(parts[n-1])
+	return parts, nil
+}
+
+// trimExt strips a format extension such as ".png" or ".pbf"; numeric
+// suffixes are kept so that "1.5" is rejected as a non-integer index.
+func trimExt(s string) string {
+	ext := path.Ext(s)
+	if strings.IndexFunc(ext, unicode.IsLetter) < 0 {
+		return s
+	}
+	return strings.TrimSuffix(s, ext)
+}
@@ -0,0 +1,188 @@
+package ogcapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/tms"
+)
+
+func TestTileParserParse(t *testing.T) {
+	var p TileParser
+	req, err := p.Parse("WebMercatorQuad", "3", "2", "5")
+	if err != nil {
+		t.Fatalf("parse err: %v", err)
+	}
+	if req.TileMatrixSetID != "WebMercatorQuad" || req.TileMatrixSet == nil {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	if req.Tile.Zoom != 3 || req.Tile.Row != 2 || req.Tile.Col != 5 {
+		t.Fatalf("unexpected tile %+v", req.Tile)
+	}
+
+	req, err = p.ParsePath("/WebMercatorQuad/12/1343/2200.png")
+	if err != nil {
+		t.Fatalf("parse path err: %v", err)
+	}
+	if req.Tile.Zoom != 12 || req.Tile.Row != 1343 || req.Tile.Col != 2200 {
+		t.Fatalf("unexpected tile %+v", req.Tile)
+	}
+
+	req, err = p.ParseXYZPath("WebMercatorQuad", "12/2200/1343.pbf")
+	if err != nil {
+		t.Fatalf("parse xyz path err: %v", err)
+	}
+	if req.Tile.Zoom != 12 || req.Tile.Row != 1343 || req.Tile.Col != 2200 {
+		t.Fatalf("unexpected tile %+v", req.Tile)
+	}
+}
+
+func TestTileParserErrors(t *testing.T) {
+	p := TileParser{Limits: map[string][]tms.TileMatrixLimits{
+		"WorldCRS84Quad": {{TileMatrix: "2", MinTileRow: 1, MaxTileRow: 2, MinTileCol: 3, MaxTileCol: 4}},
+	}}
+	cases := []struct {
+		name   string
+		parse  func() (TileRequest, error)
+		status int
+	}{
+		{"unknown set", func() (TileRequest, error) { return p.Parse("Nope", "0", "0", "0") }, http.StatusNotFound},
+		{"unknown matrix", func() (TileRequest, error) { return p.Parse("WebMercatorQuad", "99", "0", "0") }, http.StatusNotFound},
+		{"non-integer row", func() (TileRequest, error) { return p.Parse("WebMercatorQuad", "1", "a", "0") }, http.StatusBadRequest},
+		{"fractional col", func() (TileRequest, error) { return p.Parse("WebMercatorQuad", "1", "0", "1.5") }, http.StatusBadRequest},
+		{"negative col", func() (TileRequest, error) { return p.Parse("WebMercatorQuad", "1", "0", "-1") }, http.StatusBadRequest},
+		{"row out of matrix", func() (TileRequest, error) { return p.Parse("WebMercatorQuad", "1", "2", "0") }, http.StatusNotFound},
+		{"col out of matrix", func() (TileRequest, error) { return p.Parse("WebMercatorQuad", "1", "0", "2") }, http.StatusNotFound},
+		{"coalesced row", func() (TileRequest, error) { return p.Parse("GNOSISGlobalGrid", "1", "0", "4") }, http.StatusNotFound},
+		{"xyz zoom", func() (TileRequest, error) { return p.ParseXYZ("WebMercatorQuad", "40", "0", "0") }, http.StatusNotFound},
+		{"xyz segments", func() (TileRequest, error) { return p.ParseXYZPath("WebMercatorQuad", "1/0") }, http.StatusBadRequest},
+		{"outside limits", func() (TileRequest, error) { return p.Parse("WorldCRS84Quad", "2", "0", "3") }, http.StatusNotFound},
+		{"matrix without limits", func() (TileRequest, error) { return p.Parse("WorldCRS84Quad", "1", "0", "0") }, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		_, err := tc.parse()
+		if err == nil {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+		if got := StatusCode(err); got != tc.status {
+			t.Fatalf("%s: expected status %d, got %d (%v)", tc.name, tc.status, got, err)
+		}
+	}
+
+	if _, err := p.Parse("GNOSISGlobalGrid", "1", "0", "3"); err != nil {
+		t.Fatalf("expected last coalesced column to be valid: %v", err)
+	}
+	if _, err := p.Parse("WorldCRS84Quad", "2", "2", "4"); err != nil {
+		t.Fatalf("expected tile within limits: %v", err)
+	}
+	var notFound *NotFoundError
+	if _, err := p.Parse("Nope", "0", "0", "0"); !errors.As(err, &notFound) || notFound.Param != "tileMatrixSetId" {
+		t.Fatalf("expected NotFoundError for tileMatrixSetId, got %v", err)
+	}
+}
+
+func TestTileParserMiddleware(t *testing.T) {
+	p := TileParser{DefaultTileMatrixSetID: "WebMercatorQuad"}
+	tileHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, ok := TileRequestFromContext(r.Context())
+		if !ok {
+			t.Fatalf("expected tile request in context")
+		}
+		fmt.Fprintf(w, "%s %d/%d/%d", req.TileMatrixSetID, req.Tile.Zoom, req.Tile.Col, req.Tile.Row)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("GET /tiles/{tileMatrixSetId}/{tileMatrix}/{tileRow}/{tileCol}", p.Middleware(tileHandler))
+	mux.Handle("GET /xyz/{z}/{x}/{y}", p.Middleware(tileHandler))
+
+	cases := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/tiles/WorldCRS84Quad/1/1/3", http.StatusOK, "WorldCRS84Quad 1/3/1"},
+		{"/xyz/2/3/1.png", http.StatusOK, "WebMercatorQuad 2/3/1"},
+		{"/tiles/WorldCRS84Quad/1/2/0", http.StatusNotFound, ""},
+		{"/xyz/2/x/1", http.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		if rec.Code != tc.status {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.target, tc.status, rec.Code, rec.Body)
+		}
+		if tc.body != "" && rec.Body.String() != tc.body {
+			t.Fatalf("%s: unexpected body %q", tc.target, rec.Body)
+		}
+	}
+}
+
+func TestTileParserXYZBottomLeft(t *testing.T) {
+	set := gocantile.WrapTileMatrixSet(tms.TileMatrixSet{
+		Crs: "EPSG:3857",
+		TileMatrices: []tms.TileMatrix{{
+			Id:             "0",
+			CellSize:       1000,
+			TileWidth:      1,
+			TileHeight:     1,
+			MatrixWidth:    4,
+			MatrixHeight:   4,
+			PointOfOrigin:  []float64{0, 0},
+			CornerOfOrigin: tms.TileMatrixJsonCornerOfOriginBottomLeft,
+		}},
+	})
+	p := TileParser{Registry: staticRegistry{"Custom": set}}
+
+	req, err := p.ParseXYZ("Custom", "0", "1", "0")
+	if err != nil {
+		t.Fatalf("parse err: %v", err)
+	}
+	if req.Tile.Col != 1 || req.Tile.Row != 3 {
+		t.Fatalf("expected top XYZ row to map to row 3, got %+v", req.Tile)
+	}
+	if _, err := p.ParseXYZ("Custom", "0", "0", "4"); StatusCode(err) != http.StatusNotFound {
+		t.Fatalf("expected 404 for y outside matrix, got %v", err)
+	}
+}
+
+type staticRegistry map[string]*gocantile.TileMatrixSet
+
+func (r staticRegistry) TileMatrixSetIDs() []string {
+	ids := make([]string, 0, len(r))
+	for id := range r {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (r staticRegistry) TileMatrixSet(id string) (*gocantile.TileMatrixSet, error) {
+	set, ok := r[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return set, nil
+}
+
+func TestTileParserMiddlewareServerError(t *testing.T) {
+	var logged bytes.Buffer
+	p := TileParser{Registry: failingRegistry{}, DefaultTileMatrixSetID: "Broken", ErrorLog: log.New(&logged, "", 0)}
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("handler called for a failing registry")
+	}))
+	mux := http.NewServeMux()
+	mux.Handle("GET /xyz/{z}/{x}/{y}", h)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/xyz/0/0/0", nil))
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "boom") {
+		t.Fatalf("expected 500 without details, got %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(logged.String(), "boom") {
+		t.Fatalf("error not logged: %q", logged.String())
+	}
+}
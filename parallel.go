@@ -0,0 +1,130 @@
+package gocantile
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+)
+
+// ParallelOptions controls TilesForGeometryContext.
+type ParallelOptions struct {
+	// Workers is the number of goroutines computing tiles and defaults to
+	// runtime.GOMAXPROCS(0).
+	Workers int
+	// BandRows is the number of matrix rows handed to a worker at a time.
+	// Zero spreads each zoom level over about four bands per worker.
+	BandRows int
+	// Progress, if set, is called after each band with the number of rows
+	// done and the total number of rows over all zoom levels. Calls never
+	// overlap, but come from the worker goroutines.
+	Progress func(done, total int)
+}
+
+// rowBand is a band of rows of one zoom level. Bands are numbered in
+// zoom and row order, so their results concatenate into sorted order.
+type rowBand struct {
+	zoom, minRow, maxRow int
+	adapter              grid.TileMatrix
+}
+
+// TilesForGeometryContext returns the tiles of TilesForGeometry in the same
+// order, computing zoom levels in bands of rows across opts.Workers
+// goroutines. It stops between rows once ctx is done and then returns
+// ctx.Err().
+func (t *TileMatrixSet) TilesForGeometryContext(ctx context.Context, g orb.Geometry, minZoom, maxZoom int, buffer float64, opts ParallelOptions) (grid.TilesList, error) {
+	if opts.Workers < 0 || opts.BandRows < 0 {
+		return nil, fmt.Errorf("invalid parallel options workers=%d band rows=%d", opts.Workers, opts.BandRows)
+	}
+	mats, err := t.zoomMatrices(minZoom, maxZoom)
+	if err != nil {
+		return nil, err
+	}
+	workers := opts.Workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var bands []rowBand
+	total := 0
+	for z := minZoom; z <= maxZoom; z++ {
+		adapter := grid.TileMatrix{TM: mats[z]}
+		tr, ok := adapter.TileRangeForGeometry(g, buffer)
+		if !ok {
+			continue
+		}
+		rows := tr.MaxRow - tr.MinRow + 1
+		step := opts.BandRows
+		if step == 0 {
+			step = max(1, (rows+4*workers-1)/(4*workers))
+		}
+		for r := tr.MinRow; r <= tr.MaxRow; r += step {
+			bands = append(bands, rowBand{
+				zoom:    z,
+				minRow:  r,
+				maxRow:  min(r+step-1, tr.MaxRow),
+				adapter: adapter,
+			})
+		}
+		total += rows
+	}
+
+	results := make([]grid.TilesList, len(bands))
+	next := make(chan int)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for range min(workers, len(bands)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				b := bands[i]
+				var tiles grid.TilesList
+				row := -1
+				b.adapter.EachTileForGeometryInRows(g, buffer, b.minRow, b.maxRow, func(idx grid.TileIndex) bool {
+					// Checking the context takes a lock, so do it once per row.
+					if idx.Row != row {
+						if ctx.Err() != nil {
+							return false
+						}
+						row = idx.Row
+					}
+					tiles = append(tiles, grid.Tile{Zoom: b.zoom, TileIndex: idx})
+					return true
+				})
+				results[i] = tiles
+				if opts.Progress != nil && ctx.Err() == nil {
+					mu.Lock()
+					done += b.maxRow - b.minRow + 1
+					opts.Progress(done, total)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+feed:
+	for i := range bands {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var tiles grid.TilesList
+	for _, r := range results {
+		tiles = append(tiles, r...)
+	}
+	return tiles, nil
+}
@@ -0,0 +1,85 @@
+package gocantile
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func TestTilesForGeometryContextMatchesTilesForGeometry(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	g := orb.Polygon{{
+		{-500000, -300000}, {1200000, -300000}, {1200000, 900000}, {-500000, 900000}, {-500000, -300000},
+	}}
+	want, err := set.TilesForGeometry(g, 0, 9, 0)
+	if err != nil {
+		t.Fatalf("tiles: %v", err)
+	}
+	for _, opts := range []ParallelOptions{{}, {Workers: 1}, {Workers: 3, BandRows: 2}} {
+		var calls, last, total int
+		opts.Progress = func(done, all int) {
+			if done <= last {
+				t.Errorf("progress went from %d to %d", last, done)
+			}
+			calls++
+			last, total = done, all
+		}
+		got, err := set.TilesForGeometryContext(context.Background(), g, 0, 9, 0, opts)
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%+v: got %d tiles, want %d", opts, len(got), len(want))
+		}
+		if calls == 0 || last != total {
+			t.Fatalf("%+v: progress %d of %d after %d calls", opts, last, total, calls)
+		}
+	}
+}
+
+func TestTilesForGeometryContextCancel(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	g := orb.Polygon{{
+		{-2000000, -2000000}, {2000000, -2000000}, {2000000, 2000000}, {-2000000, 2000000}, {-2000000, -2000000},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := set.TilesForGeometryContext(ctx, g, 0, 12, 0, ParallelOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled before start: %v", err)
+	}
+
+	// Cancelling from the progress callback stops the remaining bands.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	opts := ParallelOptions{Workers: 2, BandRows: 1, Progress: func(done, total int) {
+		calls++
+		cancel()
+	}}
+	if _, err := set.TilesForGeometryContext(ctx, g, 0, 12, 0, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled while running: %v", err)
+	}
+	if calls > 2 {
+		t.Fatalf("progress called %d times after cancel", calls)
+	}
+}
+
+func TestTilesForGeometryContextInvalid(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	ctx := context.Background()
+	if _, err := set.TilesForGeometryContext(ctx, orb.Point{0, 0}, 3, 2, 0, ParallelOptions{}); err == nil {
+		t.Fatal("expected error for invalid zoom range")
+	}
+	if _, err := set.TilesForGeometryContext(ctx, orb.Point{0, 0}, 0, 2, 0, ParallelOptions{Workers: -1}); err == nil {
+		t.Fatal("expected error for negative workers")
+	}
+	// A geometry outside the set has no tiles.
+	tiles, err := set.TilesForGeometryContext(ctx, orb.Point{1e9, 1e9}, 0, 2, 0, ParallelOptions{})
+	if err != nil || len(tiles) != 0 {
+		t.Fatalf("outside: %v, %v", tiles, err)
+	}
+}
@@ -0,0 +1,373 @@
+package gocantile
+
+import (
+	"fmt"
+	"math/bits"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// TilePathLayout maps tiles of a TileMatrixSet to slash-separated paths
+// relative to a cache root, as used on disk and in object storage.
+type TilePathLayout interface {
+	// Format returns the path of the tile.
+	Format(set *TileMatrixSet, tile grid.Tile) (string, error)
+	// Parse returns the tile of a path produced by Format. A leading slash
+	// is ignored.
+	Parse(set *TileMatrixSet, p string) (grid.Tile, error)
+}
+
+// PathLayoutByName returns a built-in layout: "xyz", "tms", "quadkey",
+// "arcgis", "geowebcache" or "tc". ext is the file extension without dot.
+func PathLayoutByName(name, ext string) (TilePathLayout, error) {
+	switch name {
+	case "xyz":
+		return XYZPathLayout{Ext: ext}, nil
+	case "tms":
+		return TMSPathLayout{Ext: ext}, nil
+	case "quadkey":
+		return QuadkeyPathLayout{Ext: ext}, nil
+	case "arcgis":
+		return ArcGISPathLayout{Ext: ext}, nil
+	case "geowebcache":
+		return GeoWebCachePathLayout{Ext: ext}, nil
+	case "tc":
+		return TileCachePathLayout{Ext: ext}, nil
+	}
+	return nil, fmt.Errorf("unknown path layout %q", name)
+}
+
+// XYZPathLayout is "{z}/{x}/{y}.{ext}" with y counted from the top.
+type XYZPathLayout struct{ Ext string }
+
+func (l XYZPathLayout) Format(set *TileMatrixSet, tile grid.Tile) (string, error) {
+	x, y, z, err := set.TileToXYZ(tile)
+	if err != nil {
+		return "", err
+	}
+	return withExt(fmt.Sprintf("%d/%d/%d", z, x, y), l.Ext), nil
+}
+
+func (l XYZPathLayout) Parse(set *TileMatrixSet, p string) (grid.Tile, error) {
+	v, err := parsePathInts(p, l.Ext, 3, 10)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	return set.TileFromXYZ(v[1], v[2], v[0])
+}
+
+// TMSPathLayout is "{z}/{x}/{y}.{ext}" with y counted from the bottom, as in
+// TMS and MBTiles.
+type TMSPathLayout struct{ Ext string }
+
+func (l TMSPathLayout) Format(set *TileMatrixSet, tile grid.Tile) (string, error) {
+	x, y, z, err := set.TileToTMS(tile)
+	if err != nil {
+		return "", err
+	}
+	return withExt(fmt.Sprintf("%d/%d/%d", z, x, y), l.Ext), nil
+}
+
+func (l TMSPathLayout) Parse(set *TileMatrixSet, p string) (grid.Tile, error) {
+	v, err := parsePathInts(p, l.Ext, 3, 10)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	return set.TileFromTMS(v[1], v[2], v[0])
+}
+
+// QuadkeyPathLayout is "{quadkey}.{ext}". Each quadkey digit selects a child
+// quadrant (0 top-left, 1 top-right, 2 bottom-left, 3 bottom-right), so the
+// length is the zoom level. Only zoom levels with 2^z x 2^z tiles, e.g. of
+// WebMercatorQuad, can be addressed, and zoom 0 has no quadkey.
+type QuadkeyPathLayout struct{ Ext string }
+
+func (l QuadkeyPathLayout) Format(set *TileMatrixSet, tile grid.Tile) (string, error) {
+	if err := checkQuadMatrix(set, tile.Zoom); err != nil {
+		return "", err
+	}
+	x, y, z, err := set.TileToXYZ(tile)
+	if err != nil {
+		return "", err
+	}
+	key := make([]byte, z)
+	for i := z; i > 0; i-- {
+		mask := 1 << (i - 1)
+		digit := byte('0')
+		if x&mask != 0 {
+			digit++
+		}
+		if y&mask != 0 {
+			digit += 2
+		}
+		key[z-i] = digit
+	}
+	return withExt(string(key), l.Ext), nil
+}
+
+func (l QuadkeyPathLayout) Parse(set *TileMatrixSet, p string) (grid.Tile, error) {
+	key, err := trimPathExt(p, l.Ext)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	if key == "" || strings.Contains(key, "/") {
+		return grid.Tile{}, fmt.Errorf("invalid quadkey path %q", p)
+	}
+	z := len(key)
+	if err := checkQuadMatrix(set, z); err != nil {
+		return grid.Tile{}, err
+	}
+	var x, y int
+	for i, c := range key {
+		mask := 1 << (z - i - 1)
+		switch c {
+		case '0':
+		case '1':
+			x |= mask
+		case '2':
+			y |= mask
+		case '3':
+			x |= mask
+			y |= mask
+		default:
+			return grid.Tile{}, fmt.Errorf("invalid quadkey digit %q in %q", c, key)
+		}
+	}
+	return set.TileFromXYZ(x, y, z)
+}
+
+// checkQuadMatrix checks that the matrix at zoom z has 2^z x 2^z tiles.
+func checkQuadMatrix(set *TileMatrixSet, z int) error {
+	if z < 1 || z >= bits.UintSize-1 {
+		return fmt.Errorf("quadkey needs zoom >= 1, got %d", z)
+	}
+	adapter, err := set.tileMatrix(z)
+	if err != nil {
+		return err
+	}
+	if width, height := adapter.MatrixSize(); width != 1<<z || height != 1<<z || len(adapter.TM.VariableMatrixWidths) > 0 {
+		return fmt.Errorf("matrix %s is %dx%d, quadkeys need %dx%d", adapter.TM.Id, width, height, 1<<z, 1<<z)
+	}
+	return nil
+}
+
+// ArcGISPathLayout is the ArcGIS exploded cache layout
+// "L{zz}/R{row}/C{col}.{ext}": the zoom level in two decimal digits and the
+// row, counted from the top, and column in eight lowercase hex digits.
+type ArcGISPathLayout struct{ Ext string }
+
+func (l ArcGISPathLayout) Format(set *TileMatrixSet, tile grid.Tile) (string, error) {
+	x, y, z, err := set.TileToXYZ(tile)
+	if err != nil {
+		return "", err
+	}
+	return withExt(fmt.Sprintf("L%02d/R%08x/C%08x", z, y, x), l.Ext), nil
+}
+
+func (l ArcGISPathLayout) Parse(set *TileMatrixSet, p string) (grid.Tile, error) {
+	s, err := trimPathExt(p, l.Ext)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 {
+		return grid.Tile{}, fmt.Errorf("invalid ArcGIS cache path %q", p)
+	}
+	var v [3]int
+	for i, prefix := range []string{"L", "R", "C"} {
+		seg := parts[i]
+		if len(seg) < 2 || !strings.EqualFold(seg[:1], prefix) {
+			return grid.Tile{}, fmt.Errorf("invalid ArcGIS cache path %q: segment %q", p, seg)
+		}
+		base := 16
+		if i == 0 {
+			base = 10
+		}
+		if v[i], err = parseIndexBase(seg[1:], base); err != nil {
+			return grid.Tile{}, fmt.Errorf("invalid ArcGIS cache path %q: %w", p, err)
+		}
+	}
+	return set.TileFromXYZ(v[2], v[1], v[0])
+}
+
+// GeoWebCachePathLayout is the GeoWebCache file blob store layout
+// "{gridset}_{zz}/{x/half}_{y/half}/{x}_{y}.{ext}". Rows are counted from the
+// bottom, tiles are grouped into folders of half = 2^(z/2+1) columns and
+// rows, and numbers are zero padded to the digit count of half (twice that
+// for file names).
+type GeoWebCachePathLayout struct {
+	Ext string
+	// GridSetID names the gridset folder. If empty, the TileMatrixSet id
+	// is used. Colons are replaced with underscores as GeoWebCache does.
+	GridSetID string
+}
+
+func (l GeoWebCachePathLayout) Format(set *TileMatrixSet, tile grid.Tile) (string, error) {
+	gridSet, err := l.gridSet(set)
+	if err != nil {
+		return "", err
+	}
+	x, y, z, err := set.TileToTMS(tile)
+	if err != nil {
+		return "", err
+	}
+	half, digits := gwcHalf(z)
+	return withExt(fmt.Sprintf("%s_%02d/%0*d_%0*d/%0*d_%0*d", gridSet, z,
+		digits, x/half, digits, y/half, 2*digits, x, 2*digits, y), l.Ext), nil
+}
+
+func (l GeoWebCachePathLayout) Parse(set *TileMatrixSet, p string) (grid.Tile, error) {
+	gridSet, err := l.gridSet(set)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	s, err := trimPathExt(p, l.Ext)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 {
+		return grid.Tile{}, fmt.Errorf("invalid GeoWebCache path %q", p)
+	}
+	zoom, ok := strings.CutPrefix(parts[0], gridSet+"_")
+	if !ok {
+		return grid.Tile{}, fmt.Errorf("invalid GeoWebCache path %q: expected gridset %q", p, gridSet)
+	}
+	z, err := parseIndexBase(zoom, 10)
+	if err != nil {
+		return grid.Tile{}, fmt.Errorf("invalid GeoWebCache path %q: %w", p, err)
+	}
+	dir, err := parsePairs(parts[1])
+	if err != nil {
+		return grid.Tile{}, fmt.Errorf("invalid GeoWebCache path %q: %w", p, err)
+	}
+	xy, err := parsePairs(parts[2])
+	if err != nil {
+		return grid.Tile{}, fmt.Errorf("invalid GeoWebCache path %q: %w", p, err)
+	}
+	if half, _ := gwcHalf(z); xy[0]/half != dir[0] || xy[1]/half != dir[1] {
+		return grid.Tile{}, fmt.Errorf("invalid GeoWebCache path %q: tile not in folder %s", p, parts[1])
+	}
+	return set.TileFromTMS(xy[0], xy[1], z)
+}
+
+func (l GeoWebCachePathLayout) gridSet(set *TileMatrixSet) (string, error) {
+	id := l.GridSetID
+	if id == "" && set.Id != nil {
+		id = *set.Id
+	}
+	if id == "" {
+		return "", fmt.Errorf("GeoWebCache layout needs a gridset id")
+	}
+	return strings.ReplaceAll(id, ":", "_"), nil
+}
+
+// gwcHalf returns the folder size and the zero padding width of GeoWebCache
+// paths at zoom z.
+func gwcHalf(z int) (int, int) {
+	half := 2 << (z / 2)
+	return half, len(strconv.Itoa(half))
+}
+
+func parsePairs(s string) ([2]int, error) {
+	a, b, ok := strings.Cut(s, "_")
+	if !ok {
+		return [2]int{}, fmt.Errorf("expected {x}_{y}, got %q", s)
+	}
+	x, err := parseIndexBase(a, 10)
+	if err != nil {
+		return [2]int{}, err
+	}
+	y, err := parseIndexBase(b, 10)
+	if err != nil {
+		return [2]int{}, err
+	}
+	return [2]int{x, y}, nil
+}
+
+// TileCachePathLayout is the TileCache/MapProxy "tc" layout
+// "{zz}/{xxx}/{xxx}/{xxx}/{yyy}/{yyy}/{yyy}.{ext}": the zoom level in two
+// digits and column and row, counted from the bottom, as nine digits split
+// into groups of three.
+type TileCachePathLayout struct{ Ext string }
+
+func (l TileCachePathLayout) Format(set *TileMatrixSet, tile grid.Tile) (string, error) {
+	x, y, z, err := set.TileToTMS(tile)
+	if err != nil {
+		return "", err
+	}
+	if x >= 1e9 || y >= 1e9 {
+		return "", fmt.Errorf("tile %d/%d/%d exceeds nine digits", z, x, y)
+	}
+	return withExt(fmt.Sprintf("%02d/%03d/%03d/%03d/%03d/%03d/%03d", z,
+		x/1e6, x/1e3%1e3, x%1e3, y/1e6, y/1e3%1e3, y%1e3), l.Ext), nil
+}
+
+func (l TileCachePathLayout) Parse(set *TileMatrixSet, p string) (grid.Tile, error) {
+	v, err := parsePathInts(p, l.Ext, 7, 10)
+	if err != nil {
+		return grid.Tile{}, err
+	}
+	for _, part := range v[1:] {
+		if part >= 1000 {
+			return grid.Tile{}, fmt.Errorf("invalid tc path %q: group %d exceeds three digits", p, part)
+		}
+	}
+	x := v[1]*1e6 + v[2]*1e3 + v[3]
+	y := v[4]*1e6 + v[5]*1e3 + v[6]
+	return set.TileFromTMS(x, y, v[0])
+}
+
+func withExt(p, ext string) string {
+	if ext == "" {
+		return p
+	}
+	return p + "." + ext
+}
+
+// trimPathExt removes a leading slash and the layout extension, which must be
+// present if ext is set.
+func trimPathExt(p, ext string) (string, error) {
+	s := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if ext == "" {
+		return s, nil
+	}
+	trimmed, ok := strings.CutSuffix(s, "."+ext)
+	if !ok {
+		return "", fmt.Errorf("path %q does not have extension %q", p, ext)
+	}
+	return trimmed, nil
+}
+
+// parsePathInts splits a path into n non-negative integer segments.
+func parsePathInts(p, ext string, n, base int) ([]int, error) {
+	s, err := trimPathExt(p, ext)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) != n {
+		return nil, fmt.Errorf("path %q: expected %d segments, got %d", p, n, len(parts))
+	}
+	v := make([]int, n)
+	for i, part := range parts {
+		if v[i], err = parseIndexBase(part, base); err != nil {
+			return nil, fmt.Errorf("path %q: %w", p, err)
+		}
+	}
+	return v, nil
+}
+
+func parseIndexBase(s string, base int) (int, error) {
+	if s == "" || s[0] == '+' || s[0] == '-' {
+		return 0, fmt.Errorf("invalid index %q", s)
+	}
+	v, err := strconv.ParseUint(s, base, 31)
+	if err != nil {
+		return 0, fmt.Errorf("invalid index %q", s)
+	}
+	return int(v), nil
+}
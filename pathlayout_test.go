@@ -0,0 +1,120 @@
+package gocantile
+
+import (
+	"testing"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func TestPathLayoutsFormat(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	tile := Tile{Zoom: 3, TileIndex: TileIndex{Col: 5, Row: 2}}
+	cases := []struct {
+		layout TilePathLayout
+		want   string
+	}{
+		{XYZPathLayout{Ext: "png"}, "3/5/2.png"},
+		{TMSPathLayout{Ext: "png"}, "3/5/5.png"},
+		{QuadkeyPathLayout{Ext: "png"}, "121.png"},
+		{ArcGISPathLayout{Ext: "png"}, "L03/R00000002/C00000005.png"},
+		{GeoWebCachePathLayout{Ext: "png"}, "WebMercatorQuad_03/1_1/05_05.png"},
+		{GeoWebCachePathLayout{Ext: "png", GridSetID: "EPSG:900913"}, "EPSG_900913_03/1_1/05_05.png"},
+		{TileCachePathLayout{Ext: "png"}, "03/000/000/005/000/000/005.png"},
+		{XYZPathLayout{}, "3/5/2"},
+	}
+	for _, tc := range cases {
+		got, err := tc.layout.Format(set, tile)
+		if err != nil || got != tc.want {
+			t.Fatalf("%T: got %q err=%v, want %q", tc.layout, got, err, tc.want)
+		}
+		back, err := tc.layout.Parse(set, "/"+got)
+		if err != nil || back != tile {
+			t.Fatalf("%T: parse %q = %+v err=%v", tc.layout, got, back, err)
+		}
+	}
+}
+
+func TestPathLayoutsRoundTrip(t *testing.T) {
+	for _, id := range []string{"WebMercatorQuad", "GNOSISGlobalGrid"} {
+		set, err := LoadTileMatrixSet(id)
+		if err != nil {
+			t.Fatalf("load %s: %v", id, err)
+		}
+		for _, name := range []string{"xyz", "tms", "quadkey", "arcgis", "geowebcache", "tc"} {
+			layout, err := PathLayoutByName(name, "pbf")
+			if err != nil {
+				t.Fatalf("layout %s: %v", name, err)
+			}
+			if name == "quadkey" && id != "WebMercatorQuad" {
+				continue
+			}
+			for z := 0; z <= 4; z++ {
+				if name == "quadkey" && z == 0 {
+					continue
+				}
+				tm, err := set.TileMatrixForZoom(z)
+				if err != nil {
+					t.Fatalf("matrix %d: %v", z, err)
+				}
+				adapter := grid.TileMatrix{TM: tm}
+				width, height := adapter.MatrixSize()
+				for row := 0; row < height; row++ {
+					for col := 0; col < adapter.RowWidth(row); col++ {
+						tile := Tile{Zoom: z, TileIndex: TileIndex{Col: col, Row: row}}
+						p, err := layout.Format(set, tile)
+						if err != nil {
+							t.Fatalf("%s %s: format %+v: %v", id, name, tile, err)
+						}
+						back, err := layout.Parse(set, p)
+						if err != nil || back != tile {
+							t.Fatalf("%s %s: %+v -> %q -> %+v (%v)", id, name, tile, p, back, err)
+						}
+					}
+				}
+				if _, err := layout.Format(set, Tile{Zoom: z, TileIndex: TileIndex{Col: width}}); err == nil {
+					t.Fatalf("%s %s: expected error for column outside matrix", id, name)
+				}
+			}
+		}
+	}
+	if _, err := PathLayoutByName("s3", ""); err == nil {
+		t.Fatalf("expected error for unknown layout")
+	}
+}
+
+func TestPathLayoutsParseErrors(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	cases := []struct {
+		layout TilePathLayout
+		set    *TileMatrixSet
+		path   string
+	}{
+		{XYZPathLayout{Ext: "png"}, set, "3/5/2.jpg"},
+		{XYZPathLayout{Ext: "png"}, set, "3/5.png"},
+		{XYZPathLayout{Ext: "png"}, set, "3/-5/2.png"},
+		{XYZPathLayout{Ext: "png"}, set, "3/8/2.png"},
+		{TMSPathLayout{}, set, "3/x/2"},
+		{QuadkeyPathLayout{}, set, "0124"},
+		{QuadkeyPathLayout{}, set, ""},
+		{QuadkeyPathLayout{}, crs84, "01"},
+		{ArcGISPathLayout{}, set, "L03/R00000002/X00000005"},
+		{ArcGISPathLayout{}, set, "L03/R0000000g/C00000005"},
+		{GeoWebCachePathLayout{}, set, "Other_03/1_1/05_05"},
+		{GeoWebCachePathLayout{}, set, "WebMercatorQuad_03/0_1/05_05"},
+		{GeoWebCachePathLayout{}, set, "WebMercatorQuad_03/1_1/0505"},
+		{TileCachePathLayout{}, set, "03/000/000/005/000/000/1005"},
+		{TileCachePathLayout{}, set, "03/000/000/005/000/005"},
+	}
+	for _, tc := range cases {
+		if tile, err := tc.layout.Parse(tc.set, tc.path); err == nil {
+			t.Fatalf("%T: expected error for %q, got %+v", tc.layout, tc.path, tile)
+		}
+	}
+	if _, err := (QuadkeyPathLayout{}).Format(set, Tile{}); err == nil {
+		t.Fatalf("expected error for zoom 0 quadkey")
+	}
+}
@@ -0,0 +1,156 @@
+package pmtiles
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Entry is a directory entry. Entries with RunLength > 0 address RunLength
+// consecutive tile ids sharing the same data; entries with RunLength 0 point
+// to a leaf directory.
+type Entry struct {
+	TileID    uint64
+	Offset    uint64
+	Length    uint32
+	RunLength uint32
+}
+
+// encodeDirectory serializes entries sorted by TileID, column by column:
+// count, tile id deltas, run lengths, lengths and offsets. An offset of 0
+// means the entry directly follows the previous one; other offsets are
+// stored plus one.
+func encodeDirectory(entries []Entry, c Compression) ([]byte, error) {
+	buf := binary.AppendUvarint(nil, uint64(len(entries)))
+	var last uint64
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, e.TileID-last)
+		last = e.TileID
+	}
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, uint64(e.RunLength))
+	}
+	for _, e := range entries {
+		buf = binary.AppendUvarint(buf, uint64(e.Length))
+	}
+	for i, e := range entries {
+		if i > 0 && e.Offset == entries[i-1].Offset+uint64(entries[i-1].Length) {
+			buf = binary.AppendUvarint(buf, 0)
+		} else {
+			buf = binary.AppendUvarint(buf, e.Offset+1)
+		}
+	}
+	return compress(c, buf)
+}
+
+// decodeDirectory is the inverse of encodeDirectory.
+func decodeDirectory(data []byte, c Compression) ([]Entry, error) {
+	raw, err := decompress(c, data)
+	if err != nil {
+		return nil, err
+	}
+	r := bytes.NewReader(raw)
+	next := func() (uint64, error) {
+		v, err := binary.ReadUvarint(r)
+		if err != nil {
+			return 0, fmt.Errorf("pmtiles: malformed directory: %w", err)
+		}
+		return v, nil
+	}
+	n, err := next()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(raw)) {
+		return nil, errors.New("pmtiles: malformed directory: entry count exceeds size")
+	}
+	entries := make([]Entry, n)
+	var last uint64
+	for i := range entries {
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		last += v
+		entries[i].TileID = last
+	}
+	for i := range entries {
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		entries[i].RunLength = uint32(v)
+	}
+	for i := range entries {
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		entries[i].Length = uint32(v)
+	}
+	for i := range entries {
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case v == 0 && i == 0:
+			return nil, errors.New("pmtiles: malformed directory: first entry continues no previous entry")
+		case v == 0:
+			entries[i].Offset = entries[i-1].Offset + uint64(entries[i-1].Length)
+		default:
+			entries[i].Offset = v - 1
+		}
+	}
+	return entries, nil
+}
+
+// findEntry returns the entry addressing id: a tile entry whose run covers
+// id, or the leaf directory entry whose range may contain it.
+func findEntry(entries []Entry, id uint64) (Entry, bool) {
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].TileID > id }) - 1
+	if i < 0 {
+		return Entry{}, false
+	}
+	e := entries[i]
+	if e.RunLength == 0 || id-e.TileID < uint64(e.RunLength) {
+		return e, true
+	}
+	return Entry{}, false
+}
+
+// buildDirectories serializes the entries as a root directory that fits
+// the first 16 KiB of the archive. If the entries do not fit, they are split
+// into leaf directories of growing size and the root points to the leaves.
+func buildDirectories(entries []Entry, c Compression) (root, leaves []byte, err error) {
+	root, err = encodeDirectory(entries, c)
+	if err != nil || len(root) <= maxRootLength {
+		return root, nil, err
+	}
+	for leafSize := 4096; ; leafSize += leafSize / 5 {
+		var rootEntries []Entry
+		var buf bytes.Buffer
+		for start := 0; start < len(entries); start += leafSize {
+			end := min(start+leafSize, len(entries))
+			leaf, err := encodeDirectory(entries[start:end], c)
+			if err != nil {
+				return nil, nil, err
+			}
+			rootEntries = append(rootEntries, Entry{
+				TileID: entries[start].TileID,
+				Offset: uint64(buf.Len()),
+				Length: uint32(len(leaf)),
+			})
+			buf.Write(leaf)
+		}
+		root, err = encodeDirectory(rootEntries, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(root) <= maxRootLength {
+			return root, buf.Bytes(), nil
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package pmtiles
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func TestDirectoryRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{TileID: 0, Offset: 0, Length: 10, RunLength: 1},
+		{TileID: 1, Offset: 10, Length: 5, RunLength: 3},
+		{TileID: 5, Offset: 0, Length: 10, RunLength: 1},
+		{TileID: 1000, Offset: 15, Length: 7, RunLength: 0},
+	}
+	for _, c := range []Compression{NoCompression, Gzip} {
+		b, err := encodeDirectory(entries, c)
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		got, err := decodeDirectory(b, c)
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if !reflect.DeepEqual(got, entries) {
+			t.Fatalf("compression %d: got %+v", c, got)
+		}
+	}
+	if _, err := encodeDirectory(entries, Brotli); err == nil {
+		t.Fatalf("expected unsupported compression error")
+	}
+	if _, err := decodeDirectory([]byte{5, 1}, NoCompression); err == nil {
+		t.Fatalf("expected error for truncated directory")
+	}
+	// One entry: tile 0, run 1, length 5 and an offset continuing the
+	// previous entry, which the first entry does not have.
+	if _, err := decodeDirectory([]byte{1, 0, 1, 5, 0}, NoCompression); err == nil {
+		t.Fatalf("expected error for first entry without offset")
+	}
+}
+
+func TestFindEntry(t *testing.T) {
+	entries := []Entry{
+		{TileID: 5, RunLength: 2},
+		{TileID: 10, RunLength: 0},
+	}
+	cases := []struct {
+		id   uint64
+		want uint64
+		ok   bool
+	}{
+		{4, 0, false},
+		{5, 5, true},
+		{6, 5, true},
+		{7, 0, false},
+		{10, 10, true},
+		{1 << 40, 10, true},
+	}
+	for _, tc := range cases {
+		e, ok := findEntry(entries, tc.id)
+		if ok != tc.ok || (ok && e.TileID != tc.want) {
+			t.Fatalf("findEntry(%d) = %+v, %v", tc.id, e, ok)
+		}
+	}
+}
+
+func TestBuildDirectoriesLeaves(t *testing.T) {
+	// Scattered ids and offsets do not compress well and overflow the root.
+	entries := make([]Entry, 20000)
+	for i := range entries {
+		entries[i] = Entry{TileID: uint64(i * 7), Offset: uint64(i * 1009 % 65521), Length: uint32(100 + i%50), RunLength: 1}
+	}
+	root, leaves, err := buildDirectories(entries, NoCompression)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(root) > maxRootLength || len(leaves) == 0 {
+		t.Fatalf("expected leaves and root <= %d bytes, got root %d leaves %d", maxRootLength, len(root), len(leaves))
+	}
+	rootEntries, err := decodeDirectory(root, NoCompression)
+	if err != nil {
+		t.Fatalf("decode root: %v", err)
+	}
+	var all []Entry
+	for _, e := range rootEntries {
+		if e.RunLength != 0 {
+			t.Fatalf("expected leaf pointer, got %+v", e)
+		}
+		leaf, err := decodeDirectory(leaves[e.Offset:e.Offset+uint64(e.Length)], NoCompression)
+		if err != nil {
+			t.Fatalf("decode leaf: %v", err)
+		}
+		all = append(all, leaf...)
+	}
+	if !reflect.DeepEqual(all, entries) {
+		t.Fatalf("leaves do not contain all entries")
+	}
+
+	root, leaves, err = buildDirectories(entries[:10], Gzip)
+	if err != nil || leaves != nil || len(root) == 0 {
+		t.Fatalf("expected root-only directory, got root %d leaves %d err=%v", len(root), len(leaves), err)
+	}
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	h := Header{
+		RootOffset:          127,
+		RootLength:          42,
+		MetadataOffset:      169,
+		MetadataLength:      20,
+		LeafDirectoryOffset: 189,
+		TileDataOffset:      189,
+		TileDataLength:      1 << 33,
+		AddressedTiles:      7,
+		TileEntries:         5,
+		TileContents:        4,
+		Clustered:           true,
+		InternalCompression: Gzip,
+		TileCompression:     Gzip,
+		TileType:            MVT,
+		MinZoom:             2,
+		MaxZoom:             14,
+		Bounds:              grid.Bounds{MinX: -180, MinY: -85.0511287, MaxX: 180, MaxY: 85.0511287},
+		Center:              [3]float64{11.5754, 48.1372, 2},
+	}
+	b, err := h.MarshalBinary()
+	if err != nil || len(b) != HeaderLength || string(b[:7]) != "PMTiles" || b[7] != 3 {
+		t.Fatalf("unexpected header bytes %v err=%v", b, err)
+	}
+	var got Header
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got != h {
+		t.Fatalf("got %+v\nwant %+v", got, h)
+	}
+
+	b[7] = 2
+	if err := got.UnmarshalBinary(b); err == nil {
+		t.Fatalf("expected error for version 2")
+	}
+	if _, err := (Header{MinZoom: 3, MaxZoom: 2}).MarshalBinary(); err == nil {
+		t.Fatalf("expected error for invalid zoom range")
+	}
+}
@@ -0,0 +1,189 @@
+package pmtiles
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// HeaderLength is the size of the fixed PMTiles v3 header in bytes.
+const HeaderLength = 127
+
+// maxRootLength is the space for the root directory in the first 16 KiB of
+// an archive, after the header.
+const maxRootLength = 16384 - HeaderLength
+
+var magic = []byte("PMTiles")
+
+// ErrUnsupportedCompression is returned for compression types this package
+// cannot encode or decode.
+var ErrUnsupportedCompression = errors.New("pmtiles: unsupported compression")
+
+// Compression is the compression of directories, metadata or tile data.
+type Compression uint8
+
+const (
+	UnknownCompression Compression = iota
+	NoCompression
+	Gzip
+	Brotli
+	Zstd
+)
+
+// TileType is the format of the tile data.
+type TileType uint8
+
+const (
+	UnknownTileType TileType = iota
+	MVT
+	PNG
+	JPEG
+	WebP
+	AVIF
+)
+
+// Header is the fixed-size header at the start of a PMTiles v3 archive.
+// Offsets are absolute; leaf directory entries are relative to
+// LeafDirectoryOffset and tile entries to TileDataOffset.
+type Header struct {
+	RootOffset          uint64
+	RootLength          uint64
+	MetadataOffset      uint64
+	MetadataLength      uint64
+	LeafDirectoryOffset uint64
+	LeafDirectoryLength uint64
+	TileDataOffset      uint64
+	TileDataLength      uint64
+	AddressedTiles      uint64
+	TileEntries         uint64
+	TileContents        uint64
+	Clustered           bool
+	InternalCompression Compression
+	TileCompression     Compression
+	TileType            TileType
+	MinZoom             int
+	MaxZoom             int
+	// Bounds is the extent of the tiles in lon/lat (degrees).
+	Bounds grid.Bounds
+	// Center is lon, lat and zoom of the default view.
+	Center [3]float64
+}
+
+// MarshalBinary encodes the header. Coordinates are stored as integers in
+// units of 1e-7 degrees.
+func (h Header) MarshalBinary() ([]byte, error) {
+	if h.MinZoom < 0 || h.MaxZoom > MaxZoom || h.MinZoom > h.MaxZoom {
+		return nil, fmt.Errorf("pmtiles: invalid zoom range %d..%d", h.MinZoom, h.MaxZoom)
+	}
+	b := make([]byte, HeaderLength)
+	copy(b, magic)
+	b[7] = 3
+	le := binary.LittleEndian
+	for i, v := range []uint64{
+		h.RootOffset, h.RootLength, h.MetadataOffset, h.MetadataLength,
+		h.LeafDirectoryOffset, h.LeafDirectoryLength, h.TileDataOffset, h.TileDataLength,
+		h.AddressedTiles, h.TileEntries, h.TileContents,
+	} {
+		le.PutUint64(b[8+8*i:], v)
+	}
+	if h.Clustered {
+		b[96] = 1
+	}
+	b[97] = byte(h.InternalCompression)
+	b[98] = byte(h.TileCompression)
+	b[99] = byte(h.TileType)
+	b[100] = byte(h.MinZoom)
+	b[101] = byte(h.MaxZoom)
+	le.PutUint32(b[102:], uint32(e7(h.Bounds.MinX)))
+	le.PutUint32(b[106:], uint32(e7(h.Bounds.MinY)))
+	le.PutUint32(b[110:], uint32(e7(h.Bounds.MaxX)))
+	le.PutUint32(b[114:], uint32(e7(h.Bounds.MaxY)))
+	b[118] = byte(h.Center[2])
+	le.PutUint32(b[119:], uint32(e7(h.Center[0])))
+	le.PutUint32(b[123:], uint32(e7(h.Center[1])))
+	return b, nil
+}
+
+// UnmarshalBinary decodes a PMTiles v3 header.
+func (h *Header) UnmarshalBinary(b []byte) error {
+	if len(b) < HeaderLength {
+		return fmt.Errorf("pmtiles: header too short (%d bytes)", len(b))
+	}
+	if !bytes.Equal(b[:7], magic) {
+		return errors.New("pmtiles: missing magic number")
+	}
+	if b[7] != 3 {
+		return fmt.Errorf("pmtiles: unsupported version %d", b[7])
+	}
+	le := binary.LittleEndian
+	u := func(i int) uint64 { return le.Uint64(b[8+8*i:]) }
+	deg := func(off int) float64 { return float64(int32(le.Uint32(b[off:]))) / 1e7 }
+	*h = Header{
+		RootOffset:          u(0),
+		RootLength:          u(1),
+		MetadataOffset:      u(2),
+		MetadataLength:      u(3),
+		LeafDirectoryOffset: u(4),
+		LeafDirectoryLength: u(5),
+		TileDataOffset:      u(6),
+		TileDataLength:      u(7),
+		AddressedTiles:      u(8),
+		TileEntries:         u(9),
+		TileContents:        u(10),
+		Clustered:           b[96] == 1,
+		InternalCompression: Compression(b[97]),
+		TileCompression:     Compression(b[98]),
+		TileType:            TileType(b[99]),
+		MinZoom:             int(b[100]),
+		MaxZoom:             int(b[101]),
+		Bounds:              grid.Bounds{MinX: deg(102), MinY: deg(106), MaxX: deg(110), MaxY: deg(114)},
+		Center:              [3]float64{deg(119), deg(123), float64(b[118])},
+	}
+	return nil
+}
+
+func e7(deg float64) int32 {
+	return int32(math.Round(deg * 1e7))
+}
+
+// compress encodes data with the given compression. Only NoCompression and
+// Gzip are supported.
+func compress(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case NoCompression:
+		return data, nil
+	case Gzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("%w: %d", ErrUnsupportedCompression, c)
+}
+
+// decompress decodes data with the given compression.
+func decompress(c Compression, data []byte) ([]byte, error) {
+	switch c {
+	case NoCompression:
+		return data, nil
+	case Gzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	}
+	return nil, fmt.Errorf("%w: %d", ErrUnsupportedCompression, c)
+}
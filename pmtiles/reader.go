@@ -0,0 +1,223 @@
+// Package pmtiles reads and writes PMTiles v3 archives of WebMercatorQuad
+// tiles. Tiles are addressed by grid.Tile with XYZ columns and rows, which
+// match the WebMercatorQuad row numbering; TileID and TileFromID convert to
+// and from the Hilbert-curve tile ids used inside the archive.
+//
+// Directories and metadata can be uncompressed or gzip-compressed. Tile data
+// is stored and returned as given; Header.TileCompression describes it.
+package pmtiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// ErrTileNotFound is returned by Reader.Tile for tiles not in the archive.
+var ErrTileNotFound = errors.New("pmtiles: tile not found")
+
+// maxDirectoryDepth bounds the number of directory levels followed when
+// looking up a tile: the root and up to three leaf levels.
+const maxDirectoryDepth = 4
+
+// Reader reads tiles from a PMTiles archive.
+type Reader struct {
+	r      io.ReaderAt
+	size   int64 // -1 if unknown
+	closer io.Closer
+	header Header
+	root   []Entry
+}
+
+// Open opens the archive at path.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewReader reads the header and root directory of an archive. Lengths in the
+// archive are checked against the size of r if it has a Size method, like
+// bytes.Reader, or a Stat method, like os.File.
+func NewReader(r io.ReaderAt) (*Reader, error) {
+	b := make([]byte, HeaderLength)
+	if n, err := r.ReadAt(b, 0); n < len(b) {
+		return nil, fmt.Errorf("pmtiles: read header: %w", err)
+	}
+	pr := &Reader{r: r, size: sourceSize(r)}
+	if err := pr.header.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	if pr.header.RootLength > maxRootLength {
+		return nil, fmt.Errorf("pmtiles: root directory length %d exceeds %d", pr.header.RootLength, maxRootLength)
+	}
+	root, err := pr.directory(pr.header.RootOffset, pr.header.RootLength)
+	if err != nil {
+		return nil, err
+	}
+	pr.root = root
+	return pr, nil
+}
+
+// Header returns the archive header.
+func (r *Reader) Header() Header { return r.header }
+
+// Metadata returns the decoded JSON metadata object.
+func (r *Reader) Metadata() (map[string]any, error) {
+	b, err := r.read(r.header.MetadataOffset, r.header.MetadataLength)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := decompress(r.header.InternalCompression, b)
+	if err != nil {
+		return nil, err
+	}
+	md := map[string]any{}
+	if len(raw) == 0 {
+		return md, nil
+	}
+	if err := json.Unmarshal(raw, &md); err != nil {
+		return nil, fmt.Errorf("pmtiles: metadata: %w", err)
+	}
+	return md, nil
+}
+
+// Tile returns the data of a tile, or ErrTileNotFound.
+func (r *Reader) Tile(tile grid.Tile) ([]byte, error) {
+	id, err := TileID(tile)
+	if err != nil {
+		return nil, err
+	}
+	if tile.Zoom < r.header.MinZoom || tile.Zoom > r.header.MaxZoom {
+		return nil, fmt.Errorf("%w: %d/%d/%d", ErrTileNotFound, tile.Zoom, tile.Col, tile.Row)
+	}
+	entries := r.root
+	for depth := 0; depth < maxDirectoryDepth; depth++ {
+		e, ok := findEntry(entries, id)
+		if !ok {
+			break
+		}
+		if e.RunLength > 0 {
+			return r.read(r.header.TileDataOffset+e.Offset, uint64(e.Length))
+		}
+		if entries, err = r.directory(r.header.LeafDirectoryOffset+e.Offset, uint64(e.Length)); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w: %d/%d/%d", ErrTileNotFound, tile.Zoom, tile.Col, tile.Row)
+}
+
+// Entries returns all tile entries in tile id order, following leaf
+// directories.
+func (r *Reader) Entries() ([]Entry, error) {
+	return r.collect(r.root, 0, nil)
+}
+
+func (r *Reader) collect(entries []Entry, depth int, out []Entry) ([]Entry, error) {
+	if depth >= maxDirectoryDepth {
+		return nil, errors.New("pmtiles: directories nested too deeply")
+	}
+	for _, e := range entries {
+		if e.RunLength > 0 {
+			out = append(out, e)
+			continue
+		}
+		leaf, err := r.directory(r.header.LeafDirectoryOffset+e.Offset, uint64(e.Length))
+		if err != nil {
+			return nil, err
+		}
+		if out, err = r.collect(leaf, depth+1, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Tiles lists all tiles in the archive in tile id order, expanding run
+// lengths.
+func (r *Reader) Tiles() (grid.TilesList, error) {
+	entries, err := r.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var tiles grid.TilesList
+	for _, e := range entries {
+		for i := uint64(0); i < uint64(e.RunLength); i++ {
+			tile, err := TileFromID(e.TileID + i)
+			if err != nil {
+				return nil, err
+			}
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles, nil
+}
+
+// Close closes the file opened by Open. It is a no-op for NewReader.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+func (r *Reader) directory(offset, length uint64) ([]Entry, error) {
+	b, err := r.read(offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDirectory(b, r.header.InternalCompression)
+}
+
+// read reads length bytes at offset. Offsets and lengths come from the
+// archive, so they are checked against the source size before allocating;
+// if the size is unknown the buffer grows only as data is read.
+func (r *Reader) read(offset, length uint64) ([]byte, error) {
+	if offset > math.MaxInt64 || length > math.MaxInt64-offset {
+		return nil, fmt.Errorf("pmtiles: read %d bytes at %d: out of range", length, offset)
+	}
+	if r.size < 0 {
+		b, err := io.ReadAll(io.NewSectionReader(r.r, int64(offset), int64(length)))
+		if err == nil && uint64(len(b)) < length {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pmtiles: read %d bytes at %d: %w", length, offset, err)
+		}
+		return b, nil
+	}
+	if offset+length > uint64(r.size) {
+		return nil, fmt.Errorf("pmtiles: read %d bytes at %d: beyond end of archive (%d bytes)", length, offset, r.size)
+	}
+	b := make([]byte, length)
+	if n, err := r.r.ReadAt(b, int64(offset)); n < len(b) {
+		return nil, fmt.Errorf("pmtiles: read %d bytes at %d: %w", length, offset, err)
+	}
+	return b, nil
+}
+
+// sourceSize returns the size of r, or -1 if r does not report it.
+func sourceSize(r io.ReaderAt) int64 {
+	switch s := r.(type) {
+	case interface{ Size() int64 }:
+		return s.Size()
+	case interface{ Stat() (os.FileInfo, error) }:
+		if fi, err := s.Stat(); err == nil && fi.Mode().IsRegular() {
+			return fi.Size()
+		}
+	}
+	return -1
+}
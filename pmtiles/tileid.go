@@ -0,0 +1,20 @@
+package pmtiles
+
+import (
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// MaxZoom is the highest zoom level addressable by a PMTiles tile id.
+const MaxZoom = grid.MaxCurveOrder
+
+// TileID returns the PMTiles tile id of a WebMercatorQuad tile: the tiles of
+// all lower zoom levels followed by the position of the tile on the Hilbert
+// curve of its zoom level. Col and Row are XYZ x and y.
+func TileID(tile grid.Tile) (uint64, error) {
+	return grid.CurveTileID(grid.HilbertCurve, tile, 0)
+}
+
+// TileFromID returns the WebMercatorQuad tile of a PMTiles tile id.
+func TileFromID(id uint64) (grid.Tile, error) {
+	return grid.CurveTileFromID(grid.HilbertCurve, id, 0)
+}
@@ -0,0 +1,92 @@
+package pmtiles
+
+import (
+	"testing"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+func TestTileID(t *testing.T) {
+	cases := []struct {
+		zoom, col, row int
+		id             uint64
+	}{
+		{0, 0, 0, 0},
+		{1, 0, 0, 1},
+		{1, 0, 1, 2},
+		{1, 1, 1, 3},
+		{1, 1, 0, 4},
+		{2, 0, 0, 5},
+		{12, 3423, 1763, 19078479},
+	}
+	for _, tc := range cases {
+		tile := grid.Tile{Zoom: tc.zoom, TileIndex: grid.TileIndex{Col: tc.col, Row: tc.row}}
+		id, err := TileID(tile)
+		if err != nil || id != tc.id {
+			t.Fatalf("TileID(%+v) = %d, %v; want %d", tile, id, err, tc.id)
+		}
+		back, err := TileFromID(tc.id)
+		if err != nil || back != tile {
+			t.Fatalf("TileFromID(%d) = %+v, %v; want %+v", tc.id, back, err, tile)
+		}
+	}
+}
+
+func TestTileIDRoundTrip(t *testing.T) {
+	// Tile 0/0 of each zoom level starts its ids on the curve.
+	start := func(z int) uint64 {
+		id, err := grid.CurveTileID(grid.HilbertCurve, grid.Tile{Zoom: z}, 0)
+		if err != nil {
+			t.Fatalf("zoom %d start: %v", z, err)
+		}
+		return id
+	}
+	for z := 0; z <= 4; z++ {
+		n := 1 << z
+		seen := map[uint64]bool{}
+		for x := 0; x < n; x++ {
+			for y := 0; y < n; y++ {
+				tile := grid.Tile{Zoom: z, TileIndex: grid.TileIndex{Col: x, Row: y}}
+				id, err := TileID(tile)
+				if err != nil {
+					t.Fatalf("TileID(%+v): %v", tile, err)
+				}
+				if id < start(z) || id >= start(z+1) || seen[id] {
+					t.Fatalf("unexpected id %d for %+v", id, tile)
+				}
+				seen[id] = true
+				if back, err := TileFromID(id); err != nil || back != tile {
+					t.Fatalf("round trip %+v -> %d -> %+v (%v)", tile, id, back, err)
+				}
+			}
+		}
+	}
+	max := grid.Tile{Zoom: MaxZoom, TileIndex: grid.TileIndex{Col: 1<<MaxZoom - 1, Row: 1<<MaxZoom - 1}}
+	id, err := TileID(max)
+	if err != nil {
+		t.Fatalf("TileID(max): %v", err)
+	}
+	if back, err := TileFromID(id); err != nil || back != max {
+		t.Fatalf("round trip max zoom: %+v (%v)", back, err)
+	}
+}
+
+func TestTileIDErrors(t *testing.T) {
+	for _, tile := range []grid.Tile{
+		{Zoom: -1},
+		{Zoom: MaxZoom + 1},
+		{Zoom: 1, TileIndex: grid.TileIndex{Col: 2}},
+		{Zoom: 1, TileIndex: grid.TileIndex{Row: -1}},
+	} {
+		if _, err := TileID(tile); err == nil {
+			t.Fatalf("expected error for %+v", tile)
+		}
+	}
+	last, err := grid.CurveTileID(grid.HilbertCurve, grid.Tile{Zoom: MaxZoom, TileIndex: grid.TileIndex{Col: 1<<MaxZoom - 1}}, 0)
+	if err != nil {
+		t.Fatalf("last id: %v", err)
+	}
+	if _, err := TileFromID(last + 1); err == nil {
+		t.Fatalf("expected error for id beyond max zoom")
+	}
+}
@@ -0,0 +1,261 @@
+package pmtiles
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// ErrNotWebMercatorQuad is returned by Create for TileMatrixSets other than
+// WebMercatorQuad, which is the only tiling scheme PMTiles supports.
+var ErrNotWebMercatorQuad = errors.New("pmtiles: only WebMercatorQuad is supported")
+
+// Options configures a Writer.
+type Options struct {
+	TileType TileType
+	// TileCompression describes the tile data as written; tiles are stored
+	// as given.
+	TileCompression Compression
+	// InternalCompression is used for directories and metadata. The zero
+	// value selects Gzip.
+	InternalCompression Compression
+	// Metadata is written as the JSON metadata object.
+	Metadata map[string]any
+}
+
+// Writer builds a clustered PMTiles archive. Tile data is spooled to a
+// temporary file next to the archive; directories and the header are
+// written on Close. Tiles with identical data are stored once, and runs of
+// consecutive tile ids with the same data share one directory entry.
+type Writer struct {
+	path     string
+	set      *gocantile.TileMatrixSet
+	opts     Options
+	tmp      *os.File
+	tmpLen   uint64
+	entries  []Entry
+	contents map[[sha256.Size]byte]Entry
+	ranges   map[int]grid.TileRange
+}
+
+// Create starts a new archive at path. The file must not exist.
+func Create(path string, set *gocantile.TileMatrixSet, opts Options) (*Writer, error) {
+	if !set.IsWebMercatorQuad() {
+		return nil, ErrNotWebMercatorQuad
+	}
+	if opts.InternalCompression == UnknownCompression {
+		opts.InternalCompression = Gzip
+	}
+	if _, err := compress(opts.InternalCompression, nil); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("pmtiles: %s already exists", path)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".pmtiles-*")
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		path:     path,
+		set:      set,
+		opts:     opts,
+		tmp:      tmp,
+		contents: map[[sha256.Size]byte]Entry{},
+		ranges:   map[int]grid.TileRange{},
+	}, nil
+}
+
+// WriteTile adds the data of a tile. Each tile may be written once.
+func (w *Writer) WriteTile(tile grid.Tile, data []byte) error {
+	if _, _, _, err := w.set.TileToXYZ(tile); err != nil {
+		return err
+	}
+	id, err := TileID(tile)
+	if err != nil {
+		return err
+	}
+	if uint64(len(data)) > math.MaxUint32 {
+		return fmt.Errorf("pmtiles: tile %d/%d/%d too large", tile.Zoom, tile.Col, tile.Row)
+	}
+	sum := sha256.Sum256(data)
+	content, ok := w.contents[sum]
+	if !ok {
+		if _, err := w.tmp.Write(data); err != nil {
+			return err
+		}
+		content = Entry{Offset: w.tmpLen, Length: uint32(len(data))}
+		w.contents[sum] = content
+		w.tmpLen += uint64(len(data))
+	}
+	w.entries = append(w.entries, Entry{TileID: id, Offset: content.Offset, Length: content.Length, RunLength: 1})
+
+	r, ok := w.ranges[tile.Zoom]
+	if !ok {
+		r = grid.TileRange{MinCol: tile.Col, MaxCol: tile.Col, MinRow: tile.Row, MaxRow: tile.Row}
+	}
+	r.MinCol, r.MaxCol = min(r.MinCol, tile.Col), max(r.MaxCol, tile.Col)
+	r.MinRow, r.MaxRow = min(r.MinRow, tile.Row), max(r.MaxRow, tile.Row)
+	w.ranges[tile.Zoom] = r
+	return nil
+}
+
+// WriteTiles writes tiles, e.g. a TilesForGeometry coverage, in tile id
+// order. data is called once per tile; tiles for which it returns nil data
+// are skipped.
+func (w *Writer) WriteTiles(tiles grid.TilesList, data func(grid.Tile) ([]byte, error)) error {
+	ids := make([]uint64, len(tiles))
+	order := make([]int, len(tiles))
+	for i, tile := range tiles {
+		id, err := TileID(tile)
+		if err != nil {
+			return err
+		}
+		ids[i], order[i] = id, i
+	}
+	sort.Slice(order, func(a, b int) bool { return ids[order[a]] < ids[order[b]] })
+	for _, i := range order {
+		b, err := data(tiles[i])
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			continue
+		}
+		if err := w.WriteTile(tiles[i], b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the archive and removes the temporary tile data.
+func (w *Writer) Close() error {
+	err := w.finish()
+	if cerr := w.tmp.Close(); err == nil {
+		err = cerr
+	}
+	if rerr := os.Remove(w.tmp.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+func (w *Writer) finish() error {
+	sort.SliceStable(w.entries, func(i, j int) bool { return w.entries[i].TileID < w.entries[j].TileID })
+
+	// Lay out tile data in tile id order and merge runs of identical tiles.
+	offsets := make(map[uint64]uint64, len(w.contents))
+	var copies []Entry
+	var dataLen uint64
+	var entries []Entry
+	for i, e := range w.entries {
+		if i > 0 && e.TileID == w.entries[i-1].TileID {
+			tile, _ := TileFromID(e.TileID)
+			return fmt.Errorf("pmtiles: tile %d/%d/%d written twice", tile.Zoom, tile.Col, tile.Row)
+		}
+		off, ok := offsets[e.Offset]
+		if !ok {
+			off = dataLen
+			offsets[e.Offset] = off
+			copies = append(copies, e)
+			dataLen += uint64(e.Length)
+		}
+		if n := len(entries); n > 0 {
+			last := &entries[n-1]
+			if last.Offset == off && last.TileID+uint64(last.RunLength) == e.TileID {
+				last.RunLength++
+				continue
+			}
+		}
+		entries = append(entries, Entry{TileID: e.TileID, Offset: off, Length: e.Length, RunLength: 1})
+	}
+
+	root, leaves, err := buildDirectories(entries, w.opts.InternalCompression)
+	if err != nil {
+		return err
+	}
+	md := w.opts.Metadata
+	if md == nil {
+		md = map[string]any{}
+	}
+	rawMetadata, err := json.Marshal(md)
+	if err != nil {
+		return fmt.Errorf("pmtiles: metadata: %w", err)
+	}
+	metadata, err := compress(w.opts.InternalCompression, rawMetadata)
+	if err != nil {
+		return err
+	}
+
+	h := Header{
+		RootOffset:          HeaderLength,
+		RootLength:          uint64(len(root)),
+		AddressedTiles:      uint64(len(w.entries)),
+		TileEntries:         uint64(len(entries)),
+		TileContents:        uint64(len(copies)),
+		Clustered:           true,
+		InternalCompression: w.opts.InternalCompression,
+		TileCompression:     w.opts.TileCompression,
+		TileType:            w.opts.TileType,
+	}
+	h.MetadataOffset = h.RootOffset + h.RootLength
+	h.MetadataLength = uint64(len(metadata))
+	h.LeafDirectoryOffset = h.MetadataOffset + h.MetadataLength
+	h.LeafDirectoryLength = uint64(len(leaves))
+	h.TileDataOffset = h.LeafDirectoryOffset + h.LeafDirectoryLength
+	h.TileDataLength = dataLen
+	if err := w.fillExtent(&h); err != nil {
+		return err
+	}
+	header, err := h.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	for _, b := range [][]byte{header, root, metadata, leaves} {
+		if _, err := f.Write(b); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	for _, c := range copies {
+		if _, err := io.Copy(f, io.NewSectionReader(w.tmp, int64(c.Offset), int64(c.Length))); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// fillExtent sets the zoom range, bounds and center of the header from the
+// written tiles. The center is the middle of the bounds at the minimum zoom.
+func (w *Writer) fillExtent(h *Header) error {
+	if len(w.ranges) == 0 {
+		return nil
+	}
+	h.MinZoom, h.MaxZoom = math.MaxInt, math.MinInt
+	for z := range w.ranges {
+		h.MinZoom, h.MaxZoom = min(h.MinZoom, z), max(h.MaxZoom, z)
+	}
+	b, err := w.set.TileRangesBounds(w.ranges, nil)
+	if err != nil {
+		return err
+	}
+	h.Bounds = b
+	h.Center = [3]float64{(b.MinX + b.MaxX) / 2, (b.MinY + b.MaxY) / 2, float64(h.MinZoom)}
+	return nil
+}
@@ -0,0 +1,268 @@
+package pmtiles
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hafenkran/gocantile"
+	"github.com/hafenkran/gocantile/grid"
+	"github.com/paulmach/orb"
+)
+
+func loadSet(t *testing.T, id string) *gocantile.TileMatrixSet {
+	t.Helper()
+	set, err := gocantile.LoadTileMatrixSet(id)
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	return set
+}
+
+func TestWriteReadArchive(t *testing.T) {
+	set := loadSet(t, "WebMercatorQuad")
+	path := filepath.Join(t.TempDir(), "test.pmtiles")
+	w, err := Create(path, set, Options{TileType: PNG, Metadata: map[string]any{"name": "test"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// Zoom 1: both western tiles share data and become one run.
+	tiles := map[grid.Tile][]byte{
+		{Zoom: 1, TileIndex: grid.TileIndex{Col: 0, Row: 0}}: []byte("west"),
+		{Zoom: 1, TileIndex: grid.TileIndex{Col: 0, Row: 1}}: []byte("west"),
+		{Zoom: 1, TileIndex: grid.TileIndex{Col: 1, Row: 0}}: []byte("north-east"),
+		{Zoom: 2, TileIndex: grid.TileIndex{Col: 3, Row: 3}}: []byte("south-east"),
+	}
+	for tile, data := range tiles {
+		if err := w.WriteTile(tile, data); err != nil {
+			t.Fatalf("write %+v: %v", tile, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	h := r.Header()
+	if h.MinZoom != 1 || h.MaxZoom != 2 || !h.Clustered || h.TileType != PNG || h.InternalCompression != Gzip {
+		t.Fatalf("unexpected header %+v", h)
+	}
+	if h.AddressedTiles != 4 || h.TileEntries != 3 || h.TileContents != 3 {
+		t.Fatalf("unexpected counts %+v", h)
+	}
+	const maxLat = 85.0511287
+	if math.Abs(h.Bounds.MinX+180) > 1e-6 || math.Abs(h.Bounds.MaxX-180) > 1e-6 ||
+		math.Abs(h.Bounds.MinY+maxLat) > 1e-6 || math.Abs(h.Bounds.MaxY-maxLat) > 1e-6 {
+		t.Fatalf("unexpected bounds %+v", h.Bounds)
+	}
+	if h.Center != [3]float64{0, 0, 1} {
+		t.Fatalf("unexpected center %v", h.Center)
+	}
+	for tile, want := range tiles {
+		got, err := r.Tile(tile)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("tile %+v: %q err=%v", tile, got, err)
+		}
+	}
+	if _, err := r.Tile(grid.Tile{Zoom: 1, TileIndex: grid.TileIndex{Col: 1, Row: 1}}); !errors.Is(err, ErrTileNotFound) {
+		t.Fatalf("expected ErrTileNotFound, got %v", err)
+	}
+	if _, err := r.Tile(grid.Tile{Zoom: 3}); !errors.Is(err, ErrTileNotFound) {
+		t.Fatalf("expected ErrTileNotFound outside zoom range, got %v", err)
+	}
+	list, err := r.Tiles()
+	if err != nil || len(list) != len(tiles) {
+		t.Fatalf("unexpected tiles %+v err=%v", list, err)
+	}
+	md, err := r.Metadata()
+	if err != nil || md["name"] != "test" {
+		t.Fatalf("unexpected metadata %v err=%v", md, err)
+	}
+}
+
+func TestWriteTilesCoverage(t *testing.T) {
+	set := loadSet(t, "WebMercatorQuad")
+	// A box around Munich in EPSG:3857.
+	box := orb.Bound{Min: orb.Point{1250000, 6080000}, Max: orb.Point{1330000, 6150000}}.ToPolygon()
+	coverage, err := set.TilesForGeometry(box, 0, 12, 0)
+	if err != nil {
+		t.Fatalf("coverage: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "coverage.pmtiles")
+	w, err := Create(path, set, Options{TileType: MVT, InternalCompression: NoCompression})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	err = w.WriteTiles(coverage, func(tile grid.Tile) ([]byte, error) {
+		if tile.Zoom == 0 {
+			return nil, nil
+		}
+		return []byte(fmt.Sprintf("%d/%d/%d", tile.Zoom, tile.Col, tile.Row)), nil
+	})
+	if err != nil {
+		t.Fatalf("write tiles: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	if h := r.Header(); h.MinZoom != 1 || h.MaxZoom != 12 || h.AddressedTiles != uint64(len(coverage)-1) {
+		t.Fatalf("unexpected header %+v", h)
+	}
+	for _, tile := range coverage[1:] {
+		got, err := r.Tile(tile)
+		if err != nil || string(got) != fmt.Sprintf("%d/%d/%d", tile.Zoom, tile.Col, tile.Row) {
+			t.Fatalf("tile %+v: %q err=%v", tile, got, err)
+		}
+	}
+	list, err := r.Tiles()
+	if err != nil {
+		t.Fatalf("tiles: %v", err)
+	}
+	var last uint64
+	for i, tile := range list {
+		id, _ := TileID(tile)
+		if i > 0 && id <= last {
+			t.Fatalf("tiles not in id order at %d", i)
+		}
+		last = id
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Create(filepath.Join(dir, "a.pmtiles"), loadSet(t, "WorldCRS84Quad"), Options{}); !errors.Is(err, ErrNotWebMercatorQuad) {
+		t.Fatalf("expected ErrNotWebMercatorQuad, got %v", err)
+	}
+	set := loadSet(t, "WebMercatorQuad")
+	if _, err := Create(filepath.Join(dir, "b.pmtiles"), set, Options{InternalCompression: Zstd}); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Fatalf("expected ErrUnsupportedCompression, got %v", err)
+	}
+
+	path := filepath.Join(dir, "dup.pmtiles")
+	w, err := Create(path, set, Options{})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	tile := grid.Tile{Zoom: 1}
+	if err := w.WriteTile(tile, []byte("a")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.WriteTile(grid.Tile{Zoom: 1, TileIndex: grid.TileIndex{Col: 2}}, []byte("a")); err == nil {
+		t.Fatalf("expected error for tile outside matrix")
+	}
+	if err := w.WriteTile(tile, []byte("b")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.Close(); err == nil {
+		t.Fatalf("expected error for duplicate tile")
+	}
+}
+
+func TestWriteReadLeafDirectories(t *testing.T) {
+	set := loadSet(t, "WebMercatorQuad")
+	path := filepath.Join(t.TempDir(), "leaves.pmtiles")
+	w, err := Create(path, set, Options{InternalCompression: NoCompression})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// All 16384 tiles of zoom 7 with distinct data do not fit the root.
+	var tiles grid.TilesList
+	for x := 0; x < 128; x++ {
+		for y := 0; y < 128; y++ {
+			tiles = append(tiles, grid.Tile{Zoom: 7, TileIndex: grid.TileIndex{Col: x, Row: y}})
+		}
+	}
+	data := func(tile grid.Tile) ([]byte, error) { return []byte{byte(tile.Col), byte(tile.Row)}, nil }
+	if err := w.WriteTiles(tiles, data); err != nil {
+		t.Fatalf("write tiles: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer r.Close()
+	if r.Header().LeafDirectoryLength == 0 {
+		t.Fatalf("expected leaf directories")
+	}
+	for _, tile := range []grid.Tile{tiles[0], tiles[5000], tiles[len(tiles)-1]} {
+		got, err := r.Tile(tile)
+		if err != nil || !bytes.Equal(got, []byte{byte(tile.Col), byte(tile.Row)}) {
+			t.Fatalf("tile %+v: %v err=%v", tile, got, err)
+		}
+	}
+	list, err := r.Tiles()
+	if err != nil || len(list) != len(tiles) {
+		t.Fatalf("expected %d tiles, got %d err=%v", len(tiles), len(list), err)
+	}
+}
+
+func TestReaderRejectsLengthsBeyondArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.pmtiles")
+	w, err := Create(path, loadSet(t, "WebMercatorQuad"), Options{})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := w.WriteTile(grid.Tile{Zoom: 1}, []byte("a")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	corrupt := func(edit func(h *Header)) []byte {
+		var h Header
+		if err := h.UnmarshalBinary(data[:HeaderLength]); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		edit(&h)
+		b, err := h.MarshalBinary()
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return append(b, data[HeaderLength:]...)
+	}
+
+	b := corrupt(func(h *Header) { h.RootLength = maxRootLength + 1 })
+	if _, err := NewReader(bytes.NewReader(b)); err == nil {
+		t.Fatalf("expected error for oversized root directory")
+	}
+
+	b = corrupt(func(h *Header) { h.MetadataLength = 1 << 40 })
+	r, err := NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("new reader: %v", err)
+	}
+	if _, err := r.Metadata(); err == nil {
+		t.Fatalf("expected error for metadata beyond the archive")
+	}
+	// Without a size the read fails at the end of the data instead.
+	r, err = NewReader(struct{ io.ReaderAt }{bytes.NewReader(b)})
+	if err != nil {
+		t.Fatalf("new reader: %v", err)
+	}
+	if _, err := r.Metadata(); err == nil {
+		t.Fatalf("expected error for metadata beyond the archive")
+	}
+}
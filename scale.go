@@ -0,0 +1,106 @@
+package gocantile
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// metersPerInch converts a DPI into a rendering pixel size.
+const metersPerInch = 0.0254
+
+// GroundResolution returns the true ground resolution in meters per pixel of
+// the tile matrix at zoom at lon/lat (degrees): the geometric mean of the
+// resolutions along the x-axis and the y-axis of GroundResolutionXY, i.e. the
+// square root of the ground area of a pixel. WebMercatorQuad, being
+// conformal, gives cellSize*cos(lat) along both axes. If p is nil, a
+// projector is created from the TMS CRS.
+func (t *TileMatrixSet) GroundResolution(zoom int, lon, lat float64, p grid.Projector) (float64, error) {
+	x, y, err := t.GroundResolutionXY(zoom, lon, lat, p)
+	if err != nil {
+		return 0, err
+	}
+	return math.Sqrt(x * y), nil
+}
+
+// GroundResolutionXY returns the true ground resolution in meters per pixel
+// along the x-axis and the y-axis of the CRS. They differ in sets that are not
+// conformal, e.g. WorldCRS84Quad pixels at 60°N are half as wide as they are
+// high.
+func (t *TileMatrixSet) GroundResolutionXY(zoom int, lon, lat float64, p grid.Projector) (float64, float64, error) {
+	cellSize, err := t.ResolutionForZoom(zoom)
+	if err != nil {
+		return 0, 0, err
+	}
+	crs, err := grid.ExtractCRS(t.TileMatrixSet)
+	if err != nil {
+		return 0, 0, err
+	}
+	if p == nil {
+		p = grid.NewWGS84Projector(crs)
+	}
+	x, y, err := grid.GroundMetersPerUnitXY(p, crs, lon, lat)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cellSize * x, cellSize * y, nil
+}
+
+// GroundResolutionAtLatitude is GroundResolution at the central longitude of
+// the set, for projections whose distortion only depends on the latitude.
+func (t *TileMatrixSet) GroundResolutionAtLatitude(zoom int, lat float64, p grid.Projector) (float64, error) {
+	lon, err := t.centralLongitude(p)
+	if err != nil {
+		return 0, err
+	}
+	return t.GroundResolution(zoom, lon, lat, p)
+}
+
+// ScaleDenominator returns the true map scale denominator at lon/lat, from
+// the geometric mean resolution of GroundResolution, when tiles are displayed
+// at dpi; a dpi of 0 uses the OGC rendering pixel of
+// 0.28 mm. At the equator of WebMercatorQuad this matches the
+// scaleDenominator of the tile matrix.
+func (t *TileMatrixSet) ScaleDenominator(zoom int, lon, lat, dpi float64, p grid.Projector) (float64, error) {
+	pixelSize, err := pixelSizeForDPI(dpi)
+	if err != nil {
+		return 0, err
+	}
+	res, err := t.GroundResolution(zoom, lon, lat, p)
+	if err != nil {
+		return 0, err
+	}
+	return res / pixelSize, nil
+}
+
+// ScaleDenominatorAtLatitude is ScaleDenominator at the central longitude of
+// the set.
+func (t *TileMatrixSet) ScaleDenominatorAtLatitude(zoom int, lat, dpi float64, p grid.Projector) (float64, error) {
+	lon, err := t.centralLongitude(p)
+	if err != nil {
+		return 0, err
+	}
+	return t.ScaleDenominator(zoom, lon, lat, dpi, p)
+}
+
+// pixelSizeForDPI returns the rendering pixel size in meters.
+func pixelSizeForDPI(dpi float64) (float64, error) {
+	switch {
+	case dpi == 0:
+		return ogcPixelSize, nil
+	case dpi < 0:
+		return 0, fmt.Errorf("invalid dpi %v", dpi)
+	}
+	return metersPerInch / dpi, nil
+}
+
+// centralLongitude returns the longitude at the center of the lon/lat bounds
+// of the lowest zoom level.
+func (t *TileMatrixSet) centralLongitude(p grid.Projector) (float64, error) {
+	b, err := t.lonLatExtent(p)
+	if err != nil {
+		return 0, err
+	}
+	return (b.MinX + b.MaxX) / 2, nil
+}
@@ -0,0 +1,87 @@
+package gocantile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGroundResolution(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	cellSize, _ := set.ResolutionForZoom(12)
+	got, err := set.GroundResolution(12, 13.4, 60, nil)
+	if err != nil {
+		t.Fatalf("ground resolution: %v", err)
+	}
+	if want := cellSize / 2; math.Abs(got-want)/want > 1e-6 {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	got, err = set.GroundResolutionAtLatitude(12, 0, nil)
+	if err != nil {
+		t.Fatalf("ground resolution at latitude: %v", err)
+	}
+	if math.Abs(got-cellSize)/cellSize > 1e-6 {
+		t.Fatalf("got %v, want %v", got, cellSize)
+	}
+	if _, err := set.GroundResolution(99, 0, 0, nil); err == nil {
+		t.Fatalf("expected error for zoom out of range")
+	}
+
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	cellSize, _ = crs84.ResolutionForZoom(3)
+	got, err = crs84.GroundResolutionAtLatitude(3, 60, nil)
+	if err != nil {
+		t.Fatalf("ground resolution: %v", err)
+	}
+	// Pixels at 60°N are half as wide as they are high.
+	if want := cellSize * 111319.49079327357 * math.Sqrt(0.5); math.Abs(got-want)/want > 1e-6 {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	x, y, err := crs84.GroundResolutionXY(3, 0, 60, nil)
+	if err != nil {
+		t.Fatalf("ground resolution xy: %v", err)
+	}
+	if want := cellSize * 111319.49079327357; math.Abs(x-want/2)/want > 1e-6 || math.Abs(y-want)/want > 1e-6 {
+		t.Fatalf("got %v x %v, want %v x %v", x, y, want/2, want)
+	}
+
+	// Conformal sets have the same resolution along both axes.
+	x, y, err = set.GroundResolutionXY(12, 13.4, 60, nil)
+	if err != nil {
+		t.Fatalf("ground resolution xy: %v", err)
+	}
+	if math.Abs(x-y)/x > 1e-6 {
+		t.Fatalf("mercator resolutions differ: %v x %v", x, y)
+	}
+}
+
+func TestScaleDenominator(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	tm, err := set.TileMatrixForZoom(5)
+	if err != nil {
+		t.Fatalf("tile matrix: %v", err)
+	}
+	got, err := set.ScaleDenominatorAtLatitude(5, 0, 0, nil)
+	if err != nil {
+		t.Fatalf("scale: %v", err)
+	}
+	if math.Abs(got-tm.ScaleDenominator)/tm.ScaleDenominator > 1e-6 {
+		t.Fatalf("got %v, want %v", got, tm.ScaleDenominator)
+	}
+
+	// 96 dpi pixels are 0.2646 mm, so the scale grows by 0.28/0.2646;
+	// at 60°N it halves.
+	got, err = set.ScaleDenominator(5, 0, 60, 96, nil)
+	if err != nil {
+		t.Fatalf("scale: %v", err)
+	}
+	want := tm.ScaleDenominator * 0.00028 / (0.0254 / 96) / 2
+	if math.Abs(got-want)/want > 1e-6 {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := set.ScaleDenominator(5, 0, 0, -1, nil); err == nil {
+		t.Fatalf("expected error for negative dpi")
+	}
+}
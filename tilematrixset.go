@@ -9,6 +9,7 @@ import (
 	"github.com/hafenkran/gocantile/grid"
 	"github.com/hafenkran/gocantile/tms"
 	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/clip"
 )
 
 type TileMatrixSet struct {
@@ -75,6 +76,18 @@ func (t *TileMatrixSet) sortedMatrices() ([]tms.TileMatrix, error) {
 	return t.matrices, nil
 }
 
+// tileMatrix returns the grid adapter for the given zoom level.
+func (t *TileMatrixSet) tileMatrix(z int) (grid.TileMatrix, error) {
+	mats, err := t.sortedMatrices()
+	if err != nil {
+		return grid.TileMatrix{}, err
+	}
+	if z < 0 || z >= len(mats) {
+		return grid.TileMatrix{}, fmt.Errorf("zoom %d out of range", z)
+	}
+	return grid.TileMatrix{TM: mats[z]}, nil
+}
+
 func (t *TileMatrixSet) MinZoom() int {
 	mats, err := t.sortedMatrices()
 	if err != nil || len(mats) == 0 {
@@ -210,7 +223,8 @@ func (t *TileMatrixSet) TileForLonLat(lon, lat float64, zoom int, p grid.Project
 	if zoom < 0 || zoom >= len(mats) {
 		return grid.Tile{}, false, fmt.Errorf("zoom %d out of range", zoom)
 	}
-	adapter := grid.TileMatrix{TM: mats[zoom]}
+	// The projector returns easting first, whatever the axis order of the set.
+	adapter := t.eastingNorthing(grid.TileMatrix{TM: mats[zoom]})
 	idx, ok := adapter.TileForLonLat(lon, lat, p)
 	if !ok {
 		return grid.Tile{}, false, nil
@@ -218,10 +232,68 @@ func (t *TileMatrixSet) TileForLonLat(lon, lat float64, zoom int, p grid.Project
 	return grid.Tile{Zoom: zoom, TileIndex: idx}, true, nil
 }
 
+// TilePixelForLonLat returns the tile containing lon/lat at the zoom level
+// and the pixel position (i, j) within the tile image, e.g. for
+// GetFeatureInfo-style queries. It returns false outside the matrix. If p is
+// nil, a projector is created from the TMS CRS.
+func (t *TileMatrixSet) TilePixelForLonLat(lon, lat float64, zoom int, p grid.Projector) (grid.Tile, float64, float64, bool, error) {
+	if p == nil {
+		pp, err := grid.ProjectorFromTMS(t.TileMatrixSet)
+		if err != nil {
+			return grid.Tile{}, 0, 0, false, err
+		}
+		p = pp
+	}
+	adapter, err := t.tileMatrix(zoom)
+	if err != nil {
+		return grid.Tile{}, 0, 0, false, err
+	}
+	adapter = t.eastingNorthing(adapter)
+	x, y, err := p.Forward(lon, lat)
+	if err != nil {
+		return grid.Tile{}, 0, 0, false, err
+	}
+	idx, i, j, ok := adapter.TilePixelForXY(x, y)
+	if !ok {
+		return grid.Tile{}, 0, 0, false, nil
+	}
+	return grid.Tile{Zoom: zoom, TileIndex: idx}, i, j, true, nil
+}
+
 // TilesForGeometry returns tiles covering the geometry across zoom levels
 // [minZoom, maxZoom] inclusive. Optional buffer expands the geometry bounds
-// before tiling (in CRS units).
+// before tiling (in CRS units). Coordinates are in the axis order of the set,
+// as returned by XYBounds; see TilesForGeometryWithEPSG for geometries given
+// easting first.
 func (t *TileMatrixSet) TilesForGeometry(g orb.Geometry, minZoom, maxZoom int, buffer float64) (grid.TilesList, error) {
+	return t.tilesForGeometry(g, minZoom, maxZoom, buffer, false)
+}
+
+// tilesForGeometry is TilesForGeometry for coordinates easting first if
+// eastingFirst is set, as produced by projectors.
+func (t *TileMatrixSet) tilesForGeometry(g orb.Geometry, minZoom, maxZoom int, buffer float64, eastingFirst bool) (grid.TilesList, error) {
+	mats, err := t.zoomMatrices(minZoom, maxZoom)
+	if err != nil {
+		return nil, err
+	}
+
+	var tiles grid.TilesList
+	for z := minZoom; z <= maxZoom; z++ {
+		adapter := grid.TileMatrix{TM: mats[z]}
+		if eastingFirst {
+			adapter = t.eastingNorthing(adapter)
+		}
+		rangeTiles := adapter.TilesForGeometry(g, buffer)
+		for _, idx := range rangeTiles {
+			tiles = append(tiles, grid.Tile{Zoom: z, TileIndex: idx})
+		}
+	}
+	return tiles, nil
+}
+
+// zoomMatrices checks the zoom range and returns the matrices of the set
+// sorted by zoom.
+func (t *TileMatrixSet) zoomMatrices(minZoom, maxZoom int) ([]tms.TileMatrix, error) {
 	if minZoom < 0 || maxZoom < minZoom {
 		return nil, fmt.Errorf("invalid zoom range min=%d max=%d", minZoom, maxZoom)
 	}
@@ -232,30 +304,159 @@ func (t *TileMatrixSet) TilesForGeometry(g orb.Geometry, minZoom, maxZoom int, b
 	if maxZoom >= len(mats) {
 		return nil, fmt.Errorf("max zoom %d out of range", maxZoom)
 	}
+	return mats, nil
+}
 
-	var tiles grid.TilesList
+// CoverageForGeometry returns the tiles of TilesForGeometry as a Coverage.
+// Each row is added as one run, so the coverage can be built for zoom levels
+// whose tile list would not fit in memory.
+func (t *TileMatrixSet) CoverageForGeometry(g orb.Geometry, minZoom, maxZoom int, buffer float64) (*grid.Coverage, error) {
+	mats, err := t.zoomMatrices(minZoom, maxZoom)
+	if err != nil {
+		return nil, err
+	}
+	c := grid.NewCoverage()
 	for z := minZoom; z <= maxZoom; z++ {
 		adapter := grid.TileMatrix{TM: mats[z]}
-		rangeTiles := adapter.TilesForGeometry(g, buffer)
-		for _, idx := range rangeTiles {
-			tiles = append(tiles, grid.Tile{Zoom: z, TileIndex: idx})
+		tr, ok := adapter.TileRangeForGeometry(g, buffer)
+		if !ok {
+			continue
+		}
+		for r := tr.MinRow; r <= tr.MaxRow; r++ {
+			first, last := adapter.RowTileRange(tr, r)
+			c.AddRun(z, r, first, last)
 		}
 	}
-	return tiles, nil
+	return c, nil
 }
 
 // TilesForGeometryWithEPSG projects the geometry from sourceEPSG into the TMS
-// CRS and then computes tiles for the zoom range.
+// CRS and then computes tiles for the zoom range. Geometries in a geographic
+// CRS are split at the antimeridian first and each part is tiled on its own,
+// so a polygon around Fiji does not turn into a range across the globe. Rings
+// around a pole are closed along the pole, and long edges are densified before
+// projecting so that a ring around the South Pole still covers it in
+// UPSAntarcticWGS84Quad. Coordinates are easting first, i.e. lon/lat for
+// geographic CRSs, whatever the axis order of sourceEPSG or of the set.
 func (t *TileMatrixSet) TilesForGeometryWithEPSG(g orb.Geometry, sourceEPSG string, minZoom, maxZoom int, buffer float64) (grid.TilesList, error) {
+	return t.tilesForParts(sourceParts(g, sourceEPSG), sourceEPSG, minZoom, maxZoom, func(part orb.Geometry) (grid.TilesList, error) {
+		return t.tilesForGeometry(part, minZoom, maxZoom, buffer, true)
+	})
+}
+
+// sourceParts splits a geometry in a geographic CRS at the antimeridian.
+func sourceParts(g orb.Geometry, sourceCRS string) []orb.Geometry {
+	if !grid.IsGeographicCRS(sourceCRS) {
+		return []orb.Geometry{g}
+	}
+	return grid.SplitAntimeridian(g)
+}
+
+// TilesForLonLatBounds returns the tiles covering lon/lat bounds in degrees
+// across zoom levels [minZoom, maxZoom] inclusive. A west greater than east
+// crosses the antimeridian, and longitudes past ±180 are wrapped. Latitudes
+// are clamped to the extent of the set.
+func (t *TileMatrixSet) TilesForLonLatBounds(west, south, east, north float64, minZoom, maxZoom int) (grid.TilesList, error) {
+	if south > north {
+		return nil, fmt.Errorf("invalid latitudes south=%v north=%v", south, north)
+	}
+	p, err := grid.ProjectorFromTMS(t.TileMatrixSet)
+	if err != nil {
+		return nil, err
+	}
+	extent, err := t.lonLatExtent(p)
+	if err != nil {
+		return nil, err
+	}
+	south, north = max(south, extent.MinY), min(north, extent.MaxY)
+	var parts []orb.Geometry
+	if south <= north {
+		for _, b := range grid.SplitLonLatBounds(grid.Bounds{MinX: west, MinY: south, MaxX: east, MaxY: north}) {
+			parts = append(parts, grid.PolygonForBounds(b, footprintDensify))
+		}
+	}
+	return t.tilesForParts(parts, "EPSG:4326", minZoom, maxZoom, func(part orb.Geometry) (grid.TilesList, error) {
+		return t.tilesForGeometry(part, minZoom, maxZoom, 0, true)
+	})
+}
+
+// tilesForParts projects each part from sourceCRS into the TMS CRS, tiles it
+// with tile and merges the tiles.
+func (t *TileMatrixSet) tilesForParts(parts []orb.Geometry, sourceCRS string, minZoom, maxZoom int, tile func(orb.Geometry) (grid.TilesList, error)) (grid.TilesList, error) {
+	projected, err := t.projectParts(parts, sourceCRS, minZoom, maxZoom)
+	if err != nil {
+		return nil, err
+	}
+	var tiles grid.TilesList
+	for _, part := range projected {
+		partTiles, err := tile(part)
+		if err != nil {
+			return nil, err
+		}
+		tiles = append(tiles, partTiles...)
+	}
+	if len(projected) > 1 {
+		tiles.Sort()
+		tiles = tiles.Dedup()
+	}
+	return tiles, nil
+}
+
+// projectParts checks the zoom range and projects the parts from sourceCRS
+// into the TMS CRS.
+func (t *TileMatrixSet) projectParts(parts []orb.Geometry, sourceCRS string, minZoom, maxZoom int) ([]orb.Geometry, error) {
+	if minZoom < 0 || maxZoom < minZoom || maxZoom > t.MaxZoom() {
+		return nil, fmt.Errorf("invalid zoom range min=%d max=%d", minZoom, maxZoom)
+	}
 	targetCRS, err := grid.ExtractCRS(t.TileMatrixSet)
 	if err != nil {
 		return nil, err
 	}
-	projected, err := grid.ProjectGeometry(g, sourceEPSG, targetCRS)
+	if grid.IsGeographicCRS(sourceCRS) {
+		if parts, err = t.prepareLonLatParts(parts, targetCRS); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]orb.Geometry, 0, len(parts))
+	for _, part := range parts {
+		projected, err := grid.ProjectGeometry(part, sourceCRS, targetCRS)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, projected)
+	}
+	return out, nil
+}
+
+// lonLatDensifyStep is the longest lon/lat segment, in degrees, projected
+// into a non-geographic CRS without densifying.
+const lonLatDensifyStep = 1.0
+
+// prepareLonLatParts clips lon/lat parts to the latitudes of the set, where
+// e.g. Mercator cannot project the poles, and densifies them for projected
+// sets.
+func (t *TileMatrixSet) prepareLonLatParts(parts []orb.Geometry, targetCRS string) ([]orb.Geometry, error) {
+	p, err := grid.ProjectorFromTMS(t.TileMatrixSet)
 	if err != nil {
 		return nil, err
 	}
-	return t.TilesForGeometry(projected, minZoom, maxZoom, buffer)
+	extent, err := t.lonLatExtent(p)
+	if err != nil {
+		return nil, err
+	}
+	clipBound := orb.Bound{Min: orb.Point{-180, extent.MinY}, Max: orb.Point{180, extent.MaxY}}
+	out := make([]orb.Geometry, 0, len(parts))
+	for _, part := range parts {
+		part = clip.Geometry(clipBound, orb.Clone(part))
+		if part == nil {
+			continue
+		}
+		if !grid.IsGeographicCRS(targetCRS) {
+			part = grid.DensifyLonLat(part, lonLatDensifyStep)
+		}
+		out = append(out, part)
+	}
+	return out, nil
 }
 
 func parseZoom(id string) (int, error) {
@@ -276,6 +477,15 @@ func (t *TileMatrixSet) ZoomForID(id string) (int, error) {
 	return z, nil
 }
 
+// TileMatrixForZoom returns the TileMatrix at the zero-based zoom index.
+func (t *TileMatrixSet) TileMatrixForZoom(z int) (tms.TileMatrix, error) {
+	adapter, err := t.tileMatrix(z)
+	if err != nil {
+		return tms.TileMatrix{}, err
+	}
+	return adapter.TM, nil
+}
+
 // TileMatrixForID returns the TileMatrix for the given ID.
 func (t *TileMatrixSet) TileMatrixForID(id string) (tms.TileMatrix, error) {
 	mats, err := t.sortedMatrices()
@@ -291,3 +501,34 @@ func (t *TileMatrixSet) TileMatrixForID(id string) (tms.TileMatrix, error) {
 	}
 	return mats[z], nil
 }
+
+// webMercatorHalfExtent is the half width of the WebMercatorQuad bounding box.
+const webMercatorHalfExtent = 20037508.3427892
+
+// IsWebMercatorQuad reports whether the set has the WebMercatorQuad layout:
+// EPSG:3857, a single tile at zoom 0 covering the whole extent and doubling
+// matrix sizes per zoom level. Tile sizes other than 256 are accepted. Tile
+// formats such as MBTiles and PMTiles are only defined for this layout.
+func (t *TileMatrixSet) IsWebMercatorQuad() bool {
+	crs, err := grid.ExtractCRS(t.TileMatrixSet)
+	if err != nil || crs != "EPSG:3857" {
+		return false
+	}
+	mats, err := t.sortedMatrices()
+	if err != nil || len(mats) == 0 {
+		return false
+	}
+	tol := 1e-6 * webMercatorHalfExtent
+	for z, tm := range mats {
+		adapter := grid.TileMatrix{TM: tm}
+		n := 1 << z
+		if width, height := adapter.MatrixSize(); width != n || height != n || len(tm.VariableMatrixWidths) > 0 {
+			return false
+		}
+		b, err := adapter.BoundsForTile(grid.TileIndex{})
+		if err != nil || math.Abs(b.MinX+webMercatorHalfExtent) > tol || math.Abs(b.MaxY-webMercatorHalfExtent) > tol {
+			return false
+		}
+	}
+	return true
+}
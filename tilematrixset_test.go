@@ -1,6 +1,7 @@
 package gocantile
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/hafenkran/gocantile/tms"
@@ -336,3 +337,268 @@ func TestTileMatrixSetDuplicateIDError(t *testing.T) {
 		t.Fatalf("expected resolution error due to duplicate ids")
 	}
 }
+
+func TestTileMatrixSetTileMatrixForZoom(t *testing.T) {
+	tms := loadWebMercatorQuad(t)
+
+	tm, err := tms.TileMatrixForZoom(3)
+	if err != nil {
+		t.Fatalf("tile matrix err: %v", err)
+	}
+	if tm.Id != "3" || tm.MatrixWidth != 8 {
+		t.Fatalf("unexpected tile matrix %s %vx%v", tm.Id, tm.MatrixWidth, tm.MatrixHeight)
+	}
+	if _, err := tms.TileMatrixForZoom(tms.MaxZoom() + 1); err == nil {
+		t.Fatalf("expected error for zoom out of range")
+	}
+}
+
+func TestTileMatrixSetIsWebMercatorQuad(t *testing.T) {
+	if !loadWebMercatorQuad(t).IsWebMercatorQuad() {
+		t.Fatalf("expected WebMercatorQuad to be detected")
+	}
+	for _, id := range []string{"WorldCRS84Quad", "WorldMercatorWGS84Quad"} {
+		set, err := LoadTileMatrixSet(id)
+		if err != nil {
+			t.Fatalf("load %s: %v", id, err)
+		}
+		if set.IsWebMercatorQuad() {
+			t.Fatalf("%s detected as WebMercatorQuad", id)
+		}
+	}
+}
+
+func TestTileMatrixSetTilePixelForLonLat(t *testing.T) {
+	tms := loadWebMercatorQuad(t)
+	// Null Island is the top-left pixel of tile 1/1/1.
+	tile, i, j, ok, err := tms.TilePixelForLonLat(0.001, -0.001, 1, nil)
+	if err != nil || !ok {
+		t.Fatalf("expected tile, ok=%v err=%v", ok, err)
+	}
+	if tile.Zoom != 1 || tile.Col != 1 || tile.Row != 1 || int(i) != 0 || int(j) != 0 || i <= 0 || j <= 0 {
+		t.Fatalf("unexpected tile pixel %+v %v,%v", tile, i, j)
+	}
+	if _, _, _, _, err := tms.TilePixelForLonLat(0, 0, 99, nil); err == nil {
+		t.Fatalf("expected error for zoom out of range")
+	}
+
+	// WGS1984Quad lists the latitude first; lon/lat still picks the tile
+	// east of the antimeridian and north of the equator.
+	set, err := LoadTileMatrixSet("WGS1984Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	want := Tile{Zoom: 2, TileIndex: TileIndex{Col: 4, Row: 1}}
+	tile, i, j, ok, err = set.TilePixelForLonLat(10, 10, 2, nil)
+	if err != nil || !ok || tile != want || i < 0 || i >= 256 || j < 0 || j >= 256 {
+		t.Fatalf("WGS1984Quad tile pixel = %+v %v,%v ok=%v err=%v; want %+v", tile, i, j, ok, err, want)
+	}
+	tile, ok, err = set.TileForLonLat(10, 10, 2, nil)
+	if err != nil || !ok || tile != want {
+		t.Fatalf("WGS1984Quad tile = %+v ok=%v err=%v; want %+v", tile, ok, err, want)
+	}
+}
+
+func TestCoverageForGeometry(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	poly := orb.Polygon{{{-1e6, -5e5}, {3e6, -5e5}, {3e6, 2e6}, {-1e6, 2e6}, {-1e6, -5e5}}}
+	tiles, err := set.TilesForGeometry(poly, 4, 9, 0)
+	if err != nil {
+		t.Fatalf("tiles: %v", err)
+	}
+	c, err := set.CoverageForGeometry(poly, 4, 9, 0)
+	if err != nil {
+		t.Fatalf("coverage: %v", err)
+	}
+	tiles.Sort()
+	if got := c.TilesList(); !reflect.DeepEqual(got, tiles) {
+		t.Fatalf("coverage differs from TilesForGeometry: %d vs %d tiles", len(got), len(tiles))
+	}
+	if _, err := set.CoverageForGeometry(poly, 5, 4, 0); err == nil {
+		t.Fatalf("expected error for an invalid zoom range")
+	}
+
+	// Runs of coalesced rows hold tile columns, as in TilesForGeometry.
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	world := orb.LineString{{-1000, -1000}, {1000, 1000}}
+	tiles, err = gnosis.TilesForGeometry(world, 0, 3, 0)
+	if err != nil {
+		t.Fatalf("tiles: %v", err)
+	}
+	if c, err = gnosis.CoverageForGeometry(world, 0, 3, 0); err != nil {
+		t.Fatalf("coverage: %v", err)
+	}
+	tiles.Sort()
+	if got := c.TilesList(); len(tiles) == 0 || !reflect.DeepEqual(got, tiles) {
+		t.Fatalf("GNOSIS coverage differs from TilesForGeometry: %d vs %d tiles", len(got), len(tiles))
+	}
+}
+
+func TestTilesForLonLatBoundsAntimeridian(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	want := TilesList{
+		{Zoom: 3, TileIndex: TileIndex{Col: 0, Row: 4}},
+		{Zoom: 3, TileIndex: TileIndex{Col: 7, Row: 4}},
+	}
+	// Fiji, given with west > east and with eastings past 180°.
+	for _, b := range [][4]float64{{177, -19, -178, -16}, {177, -19, 182, -16}} {
+		tiles, err := set.TilesForLonLatBounds(b[0], b[1], b[2], b[3], 3, 3)
+		if err != nil {
+			t.Fatalf("TilesForLonLatBounds(%v): %v", b, err)
+		}
+		if !reflect.DeepEqual(tiles, want) {
+			t.Fatalf("TilesForLonLatBounds(%v) = %v, want %v", b, tiles, want)
+		}
+	}
+	// Latitudes beyond the Mercator extent are clamped.
+	tiles, err := set.TilesForLonLatBounds(-180, -90, 180, 90, 1, 1)
+	if err != nil || len(tiles) != 4 {
+		t.Fatalf("world bounds = %v, %v", tiles, err)
+	}
+	if _, err := set.TilesForLonLatBounds(0, 10, 1, 0, 1, 1); err == nil {
+		t.Fatal("expected error for south > north")
+	}
+}
+
+func TestTilesForGeometryWithEPSGAntimeridian(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	fiji := orb.Polygon{{{177, -16}, {-178, -16}, {-178, -19}, {177, -19}, {177, -16}}}
+	tiles, err := set.TilesForGeometryWithEPSG(fiji, "EPSG:4326", 3, 4, 0)
+	if err != nil {
+		t.Fatalf("TilesForGeometryWithEPSG: %v", err)
+	}
+	want := TilesList{
+		{Zoom: 3, TileIndex: TileIndex{Col: 0, Row: 4}},
+		{Zoom: 3, TileIndex: TileIndex{Col: 7, Row: 4}},
+		{Zoom: 4, TileIndex: TileIndex{Col: 0, Row: 8}},
+		{Zoom: 4, TileIndex: TileIndex{Col: 15, Row: 8}},
+	}
+	if !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("tiles = %v, want %v", tiles, want)
+	}
+
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	tiles, err = crs84.TilesForGeometryWithEPSG(fiji, "EPSG:4326", 2, 2, 0)
+	if err != nil {
+		t.Fatalf("TilesForGeometryWithEPSG: %v", err)
+	}
+	want = TilesList{
+		{Zoom: 2, TileIndex: TileIndex{Col: 0, Row: 2}},
+		{Zoom: 2, TileIndex: TileIndex{Col: 7, Row: 2}},
+	}
+	if !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("CRS84 tiles = %v, want %v", tiles, want)
+	}
+}
+
+func TestTilesForGeometryWithEPSGPolar(t *testing.T) {
+	// A ring around the South Pole at 60°S and the same cap drawn as bounds.
+	ring := orb.Polygon{{{0, -60}, {90, -60}, {180, -60}, {-90, -60}, {0, -60}}}
+	cap := orb.Polygon{{{-180, -90}, {180, -90}, {180, -60}, {-180, -60}, {-180, -90}}}
+
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	for _, g := range []orb.Polygon{ring, cap} {
+		tiles, err := crs84.TilesForGeometryWithEPSG(g, "EPSG:4326", 3, 3, 0)
+		if err != nil {
+			t.Fatalf("CRS84: %v", err)
+		}
+		// Rows 6 and 7 hold 60°S to the pole, 16 tiles each.
+		if len(tiles) != 32 || tiles[0].Row != 6 || tiles[31].Row != 7 {
+			t.Fatalf("CRS84 cap tiles = %v", tiles)
+		}
+	}
+
+	// In GNOSISGlobalGrid the polar row of zoom 1 is coalesced into 4 tiles.
+	gnosis, err := LoadTileMatrixSet("GNOSISGlobalGrid")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	tiles, err := gnosis.TilesForGeometryWithEPSG(ring, "EPSG:4326", 1, 1, 0)
+	if err != nil {
+		t.Fatalf("GNOSIS: %v", err)
+	}
+	want := TilesList{
+		{Zoom: 1, TileIndex: TileIndex{Col: 0, Row: 3}},
+		{Zoom: 1, TileIndex: TileIndex{Col: 1, Row: 3}},
+		{Zoom: 1, TileIndex: TileIndex{Col: 2, Row: 3}},
+		{Zoom: 1, TileIndex: TileIndex{Col: 3, Row: 3}},
+	}
+	if !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("GNOSIS cap tiles = %v, want %v", tiles, want)
+	}
+
+	// In UPSAntarcticWGS84Quad the pole lies on the corner of four tiles at
+	// zoom 3, and a cap down to 80°S stays within them.
+	ups, err := LoadTileMatrixSet("UPSAntarcticWGS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	want = TilesList{
+		{Zoom: 3, TileIndex: TileIndex{Col: 3, Row: 3}},
+		{Zoom: 3, TileIndex: TileIndex{Col: 4, Row: 3}},
+		{Zoom: 3, TileIndex: TileIndex{Col: 3, Row: 4}},
+		{Zoom: 3, TileIndex: TileIndex{Col: 4, Row: 4}},
+	}
+	ring80 := orb.Polygon{{{-180, -80}, {-90, -80}, {0, -80}, {90, -80}, {180, -80}, {-180, -80}}}
+	cap80 := orb.Polygon{{{-180, -90}, {180, -90}, {180, -80}, {-180, -80}, {-180, -90}}}
+	for _, g := range []orb.Polygon{ring80, cap80} {
+		tiles, err := ups.TilesForGeometryWithEPSG(g, "EPSG:4326", 3, 3, 0)
+		if err != nil {
+			t.Fatalf("UPS: %v", err)
+		}
+		if !reflect.DeepEqual(tiles, want) {
+			t.Fatalf("UPS cap tiles = %v, want %v", tiles, want)
+		}
+	}
+	tiles, err = ups.TilesForLonLatBounds(-180, -90, 180, -80, 3, 3)
+	if err != nil || !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("UPS bounds tiles = %v, %v", tiles, err)
+	}
+}
+
+func TestTilesForGeometryNorthingFirst(t *testing.T) {
+	// WGS1984Quad lists the latitude first. TilesForGeometry takes the axis
+	// order of the set like XYBounds, TilesForGeometryWithEPSG takes lon/lat.
+	set, err := LoadTileMatrixSet("WGS1984Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	want := TilesList{{Zoom: 1, TileIndex: TileIndex{Col: 2, Row: 0}}}
+	tiles, err := set.TilesForGeometryWithEPSG(orb.Point{10, 50}, "EPSG:4326", 1, 1, 0)
+	if err != nil || !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("lon/lat tiles = %v, %v; want %v", tiles, err, want)
+	}
+	tiles, err = set.TilesForGeometryWithOptions(orb.Point{10, 50}, 1, 1, CoverageOptions{SourceCRS: "EPSG:4326"})
+	if err != nil || !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("lon/lat options tiles = %v, %v; want %v", tiles, err, want)
+	}
+
+	// The center of XYBounds fed back in the axis order of the set gives
+	// the tile.
+	b, err := set.XYBounds(want[0])
+	if err != nil {
+		t.Fatalf("bounds: %v", err)
+	}
+	center := orb.Point{(b.MinX + b.MaxX) / 2, (b.MinY + b.MaxY) / 2}
+	tiles, err = set.TilesForGeometry(center, 1, 1, 0)
+	if err != nil || !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("tiles at bounds center = %v, %v; want %v", tiles, err, want)
+	}
+	tiles, err = set.TilesForGeometryWithOptions(center, 1, 1, CoverageOptions{Buffer: 1000, BufferUnit: BufferMeters})
+	if err != nil || !reflect.DeepEqual(tiles, want) {
+		t.Fatalf("options tiles at bounds center = %v, %v; want %v", tiles, err, want)
+	}
+	c, err := set.CoverageForGeometry(center, 1, 1, 0)
+	if err != nil || c.Count() != 1 || !c.Contains(want[0]) {
+		t.Fatalf("coverage at bounds center = %v, %v", c, err)
+	}
+}
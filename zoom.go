@@ -0,0 +1,135 @@
+package gocantile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hafenkran/gocantile/grid"
+)
+
+// ZoomStrategy selects a zoom level for a resolution that falls between two
+// tile matrices.
+type ZoomStrategy string
+
+const (
+	// ZoomLower picks the coarser matrix, i.e. the lower zoom level.
+	ZoomLower ZoomStrategy = "lower"
+	// ZoomUpper picks the finer matrix, i.e. the higher zoom level, so that
+	// no detail of the source is lost.
+	ZoomUpper ZoomStrategy = "upper"
+	// ZoomNearest picks the matrix whose cell size differs least from the
+	// resolution.
+	ZoomNearest ZoomStrategy = "nearest"
+	// ZoomAuto picks the matrix whose cell size is closest by ratio, which
+	// for quad trees rounds at the geometric mean of the two cell sizes.
+	ZoomAuto ZoomStrategy = "auto"
+)
+
+// ErrResolutionOutOfRange is returned when a resolution is finer than the
+// highest or coarser than the lowest zoom level of a set.
+var ErrResolutionOutOfRange = errors.New("resolution out of range")
+
+// zoomResolutionTolerance is the relative difference under which a resolution
+// matches a cell size exactly.
+const zoomResolutionTolerance = 1e-8
+
+// ResolutionOptions controls ZoomForNativeResolution.
+type ResolutionOptions struct {
+	// Strategy defaults to ZoomAuto.
+	Strategy ZoomStrategy
+	// CRS is the CRS the resolution is given in, e.g. "EPSG:32632" for a
+	// raster in meters. Empty means the CRS of the set.
+	CRS string
+	// Lon and Lat locate the reference point where a resolution in another
+	// CRS is converted, usually the center of the raster.
+	Lon, Lat float64
+}
+
+// ZoomForNativeResolution returns the zoom level for a native resolution in
+// CRS units per pixel. Resolutions in another CRS are converted through the
+// ground distance at the reference point, so 10 m at 60°N map to a cell size
+// of 20 in WebMercatorQuad. Unlike ZoomForResolution it returns
+// ErrResolutionOutOfRange instead of falling back to zoom 0.
+func (t *TileMatrixSet) ZoomForNativeResolution(res float64, opts ResolutionOptions) (int, error) {
+	if !(res > 0) || math.IsInf(res, 0) {
+		return 0, fmt.Errorf("invalid resolution %v", res)
+	}
+	switch opts.Strategy {
+	case ZoomLower, ZoomUpper, ZoomNearest, ZoomAuto, "":
+	default:
+		return 0, fmt.Errorf("unknown zoom strategy %q", opts.Strategy)
+	}
+	mats, err := t.sortedMatrices()
+	if err != nil {
+		return 0, err
+	}
+	if len(mats) == 0 {
+		return 0, fmt.Errorf("no tile matrices")
+	}
+	if opts.CRS != "" {
+		if res, err = t.convertResolution(res, opts); err != nil {
+			return 0, err
+		}
+	}
+
+	finest, coarsest := mats[len(mats)-1].CellSize, mats[0].CellSize
+	if res < finest*(1-zoomResolutionTolerance) || res > coarsest*(1+zoomResolutionTolerance) {
+		return 0, fmt.Errorf("%w: %v not within [%v, %v]", ErrResolutionOutOfRange, res, finest, coarsest)
+	}
+
+	// Find the first matrix at least as fine as the resolution; the one
+	// before it is the coarser neighbour.
+	upper := 0
+	for upper < len(mats)-1 {
+		cs := mats[upper].CellSize
+		if res >= cs || math.Abs(res-cs)/cs <= zoomResolutionTolerance {
+			break
+		}
+		upper++
+	}
+	if math.Abs(res-mats[upper].CellSize)/mats[upper].CellSize <= zoomResolutionTolerance || upper == 0 {
+		return upper, nil
+	}
+	lower := upper - 1
+	lowerCS, upperCS := mats[lower].CellSize, mats[upper].CellSize
+
+	switch opts.Strategy {
+	case ZoomLower:
+		return lower, nil
+	case ZoomUpper:
+		return upper, nil
+	case ZoomNearest:
+		if lowerCS-res < res-upperCS {
+			return lower, nil
+		}
+		return upper, nil
+	default:
+		if lowerCS/res < res/upperCS {
+			return lower, nil
+		}
+		return upper, nil
+	}
+}
+
+// convertResolution converts a resolution in opts.CRS units to units of the
+// set CRS at the reference point.
+func (t *TileMatrixSet) convertResolution(res float64, opts ResolutionOptions) (float64, error) {
+	crs, err := grid.ExtractCRS(t.TileMatrixSet)
+	if err != nil {
+		return 0, err
+	}
+	if strings.EqualFold(opts.CRS, crs) {
+		return res, nil
+	}
+	src, err := grid.GroundMetersPerUnit(grid.NewWGS84Projector(opts.CRS), opts.CRS, opts.Lon, opts.Lat)
+	if err != nil {
+		return 0, fmt.Errorf("resolution CRS %s: %w", opts.CRS, err)
+	}
+	dst, err := grid.GroundMetersPerUnit(grid.NewWGS84Projector(crs), crs, opts.Lon, opts.Lat)
+	if err != nil {
+		return 0, fmt.Errorf("tile matrix set CRS %s: %w", crs, err)
+	}
+	return res * src / dst, nil
+}
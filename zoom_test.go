@@ -0,0 +1,85 @@
+package gocantile
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestZoomForNativeResolution(t *testing.T) {
+	set := loadWebMercatorQuad(t)
+	res10, _ := set.ResolutionForZoom(10)
+	res11, _ := set.ResolutionForZoom(11)
+
+	cases := []struct {
+		res      float64
+		strategy ZoomStrategy
+		want     int
+	}{
+		{res10, ZoomLower, 10},
+		{res10, ZoomUpper, 10},
+		{res10 * 0.9, ZoomLower, 10},
+		{res10 * 0.9, ZoomUpper, 11},
+		{res10 * 0.9, ZoomNearest, 10},
+		{res10 * 0.9, ZoomAuto, 10},
+		// 0.72 lies between the geometric mean (~0.707) and the
+		// arithmetic mean (0.75) of the neighbouring cell sizes.
+		{res10 * 0.72, ZoomNearest, 11},
+		{res10 * 0.72, ZoomAuto, 10},
+		{res10 * 0.7, ZoomAuto, 11},
+		{res11 * 1.0000000001, ZoomUpper, 11},
+	}
+	for _, c := range cases {
+		got, err := set.ZoomForNativeResolution(c.res, ResolutionOptions{Strategy: c.strategy})
+		if err != nil {
+			t.Fatalf("%v %s: %v", c.res, c.strategy, err)
+		}
+		if got != c.want {
+			t.Fatalf("%v %s: got zoom %d, want %d", c.res, c.strategy, got, c.want)
+		}
+	}
+
+	finest, _ := set.ResolutionForZoom(set.MaxZoom())
+	coarsest, _ := set.ResolutionForZoom(0)
+	for _, res := range []float64{finest / 2, coarsest * 2} {
+		if _, err := set.ZoomForNativeResolution(res, ResolutionOptions{}); !errors.Is(err, ErrResolutionOutOfRange) {
+			t.Fatalf("%v: expected ErrResolutionOutOfRange, got %v", res, err)
+		}
+	}
+	if _, err := set.ZoomForNativeResolution(0, ResolutionOptions{}); err == nil {
+		t.Fatalf("expected error for zero resolution")
+	}
+	if _, err := set.ZoomForNativeResolution(res10, ResolutionOptions{Strategy: "round"}); err == nil {
+		t.Fatalf("expected error for unknown strategy")
+	}
+}
+
+func TestZoomForNativeResolutionCRS(t *testing.T) {
+	// A raster in degrees against WebMercatorQuad: at 60°N a pixel of
+	// res10 meters on the ground needs a cell size of 2*res10.
+	set := loadWebMercatorQuad(t)
+	res10, _ := set.ResolutionForZoom(10)
+	deg := res10 / 2 / (111319.49079327357 / 2)
+	z, err := set.ZoomForNativeResolution(deg, ResolutionOptions{Strategy: ZoomUpper, CRS: "EPSG:4326", Lat: 60})
+	if err != nil {
+		t.Fatalf("zoom: %v", err)
+	}
+	if z != 10 {
+		t.Fatalf("got zoom %d, want 10", z)
+	}
+
+	// Web Mercator meters against a degree-based set: at 60°N one unit
+	// is half a meter on the ground, as is a meter per degree.
+	crs84, err := LoadTileMatrixSet("WorldCRS84Quad")
+	if err != nil {
+		t.Fatalf("load TMS: %v", err)
+	}
+	cs5, _ := crs84.ResolutionForZoom(5)
+	meters := cs5 * 111319.49079327357
+	z, err = crs84.ZoomForNativeResolution(meters, ResolutionOptions{Strategy: ZoomLower, CRS: "EPSG:3857", Lat: 60})
+	if err != nil {
+		t.Fatalf("zoom: %v", err)
+	}
+	if z != 5 {
+		t.Fatalf("got zoom %d, want 5", z)
+	}
+}